@@ -22,6 +22,7 @@ import (
 	infranetworkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
 	"github.com/openstack-k8s-operators/lib-common/modules/storage"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // AnsibleVarsFromSource represents the source of a set of ConfigMaps/Secrets
@@ -161,3 +162,95 @@ type AnsibleEESpec struct {
 	// default serviceaccount
 	ServiceAccountName string
 }
+
+// RetryPolicy defines how failed ansible executions are retried
+type RetryPolicy struct {
+	// MaxRetries number of times a failed ansible execution is retried
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=0
+	MaxRetries int `json:"maxRetries,omitempty" yaml:"maxRetries,omitempty"`
+
+	// InitialBackoffSeconds time to wait before the first retry
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:default:=30
+	InitialBackoffSeconds int `json:"initialBackoffSeconds,omitempty" yaml:"initialBackoffSeconds,omitempty"`
+
+	// BackoffMultiplier factor the backoff is multiplied by after each retry.
+	// The backoff is capped at one hour.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:default:=2
+	BackoffMultiplier int `json:"backoffMultiplier,omitempty" yaml:"backoffMultiplier,omitempty"`
+}
+
+// AnsibleExecutionTimedOut is the JobStatus recorded for an attempt which was
+// stopped because it exceeded its timeout
+const AnsibleExecutionTimedOut = "TimedOut"
+
+// AnsibleExecutionAttempt records an attempt to run the ansible execution of
+// a service
+type AnsibleExecutionAttempt struct {
+	// Attempt number, starting at 1
+	Attempt int `json:"attempt"`
+
+	// Batch of nodes the attempt was deployed on, when using a rollout strategy
+	Batch int `json:"batch,omitempty"`
+
+	// Name of the OpenStackAnsibleEE
+	Name string `json:"name"`
+
+	// JobStatus of the OpenStackAnsibleEE
+	JobStatus string `json:"jobStatus,omitempty"`
+
+	// StartTime of the attempt
+	StartTime metav1.Time `json:"startTime,omitempty"`
+
+	// FinishTime of the attempt, once it succeeded or failed
+	FinishTime *metav1.Time `json:"finishTime,omitempty"`
+
+	// Failure of the attempt, the reason its pod failed and the end of its
+	// output, once it failed
+	Failure string `json:"failure,omitempty"`
+}
+
+// DeploymentRun records a previous run of a Deployment
+type DeploymentRun struct {
+	// Run number, 0 for the first run
+	Run int `json:"run"`
+
+	// RerunToken of the rerun annotation the run was started for
+	RerunToken string `json:"rerunToken,omitempty"`
+
+	// StartTime of the run
+	StartTime metav1.Time `json:"startTime,omitempty"`
+
+	// FinishTime of the run
+	FinishTime *metav1.Time `json:"finishTime,omitempty"`
+
+	// Outcome of the run, Succeeded, Failed or Cancelled
+	Outcome string `json:"outcome,omitempty"`
+
+	// Results name of the ConfigMap holding the ansible results of the run
+	Results string `json:"results,omitempty"`
+
+	// CheckReport name of the ConfigMap holding the check report of the run
+	CheckReport string `json:"checkReport,omitempty"`
+}
+
+// AnsibleServiceResult summarizes the ansible results of a service on the
+// hosts of a NodeSet, the results of each host are in the ConfigMap referenced
+// by the Deployment status
+type AnsibleServiceResult struct {
+	// Hosts number of hosts the service ran on
+	Hosts int `json:"hosts"`
+
+	// ChangedHosts number of hosts with changed tasks
+	ChangedHosts int `json:"changedHosts,omitempty"`
+
+	// FailedHosts hosts with failed tasks
+	FailedHosts []string `json:"failedHosts,omitempty"`
+
+	// UnreachableHosts hosts which were unreachable
+	UnreachableHosts []string `json:"unreachableHosts,omitempty"`
+}
@@ -21,6 +21,36 @@ import (
 )
 
 const (
+	// RetryingReason - a failed ansible execution is going to be retried
+	RetryingReason condition.Reason = "Retrying"
+
+	// TimedOutReason - an ansible execution or the Deployment exceeded its
+	// timeout
+	TimedOutReason condition.Reason = "TimedOut"
+
+	// PausedReason - the Deployment is paused and does not start new ansible
+	// executions
+	PausedReason condition.Reason = "Paused"
+
+	// CancelledReason - the Deployment was cancelled
+	CancelledReason condition.Reason = "Cancelled"
+
+	// WaitingForMaintenanceWindowReason - no ansible execution is started on
+	// the NodeSet until its next maintenance window
+	WaitingForMaintenanceWindowReason condition.Reason = "WaitingForMaintenanceWindow"
+
+	// WaitingForDeploymentReason - the Deployment does not start until the
+	// older Deployments of its NodeSets are finished
+	WaitingForDeploymentReason condition.Reason = "WaitingForDeployment"
+
+	// WaitingForApprovalReason - the Deployment does not execute its plan
+	// until it is approved
+	WaitingForApprovalReason condition.Reason = "WaitingForApproval"
+
+	// QueuedReason - the ansible execution is not started until the number of
+	// running ansible executions is below the limits of the operator
+	QueuedReason condition.Reason = "Queued"
+
 	// DataPlaneNodeSetErrorMessage error
 	DataPlaneNodeSetErrorMessage = "DataPlaneNodeSet error occurred %s"
 
@@ -89,6 +119,23 @@ const (
 	// NodeSet Deployment is finished and successful.
 	NodeSetDeploymentReadyCondition condition.Type = "NodeSetDeploymentReady"
 
+	// NodeSetCanaryDeploymentReadyCondition Status=True condition indicates
+	// the canary nodes of the NodeSet were deployed successfully.
+	NodeSetCanaryDeploymentReadyCondition condition.Type = "NodeSetCanaryDeploymentReady"
+
+	// NodeSetCanaryDeploymentReadyMessage ready
+	NodeSetCanaryDeploymentReadyMessage = "Canary Deployment ready on %s"
+
+	// NodeSetCanaryDeploymentReadyWaitingMessage not yet ready
+	NodeSetCanaryDeploymentReadyWaitingMessage = "Canary Deployment not yet ready on %s"
+
+	// NodeSetCanaryDeploymentErrorMessage error
+	NodeSetCanaryDeploymentErrorMessage = "Canary Deployment error occurred on %s %s"
+
+	// NodeSetBatchDeploymentWaitingForApprovalMessage waiting for the canary
+	// approval
+	NodeSetBatchDeploymentWaitingForApprovalMessage = "Batch %d Deployment waiting for the canary approval"
+
 	// NodeSetDeploymentReadyMessage ready
 	NodeSetDeploymentReadyMessage = "Deployment ready for NodeSet"
 
@@ -98,6 +145,12 @@ const (
 	// NodeSetDeploymentErrorMessage error
 	NodeSetDeploymentErrorMessage = "Deployment error occurred %s for NodeSet"
 
+	// NodeSetDeploymentWaitingForStageMessage waiting for the previous stage
+	NodeSetDeploymentWaitingForStageMessage = "Deployment waiting for NodeSets %s"
+
+	// NodeSetDeploymentStageFailedMessage previous stage failed
+	NodeSetDeploymentStageFailedMessage = "Deployment not started, NodeSets %s failed"
+
 	// NodeSetServiceDeploymentReadyMessage ready
 	NodeSetServiceDeploymentReadyMessage = "%s Deployment ready"
 
@@ -106,4 +159,93 @@ const (
 
 	// NodeSetServiceDeploymentErrorMessage error
 	NodeSetServiceDeploymentErrorMessage = "%s Deployment error occurred"
+
+	// NodeSetBatchDeploymentReadyMessage ready
+	NodeSetBatchDeploymentReadyMessage = "Batch %d Deployment ready"
+
+	// NodeSetBatchDeploymentReadyWaitingMessage not yet ready
+	NodeSetBatchDeploymentReadyWaitingMessage = "Batch %d Deployment not yet ready"
+
+	// NodeSetBatchDeploymentInitMessage not yet started
+	NodeSetBatchDeploymentInitMessage = "Batch %d Deployment not yet started"
+
+	// NodeSetBatchDeploymentPausedMessage paused before starting
+	NodeSetBatchDeploymentPausedMessage = "Batch %d Deployment paused until %s"
+
+	// NodeSetBatchDeploymentErrorMessage error
+	NodeSetBatchDeploymentErrorMessage = "Batch %d Deployment error occurred %s"
+
+	// NodeSetServiceDeploymentRetryingMessage failed, waiting to retry
+	NodeSetServiceDeploymentRetryingMessage = "%s Deployment attempt %d failed, retrying at %s"
+
+	// NodeSetServiceDeploymentRetryErrorMessage error after all retries
+	NodeSetServiceDeploymentRetryErrorMessage = "%s Deployment error occurred after %d attempts"
+
+	// NodeSetServiceDeploymentTimedOutMessage execution exceeded its timeout
+	NodeSetServiceDeploymentTimedOutMessage = "%s Deployment timed out, execution %s stopped"
+
+	// DeploymentTimedOutMessage Deployment exceeded its timeout
+	DeploymentTimedOutMessage = "Deployment timed out after %s"
+
+	// NodeSetServiceDeploymentPausedMessage not started, Deployment paused
+	NodeSetServiceDeploymentPausedMessage = "%s Deployment paused"
+
+	// NodeSetServiceDeploymentCancelledMessage Deployment cancelled
+	NodeSetServiceDeploymentCancelledMessage = "%s Deployment cancelled"
+
+	// NodeSetServiceDeploymentWaitingForWindowMessage not started, outside
+	// of the NodeSet maintenance windows
+	NodeSetServiceDeploymentWaitingForWindowMessage = "%s Deployment waiting for the maintenance window starting at %s"
+
+	// NodeSetServiceDeploymentQueuedMessage not started, too many ansible
+	// executions running
+	NodeSetServiceDeploymentQueuedMessage = "%s Deployment queued at position %d, waiting for running executions to finish"
+
+	// NodeSetDeploymentWaitingForWindowMessage outside of the NodeSet
+	// maintenance windows
+	NodeSetDeploymentWaitingForWindowMessage = "Deployment waiting for the maintenance window starting at %s"
+
+	// DeploymentWaitingForDeploymentsMessage older Deployments of the same
+	// NodeSets in progress
+	DeploymentWaitingForDeploymentsMessage = "Deployment waiting for Deployments %s of the same NodeSets to finish"
+
+	// NodeSetDeploymentWaitingForDeploymentsMessage older Deployments of the
+	// NodeSet in progress
+	NodeSetDeploymentWaitingForDeploymentsMessage = "Deployment waiting for Deployments %s of the NodeSet to finish"
+
+	// DeploymentWaitingForApprovalMessage Deployment requiring approval not
+	// approved yet
+	DeploymentWaitingForApprovalMessage = "Deployment waiting for the approval of its plan %s"
+
+	// DeploymentPlanChangedMessage Deployment approved for a plan which is
+	// not its current plan
+	DeploymentPlanChangedMessage = "Deployment approved for plan %s, its plan changed to %s and waits for approval"
+
+	// DeploymentPausedMessage Deployment paused
+	DeploymentPausedMessage = "Deployment paused"
+
+	// DeploymentCancelledMessage Deployment cancelled
+	DeploymentCancelledMessage = "Deployment cancelled"
+
+	// NodeSetServiceDeploymentResumedMessage ready in the resumed Deployment
+	NodeSetServiceDeploymentResumedMessage = "%s Deployment ready, resumed from %s"
+
+	// ResumeFromErrorMessage error
+	ResumeFromErrorMessage = "Error resuming from Deployment %s: %s"
+
+	// NodeSetServiceDependencyErrorMessage error
+	NodeSetServiceDependencyErrorMessage = "Service dependency error occurred %s"
+
+	// DeploymentScheduleReadyCondition Status=True condition indicates the
+	// schedule is valid and Deployments are created on schedule.
+	DeploymentScheduleReadyCondition condition.Type = "DeploymentScheduleReady"
+
+	// DeploymentScheduleReadyMessage ready
+	DeploymentScheduleReadyMessage = "Next Deployment scheduled at %s"
+
+	// DeploymentScheduleSuspendedMessage suspended
+	DeploymentScheduleSuspendedMessage = "Schedule suspended"
+
+	// DeploymentScheduleErrorMessage error
+	DeploymentScheduleErrorMessage = "Schedule error occurred %s"
 )
@@ -0,0 +1,85 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	// Wednesday 14 February 2024
+	now := time.Date(2024, time.February, 14, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		spec string
+		want time.Time
+	}{
+		{"every minute", "* * * * *", time.Date(2024, time.February, 14, 10, 8, 0, 0, time.UTC)},
+		{"every 15 minutes", "*/15 * * * *", time.Date(2024, time.February, 14, 10, 15, 0, 0, time.UTC)},
+		{"every 15 minutes from 5", "5/15 * * * *", time.Date(2024, time.February, 14, 10, 20, 0, 0, time.UTC)},
+		{"list", "0 8,12 * * *", time.Date(2024, time.February, 14, 12, 0, 0, 0, time.UTC)},
+		{"range with step", "0 0-6/3 * * *", time.Date(2024, time.February, 15, 0, 0, 0, 0, time.UTC)},
+		{"daily", "@daily", time.Date(2024, time.February, 15, 0, 0, 0, 0, time.UTC)},
+		{"monthly", "@monthly", time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{"month name", "0 0 1 jun *", time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"Sunday as 0", "0 2 * * 0", time.Date(2024, time.February, 18, 2, 0, 0, 0, time.UTC)},
+		{"Sunday as 7", "0 2 * * 7", time.Date(2024, time.February, 18, 2, 0, 0, 0, time.UTC)},
+		{"day name", "0 2 * * sat", time.Date(2024, time.February, 17, 2, 0, 0, 0, time.UTC)},
+		{"weekday range through Sunday", "0 2 * * 5-7", time.Date(2024, time.February, 16, 2, 0, 0, 0, time.UTC)},
+		// A day matching either the day of month or the day of week is enough
+		{"day of month or day of week", "0 0 20 * mon", time.Date(2024, time.February, 19, 0, 0, 0, 0, time.UTC)},
+		{"31 February", "0 0 31 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCronSchedule(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schedule.Next(now); !got.Equal(tt.want) {
+				t.Errorf("Next() of %q = %s, want %s", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCronScheduleErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr string
+	}{
+		{"too few fields", "0 0 * *", `expected 5 fields in cron schedule "0 0 * *", found 4`},
+		{"minute out of range", "60 * * * *", `invalid minute in cron schedule "60 * * * *": value 60 out of range [0-59]`},
+		{"day of month 0", "0 0 0 * *", `invalid day of month in cron schedule "0 0 0 * *": value 0 out of range [1-31]`},
+		{"day of week 8", "0 0 * * 8", `invalid day of week in cron schedule "0 0 * * 8": value 8 out of range [0-7]`},
+		{"invalid step", "*/0 * * * *", `invalid minute in cron schedule "*/0 * * * *": invalid step "0"`},
+		{"reversed range", "0 0 * * 5-1", `invalid day of week in cron schedule "0 0 * * 5-1": invalid range "5-1"`},
+		{"unknown name", "0 0 * foo *", `invalid month in cron schedule "0 0 * foo *": invalid value "foo"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCronSchedule(tt.spec)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ParseCronSchedule(%q) error = %v, want %s", tt.spec, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -20,9 +20,42 @@ import (
 	"encoding/json"
 
 	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+const (
+	// DeploymentPauseAnnotation - when set to "true" on an
+	// OpenStackDataPlaneDeployment, no new ansible execution is started until
+	// the annotation is removed
+	DeploymentPauseAnnotation = "dataplane.openstack.org/pause"
+
+	// DeploymentCancelAnnotation - when set to "true" on an
+	// OpenStackDataPlaneDeployment, the running ansible executions are stopped
+	// and the Deployment is cancelled
+	DeploymentCancelAnnotation = "dataplane.openstack.org/cancel"
+
+	// DeploymentCanaryApprovedAnnotation - when set to "true" on an
+	// OpenStackDataPlaneDeployment, the remaining nodes are deployed once the
+	// canary nodes succeeded
+	DeploymentCanaryApprovedAnnotation = "dataplane.openstack.org/canary-approved"
+
+	// DeploymentRerunAnnotation - when set to a new token on a finished
+	// OpenStackDataPlaneDeployment, the Deployment runs again with new ansible
+	// executions
+	DeploymentRerunAnnotation = "dataplane.openstack.org/rerun"
+
+	// DeploymentApprovedAnnotation - when set to the planHash of the status
+	// of an OpenStackDataPlaneDeployment which requires approval, the
+	// Deployment executes the plan of that hash
+	DeploymentApprovedAnnotation = "dataplane.openstack.org/approved"
+)
+
+// DeploymentRunHistoryLimit is the number of previous runs kept in the status
+// of a Deployment
+const DeploymentRunHistoryLimit = 10
+
 // OpenStackDataPlaneDeploymentSpec defines the desired state of OpenStackDataPlaneDeployment
 type OpenStackDataPlaneDeploymentSpec struct {
 
@@ -52,12 +85,219 @@ type OpenStackDataPlaneDeploymentSpec struct {
 	// ServicesOverride list
 	ServicesOverride []string `json:"servicesOverride,omitempty"`
 
+	// +kubebuilder:validation:Optional
+	// ServiceOverrides ansible options of the executions of a single service,
+	// by service name
+	ServiceOverrides map[string]ServiceOverride `json:"serviceOverrides,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// RolloutStrategy to deploy the nodes of each NodeSet in batches
+	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum:=Deploy;Check
+	// Mode of the ansible executions, Deploy or Check. Check runs ansible with
+	// --check --diff, so no change is made to the nodes, and reports the
+	// changes which would have been made. Defaults to Deploy.
+	Mode string `json:"mode,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Canary nodes of each NodeSet deployed first, before the remaining nodes
+	Canary *Canary `json:"canary,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// NodeSetStrategy defines the order the NodeSets are deployed in
+	NodeSetStrategy *NodeSetStrategy `json:"nodeSetStrategy,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// RetryPolicy for the ansible executions of the services which do not
+	// set their own RetryPolicy
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ResumeFrom name of a previous OpenStackDataPlaneDeployment to resume.
+	// Services which succeeded for a NodeSet in that Deployment are skipped,
+	// as long as the NodeSet and service configuration are unchanged.
+	ResumeFrom string `json:"resumeFrom,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=1
+	// Timeout of the Deployment in seconds, measured from its creation, or
+	// the start of its rerun, and including the time waiting for the NodeSets
+	// to be SetupReady. Ansible executions still running after the timeout
+	// are stopped.
+	Timeout int `json:"timeout,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=0
+	// TTLSecondsAfterFinished time in seconds after which the finished
+	// Deployment is deleted, along with its ansible executions. The last
+	// Deployment which deployed a NodeSet is kept until another one deploys
+	// it. When not set, the Deployment is not deleted automatically.
+	TTLSecondsAfterFinished *int `json:"ttlSecondsAfterFinished,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// RequireApproval holds the execution of the Deployment, once its plan
+	// is published, until the dataplane.openstack.org/approved annotation is
+	// set to the hash of that plan. When the plan changes, the Deployment
+	// waits for the new plan to be approved.
+	RequireApproval bool `json:"requireApproval,omitempty"`
+
 	// Time before the deployment is requeued in seconds
 	// +kubebuilder:validation:Minimum:=1
 	// +kubebuilder:default:=15
 	DeploymentRequeueTime int `json:"deploymentRequeueTime"`
 }
 
+// ServiceOverride defines the ansible options of the executions of a service.
+// AnsibleTags, AnsibleSkipTags and AnsibleLimit replace the ones of the
+// Deployment when set, AnsibleExtraVars are merged with the ones of the
+// Deployment and take precedence.
+type ServiceOverride struct {
+	// AnsibleTags for ansible execution
+	// +kubebuilder:validation:Optional
+	AnsibleTags string `json:"ansibleTags,omitempty"`
+
+	// AnsibleLimit for ansible execution
+	// +kubebuilder:validation:Optional
+	AnsibleLimit string `json:"ansibleLimit,omitempty"`
+
+	// AnsibleSkipTags for ansible execution
+	// +kubebuilder:validation:Optional
+	AnsibleSkipTags string `json:"ansibleSkipTags,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// AnsibleExtraVars for ansible execution
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	AnsibleExtraVars map[string]json.RawMessage `json:"ansibleExtraVars,omitempty"`
+}
+
+// RolloutStrategy defines how the nodes of a NodeSet are split into batches
+// which are deployed one after the other
+type RolloutStrategy struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:XIntOrString
+	// BatchSize number or percentage of the nodes of a NodeSet deployed at the
+	// same time. Percentages are rounded up.
+	BatchSize intstr.IntOrString `json:"batchSize"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=0
+	// PauseSeconds time to wait after a batch is deployed before starting the
+	// next one
+	PauseSeconds int `json:"pauseSeconds,omitempty"`
+}
+
+const (
+	// DeploymentModeDeploy deploys the services on the nodes
+	DeploymentModeDeploy = "Deploy"
+	// DeploymentModeCheck runs the services in ansible check mode, without
+	// changing the nodes
+	DeploymentModeCheck = "Check"
+)
+
+// Canary defines the nodes of each NodeSet which are deployed first. The
+// remaining nodes are deployed once the canary nodes succeeded and the canary
+// is approved.
+type Canary struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=1
+	// Count number of nodes of each NodeSet deployed first, for the NodeSets
+	// not listed in Hosts
+	Count int `json:"count,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Hosts canary hosts, by NodeSet
+	Hosts map[string][]string `json:"hosts,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// AutoApprove deploys the remaining nodes as soon as the canary nodes
+	// succeeded. Otherwise, the Deployment waits for the
+	// dataplane.openstack.org/canary-approved annotation.
+	AutoApprove bool `json:"autoApprove,omitempty"`
+}
+
+const (
+	// NodeSetStrategyParallel deploys all NodeSets at the same time
+	NodeSetStrategyParallel = "Parallel"
+	// NodeSetStrategySerial deploys the NodeSets one after the other, in the
+	// order of the nodeSets list
+	NodeSetStrategySerial = "Serial"
+	// NodeSetStrategyWaves deploys the NodeSets in the order of the waves
+	// list, the NodeSets of a wave are deployed at the same time
+	NodeSetStrategyWaves = "Waves"
+)
+
+// NodeSetStrategy defines the order the NodeSets of a Deployment are deployed
+// in. A NodeSet is only started once all NodeSets of the previous stage are
+// deployed.
+type NodeSetStrategy struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=Parallel
+	// +kubebuilder:validation:Enum:=Parallel;Serial;Waves
+	// Type of the strategy, Parallel, Serial or Waves
+	Type string `json:"type,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Waves ordered list of groups of NodeSets, required for the Waves type.
+	// Each NodeSet of the nodeSets list must be part of exactly one wave.
+	Waves [][]string `json:"waves,omitempty"`
+}
+
+// DeploymentPlan is the ordered plan of the services a Deployment executes on
+// each of its NodeSets
+type DeploymentPlan struct {
+	// NodeSets - the plan of each NodeSet, in the order they are deployed
+	NodeSets []NodeSetPlan `json:"nodeSets,omitempty"`
+}
+
+// NodeSetPlan is the ordered list of the services a Deployment executes on a
+// NodeSet
+type NodeSetPlan struct {
+	// Name of the NodeSet
+	Name string `json:"name"`
+
+	// Stage - index of the stage of the nodeSetStrategy the NodeSet is
+	// deployed in, the NodeSets of a stage are deployed at the same time
+	Stage int `json:"stage"`
+
+	// Services - the services executed on the NodeSet, in the order they are
+	// started
+	Services []ServicePlan `json:"services,omitempty"`
+}
+
+// ServicePlan is the plan of a service executed on a NodeSet
+type ServicePlan struct {
+	// Name of the service
+	Name string `json:"name"`
+
+	// Global - the service is deployed on all the NodeSets of the Deployment
+	// (DeployOnAllNodeSets), and so is executed only once
+	Global bool `json:"global,omitempty"`
+
+	// TLSCert - the certificate issued for each node of the NodeSet before
+	// the service is executed, nil when none is issued
+	TLSCert *OpenstackDataPlaneServiceCert `json:"tlsCert,omitempty"`
+}
+
+// NodeSetProgress is the progress of a Deployment on a NodeSet
+type NodeSetProgress struct {
+	// CompletedServices - number of services completed on the NodeSet
+	CompletedServices int `json:"completedServices"`
+
+	// TotalServices - number of services executed on the NodeSet
+	TotalServices int `json:"totalServices"`
+
+	// CurrentService - first service of the plan of the NodeSet which is not
+	// completed yet
+	CurrentService string `json:"currentService,omitempty"`
+
+	// EstimatedFinishTime - time the Deployment is expected to finish on the
+	// NodeSet at
+	EstimatedFinishTime *metav1.Time `json:"estimatedFinishTime,omitempty"`
+}
+
 // OpenStackDataPlaneDeploymentStatus defines the observed state of OpenStackDataPlaneDeployment
 type OpenStackDataPlaneDeploymentStatus struct {
 	// +operator-sdk:csv:customresourcedefinitions:type=status,xDescriptors={"urn:alm:descriptor:io.kubernetes.conditions"}
@@ -71,6 +311,9 @@ type OpenStackDataPlaneDeploymentStatus struct {
 	// Deployed
 	Deployed bool `json:"deployed,omitempty" optional:"true"`
 
+	// Cancelled - the Deployment was cancelled before it completed
+	Cancelled bool `json:"cancelled,omitempty" optional:"true"`
+
 	// ConfigMapHashes
 	ConfigMapHashes map[string]string `json:"configMapHashes,omitempty" optional:"true"`
 
@@ -80,6 +323,68 @@ type OpenStackDataPlaneDeploymentStatus struct {
 	// NodeSetHashes
 	NodeSetHashes map[string]string `json:"nodeSetHashes,omitempty" optional:"true"`
 
+	// CheckReport - name of the ConfigMap holding the ansible recap of each
+	// host, by NodeSet and service, for a Check mode Deployment
+	CheckReport string `json:"checkReport,omitempty" optional:"true"`
+
+	// NodeSetServiceHashes - hash of the NodeSet and service configuration
+	// each service was deployed with, by NodeSet
+	NodeSetServiceHashes map[string]map[string]string `json:"nodeSetServiceHashes,omitempty" optional:"true"`
+
+	// NodeSetAttempts - history of the ansible execution attempts of each
+	// service, by NodeSet
+	NodeSetAttempts map[string]map[string][]AnsibleExecutionAttempt `json:"nodeSetAttempts,omitempty" optional:"true"`
+
+	// Results - name of the ConfigMap holding the ansible results of each
+	// host, by NodeSet, service and execution
+	Results string `json:"results,omitempty" optional:"true"`
+
+	// NodeSetResults - summary of the ansible results of each service, by
+	// NodeSet
+	NodeSetResults map[string]map[string]AnsibleServiceResult `json:"nodeSetResults,omitempty" optional:"true"`
+
+	// FinishTime - time the Deployment succeeded, failed or was cancelled
+	FinishTime *metav1.Time `json:"finishTime,omitempty" optional:"true"`
+
+	// Run - number of the current run of the Deployment, 0 for the first run
+	// and incremented by each rerun
+	Run int `json:"run,omitempty" optional:"true"`
+
+	// RerunToken - token of the rerun annotation the current run was started
+	// for
+	RerunToken string `json:"rerunToken,omitempty" optional:"true"`
+
+	// RunStartTime - time the current run was started, only set for reruns
+	RunStartTime *metav1.Time `json:"runStartTime,omitempty" optional:"true"`
+
+	// PreviousRuns - the previous runs of the Deployment, oldest first
+	PreviousRuns []DeploymentRun `json:"previousRuns,omitempty" optional:"true"`
+
+	// Plan - the services the Deployment executes on each NodeSet, published
+	// before any ansible execution is started
+	Plan *DeploymentPlan `json:"plan,omitempty" optional:"true"`
+
+	// PlanHash - hash of the published plan, the value of the
+	// dataplane.openstack.org/approved annotation approving it
+	PlanHash string `json:"planHash,omitempty" optional:"true"`
+
+	// StartTime - time the current run of the Deployment started executing
+	// its plan, once its inputs were ready
+	StartTime *metav1.Time `json:"startTime,omitempty" optional:"true"`
+
+	// Progress - number of services completed out of the total, for all the
+	// NodeSets
+	Progress string `json:"progress,omitempty" optional:"true"`
+
+	// NodeSetProgress - progress of the Deployment, by NodeSet
+	NodeSetProgress map[string]NodeSetProgress `json:"nodeSetProgress,omitempty" optional:"true"`
+
+	// EstimatedFinishTime - time the running Deployment is expected to
+	// finish at, from the durations of the services in the previous
+	// Deployments of its NodeSets. Not set while any remaining service has
+	// no previous duration.
+	EstimatedFinishTime *metav1.Time `json:"estimatedFinishTime,omitempty" optional:"true"`
+
 	//ObservedGeneration - the most recent generation observed for this Deployment. If the observed generation is less than the spec generation, then the controller has not processed the latest changes.
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
@@ -91,6 +396,8 @@ type OpenStackDataPlaneDeploymentStatus struct {
 //+kubebuilder:printcolumn:name="NodeSets",type="string",JSONPath=".spec.nodeSets",description="NodeSets"
 //+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
 //+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+//+kubebuilder:printcolumn:name="Progress",type="string",JSONPath=".status.progress",description="Progress"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Age"
 
 // OpenStackDataPlaneDeployment is the Schema for the openstackdataplanedeployments API
 // OpenStackDataPlaneDeployment name must be a valid RFC1123 as it is used in labels
@@ -121,6 +428,80 @@ func (instance OpenStackDataPlaneDeployment) IsReady() bool {
 	return instance.Status.Conditions.IsTrue(condition.ReadyCondition)
 }
 
+// IsPaused - returns true if the OpenStackDataPlaneDeployment is paused
+func (instance OpenStackDataPlaneDeployment) IsPaused() bool {
+	return instance.Annotations[DeploymentPauseAnnotation] == "true"
+}
+
+// IsCancelRequested - returns true if the OpenStackDataPlaneDeployment should
+// be cancelled
+func (instance OpenStackDataPlaneDeployment) IsCancelRequested() bool {
+	return instance.Annotations[DeploymentCancelAnnotation] == "true"
+}
+
+// IsCheckMode - returns true if the OpenStackDataPlaneDeployment runs in
+// ansible check mode
+func (instance OpenStackDataPlaneDeployment) IsCheckMode() bool {
+	return instance.Spec.Mode == DeploymentModeCheck
+}
+
+// IsCanaryApproved - returns true if the remaining nodes can be deployed
+// once the canary nodes succeeded
+func (instance OpenStackDataPlaneDeployment) IsCanaryApproved() bool {
+	return instance.Spec.Canary != nil &&
+		(instance.Spec.Canary.AutoApprove || instance.Annotations[DeploymentCanaryApprovedAnnotation] == "true")
+}
+
+// IsApproved - returns true if the OpenStackDataPlaneDeployment does not
+// require approval or its published plan was approved
+func (instance OpenStackDataPlaneDeployment) IsApproved() bool {
+	return !instance.Spec.RequireApproval ||
+		(instance.Status.PlanHash != "" && instance.Annotations[DeploymentApprovedAnnotation] == instance.Status.PlanHash)
+}
+
+// IsFailed - returns true if the OpenStackDataPlaneDeployment failed or timed
+// out. Both are final, the Deployment does not start any new ansible execution.
+func (instance OpenStackDataPlaneDeployment) IsFailed() bool {
+	ready := instance.Status.Conditions.Get(condition.ReadyCondition)
+	return condition.IsError(ready) ||
+		(ready != nil && ready.Status == corev1.ConditionFalse && ready.Reason == TimedOutReason)
+}
+
+// IsInProgress - returns true once the OpenStackDataPlaneDeployment is
+// approved, until it is deployed, cancelled, failed or timed out. A paused
+// Deployment is still in progress.
+func (instance OpenStackDataPlaneDeployment) IsInProgress() bool {
+	return instance.DeletionTimestamp.IsZero() && instance.IsApproved() &&
+		!instance.Status.Deployed && !instance.Status.Cancelled && !instance.IsFailed()
+}
+
+// IsRerunRequested - returns true if the rerun annotation of the
+// OpenStackDataPlaneDeployment carries a token which was not run yet
+func (instance OpenStackDataPlaneDeployment) IsRerunRequested() bool {
+	token := instance.Annotations[DeploymentRerunAnnotation]
+	return len(token) > 0 && token != instance.Status.RerunToken
+}
+
+// GetRunStartTime - returns the time the current run of the
+// OpenStackDataPlaneDeployment started, its creation for the first run
+func (instance OpenStackDataPlaneDeployment) GetRunStartTime() metav1.Time {
+	if instance.Status.RunStartTime != nil {
+		return *instance.Status.RunStartTime
+	}
+	return instance.CreationTimestamp
+}
+
+// DeploysBefore - returns true if the current run of the
+// OpenStackDataPlaneDeployment started before the one of the other, and so
+// deploys their common NodeSets first
+func (instance OpenStackDataPlaneDeployment) DeploysBefore(other OpenStackDataPlaneDeployment) bool {
+	start, otherStart := instance.GetRunStartTime(), other.GetRunStartTime()
+	if start.Equal(&otherStart) {
+		return instance.Name < other.Name
+	}
+	return start.Before(&otherStart)
+}
+
 // InitConditions - Initializes Status Conditons
 func (instance *OpenStackDataPlaneDeployment) InitConditions() {
 	instance.Status.Conditions = condition.Conditions{}
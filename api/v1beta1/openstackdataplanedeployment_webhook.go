@@ -17,11 +17,18 @@ limitations under the License.
 package v1beta1
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/slices"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -31,6 +38,10 @@ var openstackdataplanedeploymentlog = logf.Log.WithName("openstackdataplanedeplo
 
 // SetupWebhookWithManager sets up the webhook with the Manager
 func (r *OpenStackDataPlaneDeployment) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	if webhookClient == nil {
+		webhookClient = mgr.GetClient()
+	}
+
 	return ctrl.NewWebhookManagedBy(mgr).For(r).Complete()
 }
 
@@ -62,6 +73,12 @@ func (r *OpenStackDataPlaneDeployment) ValidateCreate() (admission.Warnings, err
 	openstackdataplanedeploymentlog.Info("validate create", "name", r.Name)
 
 	errors := r.Spec.ValidateCreate()
+	if len(r.Spec.ResumeFrom) > 0 && r.Spec.ResumeFrom == r.Name {
+		errors = append(errors, field.Invalid(
+			field.NewPath("spec").Child("resumeFrom"),
+			r.Spec.ResumeFrom,
+			"a Deployment can not resume from itself"))
+	}
 	if len(errors) != 0 {
 		openstackdataplanedeploymentlog.Info("validation failed", "name", r.Name)
 
@@ -71,13 +88,169 @@ func (r *OpenStackDataPlaneDeployment) ValidateCreate() (admission.Warnings, err
 			errors)
 	}
 
-	return nil, nil
+	deploymentList := &OpenStackDataPlaneDeploymentList{}
+	opts := &client.ListOptions{
+		Namespace: r.ObjectMeta.Namespace,
+	}
+	err := webhookClient.List(context.TODO(), deploymentList, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.overlappingDeploymentWarnings(deploymentList), nil
+}
+
+// overlappingDeploymentWarnings - warns about the Deployments in progress on
+// the NodeSets of the Deployment, which is only started once they finished
+func (r *OpenStackDataPlaneDeployment) overlappingDeploymentWarnings(deploymentList *OpenStackDataPlaneDeploymentList) admission.Warnings {
+	var warnings admission.Warnings
+
+	for _, deployment := range deploymentList.Items {
+		if deployment.Name == r.Name || !deployment.IsInProgress() {
+			continue
+		}
+		var nodeSets []string
+		for _, nodeSet := range r.Spec.NodeSets {
+			if slices.Contains(deployment.Spec.NodeSets, nodeSet) {
+				nodeSets = append(nodeSets, nodeSet)
+			}
+		}
+		if len(nodeSets) > 0 {
+			warnings = append(warnings, fmt.Sprintf(
+				"NodeSets %s are being deployed by OpenStackDataPlaneDeployment %s, this Deployment waits for it to finish",
+				strings.Join(nodeSets, ","), deployment.Name))
+		}
+	}
+
+	return warnings
 }
 
 func (r *OpenStackDataPlaneDeploymentSpec) ValidateCreate() field.ErrorList {
-	// TODO(user): fill in your validation logic upon object creation.
+	var errors field.ErrorList
+
+	if r.RolloutStrategy != nil {
+		errors = append(errors, r.RolloutStrategy.validate(field.NewPath("spec").Child("rolloutStrategy"))...)
+		if len(r.AnsibleLimit) > 0 {
+			errors = append(errors, field.Invalid(
+				field.NewPath("spec").Child("ansibleLimit"),
+				r.AnsibleLimit,
+				"ansibleLimit can not be used together with rolloutStrategy"))
+		}
+	}
 
-	return field.ErrorList{}
+	if r.Canary != nil {
+		errors = append(errors, r.Canary.validate(field.NewPath("spec").Child("canary"), r.NodeSets)...)
+		if len(r.AnsibleLimit) > 0 {
+			errors = append(errors, field.Invalid(
+				field.NewPath("spec").Child("ansibleLimit"),
+				r.AnsibleLimit,
+				"ansibleLimit can not be used together with canary"))
+		}
+	}
+
+	if r.NodeSetStrategy != nil {
+		errors = append(errors, r.NodeSetStrategy.validate(field.NewPath("spec").Child("nodeSetStrategy"), r.NodeSets)...)
+	}
+
+	if r.RolloutStrategy != nil || r.Canary != nil {
+		for service, override := range r.ServiceOverrides {
+			if len(override.AnsibleLimit) > 0 {
+				errors = append(errors, field.Invalid(
+					field.NewPath("spec").Child("serviceOverrides").Key(service).Child("ansibleLimit"),
+					override.AnsibleLimit,
+					"ansibleLimit can not be used together with rolloutStrategy or canary"))
+			}
+		}
+	}
+
+	return errors
+}
+
+// validate - checks canary nodes are set, and only for the NodeSets of the
+// Deployment
+func (r *Canary) validate(path *field.Path, nodeSets []string) field.ErrorList {
+	var errors field.ErrorList
+
+	if r.Count == 0 && len(r.Hosts) == 0 {
+		errors = append(errors, field.Required(path, "count or hosts must be set"))
+	}
+	for nodeSet, hosts := range r.Hosts {
+		if !slices.Contains(nodeSets, nodeSet) {
+			errors = append(errors, field.Invalid(
+				path.Child("hosts").Key(nodeSet),
+				nodeSet,
+				fmt.Sprintf("NodeSet %s is not in the nodeSets list", nodeSet)))
+		}
+		if len(hosts) == 0 {
+			errors = append(errors, field.Invalid(path.Child("hosts").Key(nodeSet), hosts, "hosts can not be empty"))
+		}
+	}
+
+	return errors
+}
+
+// validate - checks the waves are only set for the Waves type, and that
+// each NodeSet is part of exactly one wave
+func (r *NodeSetStrategy) validate(path *field.Path, nodeSets []string) field.ErrorList {
+	var errors field.ErrorList
+
+	if r.Type != NodeSetStrategyWaves {
+		if len(r.Waves) > 0 {
+			errors = append(errors, field.Invalid(
+				path.Child("waves"),
+				r.Waves,
+				fmt.Sprintf("waves can only be set with the %s type", NodeSetStrategyWaves)))
+		}
+		return errors
+	}
+
+	waveNodeSets := map[string]bool{}
+	for idx, wave := range r.Waves {
+		if len(wave) == 0 {
+			errors = append(errors, field.Invalid(path.Child("waves").Index(idx), wave, "wave can not be empty"))
+		}
+		for _, nodeSet := range wave {
+			if waveNodeSets[nodeSet] {
+				errors = append(errors, field.Duplicate(path.Child("waves").Index(idx), nodeSet))
+			}
+			waveNodeSets[nodeSet] = true
+			if !slices.Contains(nodeSets, nodeSet) {
+				errors = append(errors, field.Invalid(
+					path.Child("waves").Index(idx),
+					nodeSet,
+					fmt.Sprintf("NodeSet %s is not in the nodeSets list", nodeSet)))
+			}
+		}
+	}
+	for _, nodeSet := range nodeSets {
+		if !waveNodeSets[nodeSet] {
+			errors = append(errors, field.Invalid(
+				path.Child("waves"),
+				r.Waves,
+				fmt.Sprintf("NodeSet %s is not part of any wave", nodeSet)))
+		}
+	}
+
+	return errors
+}
+
+// validate - checks the batch size is a positive number or a percentage
+// between 1% and 100%
+func (r *RolloutStrategy) validate(path *field.Path) field.ErrorList {
+	var errors field.ErrorList
+
+	// Scaling 100 gives back the percentage itself, or the value of an int
+	batchSize, err := intstr.GetScaledValueFromIntOrPercent(&r.BatchSize, 100, true)
+	if err != nil {
+		errors = append(errors, field.Invalid(path.Child("batchSize"), r.BatchSize.String(), err.Error()))
+	} else if batchSize < 1 || (r.BatchSize.Type == intstr.String && batchSize > 100) {
+		errors = append(errors, field.Invalid(
+			path.Child("batchSize"),
+			r.BatchSize.String(),
+			fmt.Sprintf("batchSize must be a positive number or a percentage between 1%% and 100%%, got %s", r.BatchSize.String())))
+	}
+
+	return errors
 }
 
 func (r *OpenStackDataPlaneDeployment) ValidateUpdate(original runtime.Object) (admission.Warnings, error) {
@@ -0,0 +1,149 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConcurrencyPolicyForbid skips a scheduled run while a previous
+	// Deployment of the schedule is still running
+	ConcurrencyPolicyForbid = "Forbid"
+	// ConcurrencyPolicyReplace cancels the running Deployments of the
+	// schedule and starts the scheduled run once they are cancelled
+	ConcurrencyPolicyReplace = "Replace"
+
+	// DeploymentScheduleLabel - label holding the name of the
+	// OpenStackDataPlaneDeploymentSchedule which created a Deployment
+	DeploymentScheduleLabel = "openstackdataplanedeploymentschedule"
+
+	// DeploymentScheduledTimeAnnotation - time a Deployment created by an
+	// OpenStackDataPlaneDeploymentSchedule was scheduled at
+	DeploymentScheduledTimeAnnotation = "dataplane.openstack.org/scheduled-at"
+)
+
+// OpenStackDataPlaneDeploymentScheduleSpec defines the desired state of OpenStackDataPlaneDeploymentSchedule
+type OpenStackDataPlaneDeploymentScheduleSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	// Schedule in cron format, evaluated in UTC, for example "0 2 * * *"
+	Schedule string `json:"schedule"`
+
+	// +kubebuilder:validation:Optional
+	// Suspend stops the creation of new Deployments, running ones are not
+	// affected
+	Suspend bool `json:"suspend,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=Forbid
+	// +kubebuilder:validation:Enum:=Forbid;Replace
+	// ConcurrencyPolicy defines what happens when a run is due while a
+	// Deployment of the schedule is still running. Forbid skips the run,
+	// Replace cancels the running Deployment. A run is always skipped while a
+	// Deployment which was not created by the schedule is running on the same
+	// NodeSets.
+	ConcurrencyPolicy string `json:"concurrencyPolicy,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=3
+	// +kubebuilder:validation:Minimum:=0
+	// HistoryLimit number of finished Deployments of the schedule to keep,
+	// older ones are deleted along with their ansible executions
+	HistoryLimit int `json:"historyLimit"`
+
+	// +kubebuilder:validation:Required
+	// DeploymentTemplate spec of the OpenStackDataPlaneDeployments created on
+	// schedule
+	DeploymentTemplate OpenStackDataPlaneDeploymentSpec `json:"deploymentTemplate"`
+}
+
+// OpenStackDataPlaneDeploymentScheduleStatus defines the observed state of OpenStackDataPlaneDeploymentSchedule
+type OpenStackDataPlaneDeploymentScheduleStatus struct {
+	// +operator-sdk:csv:customresourcedefinitions:type=status,xDescriptors={"urn:alm:descriptor:io.kubernetes.conditions"}
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	// Active - Deployments of the schedule which are still running
+	Active []string `json:"active,omitempty" optional:"true"`
+
+	// LastScheduleTime - time of the last run which was due, whether a
+	// Deployment was created or the run was skipped
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty" optional:"true"`
+
+	// LastSkippedTime - time of the last run skipped because of a running
+	// Deployment
+	LastSkippedTime *metav1.Time `json:"lastSkippedTime,omitempty" optional:"true"`
+
+	// NextScheduleTime - time of the next run
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty" optional:"true"`
+
+	// LastDeployment - name of the last Deployment created by the schedule
+	LastDeployment string `json:"lastDeployment,omitempty" optional:"true"`
+
+	//ObservedGeneration - the most recent generation observed for this Schedule. If the observed generation is less than the spec generation, then the controller has not processed the latest changes.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+operator-sdk:csv:customresourcedefinitions:displayName="OpenStack Data Plane Deployment Schedules"
+//+kubebuilder:resource:shortName=osdpdsched;osdpdeploymentschedule;osdpdeploymentschedules
+//+kubebuilder:printcolumn:name="Schedule",type="string",JSONPath=".spec.schedule",description="Schedule"
+//+kubebuilder:printcolumn:name="Suspend",type="boolean",JSONPath=".spec.suspend",description="Suspend"
+//+kubebuilder:printcolumn:name="Last Schedule",type="date",JSONPath=".status.lastScheduleTime",description="Last Schedule"
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// OpenStackDataPlaneDeploymentSchedule is the Schema for the openstackdataplanedeploymentschedules API
+// OpenStackDataPlaneDeploymentSchedule name must be a valid RFC1123 as it is used in labels
+type OpenStackDataPlaneDeploymentSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OpenStackDataPlaneDeploymentScheduleSpec   `json:"spec,omitempty"`
+	Status OpenStackDataPlaneDeploymentScheduleStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// OpenStackDataPlaneDeploymentScheduleList contains a list of OpenStackDataPlaneDeploymentSchedule
+type OpenStackDataPlaneDeploymentScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OpenStackDataPlaneDeploymentSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&OpenStackDataPlaneDeploymentSchedule{}, &OpenStackDataPlaneDeploymentScheduleList{})
+}
+
+// IsReady - returns true if the OpenStackDataPlaneDeploymentSchedule is ready
+func (instance OpenStackDataPlaneDeploymentSchedule) IsReady() bool {
+	return instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+}
+
+// InitConditions - Initializes Status Conditons
+func (instance *OpenStackDataPlaneDeploymentSchedule) InitConditions() {
+	instance.Status.Conditions = condition.Conditions{}
+
+	cl := condition.CreateList(
+		condition.UnknownCondition(DeploymentScheduleReadyCondition, condition.InitReason, condition.InitReason),
+	)
+	instance.Status.Conditions.Init(&cl)
+}
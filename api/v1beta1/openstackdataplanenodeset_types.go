@@ -18,6 +18,7 @@ package v1beta1
 
 import (
 	"fmt"
+	"time"
 
 	"golang.org/x/exp/slices"
 
@@ -81,6 +82,84 @@ type OpenStackDataPlaneNodeSetSpec struct {
 	// Tags - Additional tags for NodeSet
 	// +kubebuilder:validation:Optional
 	Tags []string `json:"tags,omitempty"`
+
+	// AutoDeploy - creates an OpenStackDataPlaneDeployment automatically when
+	// the configuration of the NodeSet differs from the deployed one
+	// +kubebuilder:validation:Optional
+	AutoDeploy *AutoDeployPolicy `json:"autoDeploy,omitempty"`
+
+	// MaintenanceWindows - new ansible executions only start on the NodeSet
+	// during one of these windows. Executions already running when a window
+	// closes are allowed to finish. When not set, executions may start at any
+	// time.
+	// +kubebuilder:validation:Optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+
+	// DeploymentRetention - number of finished OpenStackDataPlaneDeployments
+	// of the NodeSet which are kept, by outcome. Older ones are deleted, along
+	// with their ansible executions. When not set, they are all kept.
+	// +kubebuilder:validation:Optional
+	DeploymentRetention *DeploymentRetention `json:"deploymentRetention,omitempty"`
+}
+
+const (
+	// AutoDeployTriggerSpec deploys changes of the NodeSet spec
+	AutoDeployTriggerSpec = "Spec"
+	// AutoDeployTriggerSpecAndInputs deploys changes of the NodeSet spec, and
+	// of the ConfigMaps and Secrets of its services
+	AutoDeployTriggerSpecAndInputs = "SpecAndInputs"
+
+	// AutoDeployHashAnnotation - hash of the NodeSet configuration an
+	// OpenStackDataPlaneDeployment was created to deploy by the AutoDeploy
+	// policy
+	AutoDeployHashAnnotation = "dataplane.openstack.org/auto-deploy-hash"
+)
+
+// AutoDeployPolicy defines when an OpenStackDataPlaneDeployment is created
+// automatically for the NodeSet
+type AutoDeployPolicy struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=Spec
+	// +kubebuilder:validation:Enum:=Spec;SpecAndInputs
+	// Trigger changes which are deployed, Spec or SpecAndInputs
+	Trigger string `json:"trigger,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=60
+	// +kubebuilder:validation:Minimum:=0
+	// DebounceSeconds time without further change to wait for before
+	// deploying, so several quick changes are deployed at once
+	DebounceSeconds int `json:"debounceSeconds,omitempty"`
+}
+
+// DeploymentRetention defines the number of finished
+// OpenStackDataPlaneDeployments of a NodeSet which are kept
+type DeploymentRetention struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=0
+	// Succeeded number of succeeded Deployments kept, all of them when not set
+	Succeeded *int `json:"succeeded,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=0
+	// Failed number of failed or cancelled Deployments kept, all of them when
+	// not set
+	Failed *int `json:"failed,omitempty"`
+}
+
+// MaintenanceWindow defines a time range during which new ansible executions
+// may start on the NodeSet
+type MaintenanceWindow struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	// Schedule start of the window in cron format, evaluated in UTC, for
+	// example "0 22 * * 6"
+	Schedule string `json:"schedule"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum:=1
+	// DurationMinutes length of the window in minutes
+	DurationMinutes int `json:"durationMinutes"`
 }
 
 //+kubebuilder:object:root=true
@@ -142,10 +221,115 @@ type OpenStackDataPlaneNodeSetStatus struct {
 	// out config changes.
 	DeployedConfigHash string `json:"deployedConfigHash,omitempty"`
 
+	// AutoDeploy - state of the AutoDeploy policy
+	AutoDeploy *AutoDeployStatus `json:"autoDeploy,omitempty" optional:"true"`
+
+	// DeploymentHistory - the most recent Deployments of the NodeSet, ordered
+	// from the oldest to the newest
+	DeploymentHistory []DeploymentHistoryEntry `json:"deploymentHistory,omitempty" optional:"true"`
+
+	// Nodes - the state of each node of the NodeSet, by hostname
+	Nodes map[string]NodeStatus `json:"nodes,omitempty" optional:"true"`
+
+	// NodesDeploymentTime - finish time of the last Deployment recorded in the
+	// state of the nodes
+	NodesDeploymentTime *metav1.Time `json:"nodesDeploymentTime,omitempty" optional:"true"`
+
 	//ObservedGeneration - the most recent generation observed for this NodeSet. If the observed generation is less than the spec generation, then the controller has not processed the latest changes.
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
+// NodeStatus defines the observed state of a node of the NodeSet
+type NodeStatus struct {
+	// LastDeployment - name of the last OpenStackDataPlaneDeployment which
+	// succeeded on the node
+	LastDeployment string `json:"lastDeployment,omitempty"`
+
+	// LastDeploymentTime - time the last Deployment which succeeded on the
+	// node finished
+	LastDeploymentTime *metav1.Time `json:"lastDeploymentTime,omitempty"`
+
+	// DeployedConfigHash - hash of the NodeSet configuration deployed on the
+	// node by the last Deployment which succeeded on it
+	DeployedConfigHash string `json:"deployedConfigHash,omitempty"`
+
+	// LastFailedService - name of the last service which failed on the node
+	LastFailedService string `json:"lastFailedService,omitempty"`
+
+	// LastFailedDeployment - name of the OpenStackDataPlaneDeployment in
+	// which the last failed service ran
+	LastFailedDeployment string `json:"lastFailedDeployment,omitempty"`
+
+	// IPs - addresses of the node, by network
+	IPs map[infranetworkv1.NetNameStr]string `json:"ips,omitempty"`
+
+	// ProvisioningState - state of the baremetal host of the node, when the
+	// NodeSet is not pre-provisioned
+	ProvisioningState string `json:"provisioningState,omitempty"`
+}
+
+// AutoDeployStatus defines the observed state of the AutoDeploy policy
+type AutoDeployStatus struct {
+	// PendingHash - hash of the configuration waiting to be deployed
+	PendingHash string `json:"pendingHash,omitempty"`
+
+	// PendingSince - time the configuration waiting to be deployed last
+	// changed
+	PendingSince *metav1.Time `json:"pendingSince,omitempty"`
+
+	// LastDeployment - name of the last OpenStackDataPlaneDeployment created
+	// by the policy
+	LastDeployment string `json:"lastDeployment,omitempty"`
+}
+
+// DeploymentHistoryLimit is the number of Deployments kept in the
+// DeploymentHistory of a NodeSet
+const DeploymentHistoryLimit = 20
+
+const (
+	// DeploymentOutcomeRunning the Deployment is still running
+	DeploymentOutcomeRunning = "Running"
+	// DeploymentOutcomeSucceeded the Deployment succeeded
+	DeploymentOutcomeSucceeded = "Succeeded"
+	// DeploymentOutcomeFailed the Deployment failed
+	DeploymentOutcomeFailed = "Failed"
+	// DeploymentOutcomeCancelled the Deployment was cancelled
+	DeploymentOutcomeCancelled = "Cancelled"
+)
+
+// DeploymentHistoryEntry records a Deployment of the NodeSet
+type DeploymentHistoryEntry struct {
+	// Deployment name of the OpenStackDataPlaneDeployment
+	Deployment string `json:"deployment"`
+
+	// Run of the Deployment, 0 for the first run
+	Run int `json:"run,omitempty"`
+
+	// Mode of the Deployment, Deploy or Check
+	Mode string `json:"mode,omitempty"`
+
+	// StartTime the run of the Deployment was started at
+	StartTime metav1.Time `json:"startTime"`
+
+	// FinishTime the Deployment was seen completed at
+	FinishTime *metav1.Time `json:"finishTime,omitempty"`
+
+	// Services run by the Deployment on the NodeSet
+	Services []string `json:"services,omitempty"`
+
+	// NodeSetHash hash of the NodeSet configuration deployed
+	NodeSetHash string `json:"nodeSetHash,omitempty"`
+
+	// ConfigMapHashes of the ConfigMaps deployed
+	ConfigMapHashes map[string]string `json:"configMapHashes,omitempty"`
+
+	// SecretHashes of the Secrets deployed
+	SecretHashes map[string]string `json:"secretHashes,omitempty"`
+
+	// Outcome of the Deployment, Running, Succeeded, Failed or Cancelled
+	Outcome string `json:"outcome"`
+}
+
 //+kubebuilder:object:root=true
 
 // OpenStackDataPlaneNodeSetList contains a list of OpenStackDataPlaneNodeSets
@@ -238,3 +422,23 @@ func (r *OpenStackDataPlaneNodeSetSpec) duplicateNodeCheck(nodeSetList *OpenStac
 
 	return
 }
+
+// validateMaintenanceWindows checks that the schedule of each maintenance
+// window is a valid cron expression, which matches at least once in the years
+// following now, so that the window opens at some point.
+func (r *OpenStackDataPlaneNodeSetSpec) validateMaintenanceWindows(now time.Time) (errors field.ErrorList) {
+	for idx, window := range r.MaintenanceWindows {
+		path := field.NewPath("spec").Child("maintenanceWindows").Index(idx).Child("schedule")
+		schedule, err := ParseCronSchedule(window.Schedule)
+		if err != nil {
+			errors = append(errors, field.Invalid(path, window.Schedule, err.Error()))
+			continue
+		}
+		if schedule.Next(now.UTC()).IsZero() {
+			errors = append(errors, field.Invalid(path, window.Schedule,
+				"the maintenance window never opens, the schedule does not match any date"))
+		}
+	}
+
+	return
+}
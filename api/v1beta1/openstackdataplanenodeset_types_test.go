@@ -0,0 +1,65 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateMaintenanceWindows(t *testing.T) {
+	now := time.Date(2024, time.February, 14, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		schedules []string
+		want      []string
+	}{
+		{"no window", nil, nil},
+		{"valid windows", []string{"0 22 * * 6", "@daily"}, nil},
+		{
+			"invalid schedule",
+			[]string{"0 22 * * 6", "0 25 * * *"},
+			[]string{`spec.maintenanceWindows[1].schedule: Invalid value: "0 25 * * *": invalid hour in cron schedule "0 25 * * *": value 25 out of range [0-23]`},
+		},
+		{
+			"window never opens",
+			[]string{"0 0 31 2 *"},
+			[]string{`spec.maintenanceWindows[0].schedule: Invalid value: "0 0 31 2 *": the maintenance window never opens, the schedule does not match any date`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := OpenStackDataPlaneNodeSetSpec{}
+			for _, schedule := range tt.schedules {
+				spec.MaintenanceWindows = append(spec.MaintenanceWindows, MaintenanceWindow{
+					Schedule:        schedule,
+					DurationMinutes: 60,
+				})
+			}
+			errors := spec.validateMaintenanceWindows(now)
+			if len(errors) != len(tt.want) {
+				t.Fatalf("validateMaintenanceWindows() = %v, want %v", errors, tt.want)
+			}
+			for idx, err := range errors {
+				if err.Error() != tt.want[idx] {
+					t.Errorf("validateMaintenanceWindows() error = %s, want %s", err.Error(), tt.want[idx])
+				}
+			}
+		})
+	}
+}
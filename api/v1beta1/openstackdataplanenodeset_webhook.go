@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	baremetalv1 "github.com/openstack-k8s-operators/openstack-baremetal-operator/api/v1beta1"
@@ -152,6 +153,8 @@ func (r *OpenStackDataPlaneNodeSetSpec) ValidateCreate(nodeSetList *OpenStackDat
 		errors = append(errors, r.duplicateNodeCheck(nodeSetList)...)
 	}
 
+	errors = append(errors, r.validateMaintenanceWindows(time.Now())...)
+
 	return errors
 
 }
@@ -198,6 +201,8 @@ func (r *OpenStackDataPlaneNodeSetSpec) ValidateUpdate(oldSpec *OpenStackDataPla
 		}
 	}
 
+	errors = append(errors, r.validateMaintenanceWindows(time.Now())...)
+
 	return errors
 }
 
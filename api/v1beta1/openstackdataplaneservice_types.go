@@ -84,6 +84,25 @@ type OpenStackDataPlaneServiceSpec struct {
 	// This will override default target of a service play, setting it to 'all'.
 	// +kubebuilder:validation:Optional
 	DeployOnAllNodeSets bool `json:"deployOnAllNodeSets,omitempty" yaml:"deployOnAllNodeSets,omitempty"`
+
+	// DependsOn - list of services that must be successfully deployed on a
+	// NodeSet before this service is started. When not set, the service
+	// depends on the service preceding it in the NodeSet or Deployment
+	// services list. Services not included in the list being deployed are
+	// assumed to have been deployed already.
+	// +kubebuilder:validation:Optional
+	DependsOn []string `json:"dependsOn,omitempty" yaml:"dependsOn,omitempty"`
+
+	// RetryPolicy for the ansible execution of the service, overrides the
+	// RetryPolicy of the Deployment
+	// +kubebuilder:validation:Optional
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty" yaml:"retryPolicy,omitempty"`
+
+	// Timeout for each ansible execution of the service in seconds. An
+	// execution still running after the timeout is stopped.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=1
+	Timeout int `json:"timeout,omitempty" yaml:"timeout,omitempty"`
 }
 
 // OpenStackDataPlaneServiceStatus defines the observed state of OpenStackDataPlaneService
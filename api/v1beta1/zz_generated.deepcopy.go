@@ -85,6 +85,26 @@ func (in *AnsibleEESpec) DeepCopy() *AnsibleEESpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnsibleExecutionAttempt) DeepCopyInto(out *AnsibleExecutionAttempt) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.FinishTime != nil {
+		in, out := &in.FinishTime, &out.FinishTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnsibleExecutionAttempt.
+func (in *AnsibleExecutionAttempt) DeepCopy() *AnsibleExecutionAttempt {
+	if in == nil {
+		return nil
+	}
+	out := new(AnsibleExecutionAttempt)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AnsibleOpts) DeepCopyInto(out *AnsibleOpts) {
 	*out = *in
@@ -122,6 +142,31 @@ func (in *AnsibleOpts) DeepCopy() *AnsibleOpts {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnsibleServiceResult) DeepCopyInto(out *AnsibleServiceResult) {
+	*out = *in
+	if in.FailedHosts != nil {
+		in, out := &in.FailedHosts, &out.FailedHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UnreachableHosts != nil {
+		in, out := &in.UnreachableHosts, &out.UnreachableHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnsibleServiceResult.
+func (in *AnsibleServiceResult) DeepCopy() *AnsibleServiceResult {
+	if in == nil {
+		return nil
+	}
+	out := new(AnsibleServiceResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AnsibleVarsFromSource) DeepCopyInto(out *AnsibleVarsFromSource) {
 	*out = *in
@@ -147,6 +192,70 @@ func (in *AnsibleVarsFromSource) DeepCopy() *AnsibleVarsFromSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoDeployPolicy) DeepCopyInto(out *AutoDeployPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoDeployPolicy.
+func (in *AutoDeployPolicy) DeepCopy() *AutoDeployPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoDeployPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoDeployStatus) DeepCopyInto(out *AutoDeployStatus) {
+	*out = *in
+	if in.PendingSince != nil {
+		in, out := &in.PendingSince, &out.PendingSince
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoDeployStatus.
+func (in *AutoDeployStatus) DeepCopy() *AutoDeployStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoDeployStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Canary) DeepCopyInto(out *Canary) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Canary.
+func (in *Canary) DeepCopy() *Canary {
+	if in == nil {
+		return nil
+	}
+	out := new(Canary)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DataplaneAnsibleImageDefaults) DeepCopyInto(out *DataplaneAnsibleImageDefaults) {
 	*out = *in
@@ -162,6 +271,127 @@ func (in *DataplaneAnsibleImageDefaults) DeepCopy() *DataplaneAnsibleImageDefaul
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentHistoryEntry) DeepCopyInto(out *DeploymentHistoryEntry) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.FinishTime != nil {
+		in, out := &in.FinishTime, &out.FinishTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConfigMapHashes != nil {
+		in, out := &in.ConfigMapHashes, &out.ConfigMapHashes
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SecretHashes != nil {
+		in, out := &in.SecretHashes, &out.SecretHashes
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentHistoryEntry.
+func (in *DeploymentHistoryEntry) DeepCopy() *DeploymentHistoryEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentHistoryEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentPlan) DeepCopyInto(out *DeploymentPlan) {
+	*out = *in
+	if in.NodeSets != nil {
+		in, out := &in.NodeSets, &out.NodeSets
+		*out = make([]NodeSetPlan, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentPlan.
+func (in *DeploymentPlan) DeepCopy() *DeploymentPlan {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentPlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentRetention) DeepCopyInto(out *DeploymentRetention) {
+	*out = *in
+	if in.Succeeded != nil {
+		in, out := &in.Succeeded, &out.Succeeded
+		*out = new(int)
+		**out = **in
+	}
+	if in.Failed != nil {
+		in, out := &in.Failed, &out.Failed
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentRetention.
+func (in *DeploymentRetention) DeepCopy() *DeploymentRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentRun) DeepCopyInto(out *DeploymentRun) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.FinishTime != nil {
+		in, out := &in.FinishTime, &out.FinishTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentRun.
+func (in *DeploymentRun) DeepCopy() *DeploymentRun {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeSection) DeepCopyInto(out *NodeSection) {
 	*out = *in
@@ -202,6 +432,99 @@ func (in *NodeSection) DeepCopy() *NodeSection {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeSetPlan) DeepCopyInto(out *NodeSetPlan) {
+	*out = *in
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]ServicePlan, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeSetPlan.
+func (in *NodeSetPlan) DeepCopy() *NodeSetPlan {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeSetPlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeSetProgress) DeepCopyInto(out *NodeSetProgress) {
+	*out = *in
+	if in.EstimatedFinishTime != nil {
+		in, out := &in.EstimatedFinishTime, &out.EstimatedFinishTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeSetProgress.
+func (in *NodeSetProgress) DeepCopy() *NodeSetProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeSetProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeSetStrategy) DeepCopyInto(out *NodeSetStrategy) {
+	*out = *in
+	if in.Waves != nil {
+		in, out := &in.Waves, &out.Waves
+		*out = make([][]string, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeSetStrategy.
+func (in *NodeSetStrategy) DeepCopy() *NodeSetStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeSetStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeStatus) DeepCopyInto(out *NodeStatus) {
+	*out = *in
+	if in.LastDeploymentTime != nil {
+		in, out := &in.LastDeploymentTime, &out.LastDeploymentTime
+		*out = (*in).DeepCopy()
+	}
+	if in.IPs != nil {
+		in, out := &in.IPs, &out.IPs
+		*out = make(map[networkv1beta1.NetNameStr]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeStatus.
+func (in *NodeStatus) DeepCopy() *NodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeTemplate) DeepCopyInto(out *NodeTemplate) {
 	*out = *in
@@ -301,6 +624,120 @@ func (in *OpenStackDataPlaneDeploymentList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenStackDataPlaneDeploymentSchedule) DeepCopyInto(out *OpenStackDataPlaneDeploymentSchedule) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackDataPlaneDeploymentSchedule.
+func (in *OpenStackDataPlaneDeploymentSchedule) DeepCopy() *OpenStackDataPlaneDeploymentSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenStackDataPlaneDeploymentSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OpenStackDataPlaneDeploymentSchedule) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenStackDataPlaneDeploymentScheduleList) DeepCopyInto(out *OpenStackDataPlaneDeploymentScheduleList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OpenStackDataPlaneDeploymentSchedule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackDataPlaneDeploymentScheduleList.
+func (in *OpenStackDataPlaneDeploymentScheduleList) DeepCopy() *OpenStackDataPlaneDeploymentScheduleList {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenStackDataPlaneDeploymentScheduleList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OpenStackDataPlaneDeploymentScheduleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenStackDataPlaneDeploymentScheduleSpec) DeepCopyInto(out *OpenStackDataPlaneDeploymentScheduleSpec) {
+	*out = *in
+	in.DeploymentTemplate.DeepCopyInto(&out.DeploymentTemplate)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackDataPlaneDeploymentScheduleSpec.
+func (in *OpenStackDataPlaneDeploymentScheduleSpec) DeepCopy() *OpenStackDataPlaneDeploymentScheduleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenStackDataPlaneDeploymentScheduleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenStackDataPlaneDeploymentScheduleStatus) DeepCopyInto(out *OpenStackDataPlaneDeploymentScheduleStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Active != nil {
+		in, out := &in.Active, &out.Active
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSkippedTime != nil {
+		in, out := &in.LastSkippedTime, &out.LastSkippedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextScheduleTime != nil {
+		in, out := &in.NextScheduleTime, &out.NextScheduleTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackDataPlaneDeploymentScheduleStatus.
+func (in *OpenStackDataPlaneDeploymentScheduleStatus) DeepCopy() *OpenStackDataPlaneDeploymentScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenStackDataPlaneDeploymentScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OpenStackDataPlaneDeploymentSpec) DeepCopyInto(out *OpenStackDataPlaneDeploymentSpec) {
 	*out = *in
@@ -329,6 +766,38 @@ func (in *OpenStackDataPlaneDeploymentSpec) DeepCopyInto(out *OpenStackDataPlane
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ServiceOverrides != nil {
+		in, out := &in.ServiceOverrides, &out.ServiceOverrides
+		*out = make(map[string]ServiceOverride, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.RolloutStrategy != nil {
+		in, out := &in.RolloutStrategy, &out.RolloutStrategy
+		*out = new(RolloutStrategy)
+		**out = **in
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(Canary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeSetStrategy != nil {
+		in, out := &in.NodeSetStrategy, &out.NodeSetStrategy
+		*out = new(NodeSetStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(RetryPolicy)
+		**out = **in
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackDataPlaneDeploymentSpec.
@@ -389,6 +858,102 @@ func (in *OpenStackDataPlaneDeploymentStatus) DeepCopyInto(out *OpenStackDataPla
 			(*out)[key] = val
 		}
 	}
+	if in.NodeSetServiceHashes != nil {
+		in, out := &in.NodeSetServiceHashes, &out.NodeSetServiceHashes
+		*out = make(map[string]map[string]string, len(*in))
+		for key, val := range *in {
+			var outVal map[string]string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.NodeSetAttempts != nil {
+		in, out := &in.NodeSetAttempts, &out.NodeSetAttempts
+		*out = make(map[string]map[string][]AnsibleExecutionAttempt, len(*in))
+		for key, val := range *in {
+			var outVal map[string][]AnsibleExecutionAttempt
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make(map[string][]AnsibleExecutionAttempt, len(*in))
+				for key, val := range *in {
+					var outVal []AnsibleExecutionAttempt
+					if val == nil {
+						(*out)[key] = nil
+					} else {
+						in, out := &val, &outVal
+						*out = make([]AnsibleExecutionAttempt, len(*in))
+						for i := range *in {
+							(*in)[i].DeepCopyInto(&(*out)[i])
+						}
+					}
+					(*out)[key] = outVal
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.NodeSetResults != nil {
+		in, out := &in.NodeSetResults, &out.NodeSetResults
+		*out = make(map[string]map[string]AnsibleServiceResult, len(*in))
+		for key, val := range *in {
+			var outVal map[string]AnsibleServiceResult
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make(map[string]AnsibleServiceResult, len(*in))
+				for key, val := range *in {
+					(*out)[key] = *val.DeepCopy()
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.FinishTime != nil {
+		in, out := &in.FinishTime, &out.FinishTime
+		*out = (*in).DeepCopy()
+	}
+	if in.RunStartTime != nil {
+		in, out := &in.RunStartTime, &out.RunStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PreviousRuns != nil {
+		in, out := &in.PreviousRuns, &out.PreviousRuns
+		*out = make([]DeploymentRun, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = new(DeploymentPlan)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NodeSetProgress != nil {
+		in, out := &in.NodeSetProgress, &out.NodeSetProgress
+		*out = make(map[string]NodeSetProgress, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.EstimatedFinishTime != nil {
+		in, out := &in.EstimatedFinishTime, &out.EstimatedFinishTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackDataPlaneDeploymentStatus.
@@ -494,6 +1059,21 @@ func (in *OpenStackDataPlaneNodeSetSpec) DeepCopyInto(out *OpenStackDataPlaneNod
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.AutoDeploy != nil {
+		in, out := &in.AutoDeploy, &out.AutoDeploy
+		*out = new(AutoDeployPolicy)
+		**out = **in
+	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeploymentRetention != nil {
+		in, out := &in.DeploymentRetention, &out.DeploymentRetention
+		*out = new(DeploymentRetention)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackDataPlaneNodeSetSpec.
@@ -586,6 +1166,29 @@ func (in *OpenStackDataPlaneNodeSetStatus) DeepCopyInto(out *OpenStackDataPlaneN
 			(*out)[key] = val
 		}
 	}
+	if in.AutoDeploy != nil {
+		in, out := &in.AutoDeploy, &out.AutoDeploy
+		*out = new(AutoDeployStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeploymentHistory != nil {
+		in, out := &in.DeploymentHistory, &out.DeploymentHistory
+		*out = make([]DeploymentHistoryEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make(map[string]NodeStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.NodesDeploymentTime != nil {
+		in, out := &in.NodesDeploymentTime, &out.NodesDeploymentTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackDataPlaneNodeSetStatus.
@@ -675,6 +1278,16 @@ func (in *OpenStackDataPlaneServiceSpec) DeepCopyInto(out *OpenStackDataPlaneSer
 		*out = new(OpenstackDataPlaneServiceCert)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(RetryPolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackDataPlaneServiceSpec.
@@ -738,3 +1351,84 @@ func (in *OpenstackDataPlaneServiceCert) DeepCopy() *OpenstackDataPlaneServiceCe
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetryPolicy) DeepCopyInto(out *RetryPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetryPolicy.
+func (in *RetryPolicy) DeepCopy() *RetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStrategy) DeepCopyInto(out *RolloutStrategy) {
+	*out = *in
+	out.BatchSize = in.BatchSize
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStrategy.
+func (in *RolloutStrategy) DeepCopy() *RolloutStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceOverride) DeepCopyInto(out *ServiceOverride) {
+	*out = *in
+	if in.AnsibleExtraVars != nil {
+		in, out := &in.AnsibleExtraVars, &out.AnsibleExtraVars
+		*out = make(map[string]json.RawMessage, len(*in))
+		for key, val := range *in {
+			var outVal []byte
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make(json.RawMessage, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceOverride.
+func (in *ServiceOverride) DeepCopy() *ServiceOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServicePlan) DeepCopyInto(out *ServicePlan) {
+	*out = *in
+	if in.TLSCert != nil {
+		in, out := &in.TLSCert, &out.TLSCert
+		*out = new(OpenstackDataPlaneServiceCert)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServicePlan.
+func (in *ServicePlan) DeepCopy() *ServicePlan {
+	if in == nil {
+		return nil
+	}
+	out := new(ServicePlan)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -19,14 +19,19 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slices"
 	corev1 "k8s.io/api/core/v1"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -34,6 +39,7 @@ import (
 	"github.com/go-logr/logr"
 	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
 	"github.com/openstack-k8s-operators/dataplane-operator/pkg/deployment"
+	"github.com/openstack-k8s-operators/dataplane-operator/pkg/metrics"
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
@@ -43,8 +49,12 @@ import (
 // OpenStackDataPlaneDeploymentReconciler reconciles a OpenStackDataPlaneDeployment object
 type OpenStackDataPlaneDeploymentReconciler struct {
 	client.Client
-	Kclient kubernetes.Interface
-	Scheme  *runtime.Scheme
+	Kclient  kubernetes.Interface
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// ExecutionQueue limits the number of ansible executions running at the
+	// same time, nil for no limit
+	ExecutionQueue *deployment.ExecutionQueue
 }
 
 // GetLogger returns a logger object with a prefix of "controller.name" and additional controller context fields
@@ -57,10 +67,14 @@ func (r *OpenStackDataPlaneDeploymentReconciler) GetLogger(ctx context.Context)
 //+kubebuilder:rbac:groups=dataplane.openstack.org,resources=openstackdataplanedeployments/finalizers,verbs=update
 //+kubebuilder:rbac:groups=dataplane.openstack.org,resources=openstackdataplanenodesets,verbs=get;list;watch
 //+kubebuilder:rbac:groups=dataplane.openstack.org,resources=openstackdataplaneservices,verbs=get;list;watch
-//+kubebuilder:rbac:groups=ansibleee.openstack.org,resources=openstackansibleees,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=ansibleee.openstack.org,resources=openstackansibleees,verbs=get;list;watch;create;update;patch;delete;deletecollection
 //+kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch;create;update;patch;delete;
 //+kubebuilder:rbac:groups=cert-manager.io,resources=issuers,verbs=get;list;watch;
 //+kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete;
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete;
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list
+//+kubebuilder:rbac:groups="",resources=pods/log,verbs=get
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -83,6 +97,7 @@ func (r *OpenStackDataPlaneDeploymentReconciler) Reconcile(ctx context.Context,
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected.
 			// For additional cleanup logic use finalizers. Return and don't requeue.
+			metrics.DeleteDeploymentMetrics(req.Namespace, req.Name)
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -97,10 +112,34 @@ func (r *OpenStackDataPlaneDeploymentReconciler) Reconcile(ctx context.Context,
 		Log,
 	)
 
+	// Run the finished deployment again when the rerun annotation changed
+	if deployment.StartDeploymentRerun(instance) {
+		Log.Info("Rerunning Deployment", "run", instance.Status.Run, "token", instance.Status.RerunToken)
+		r.Recorder.Eventf(instance, corev1.EventTypeNormal, deployment.DeploymentRerunReason,
+			"Rerunning Deployment, run %d", instance.Status.Run)
+	}
+
+	// Delete the finished deployment once its TTL expired
+	deleted, ttlRemaining, err := deployment.ExpireDeployment(ctx, helper, instance)
+	if err != nil {
+		Log.Error(err, "Unable to delete the OpenStackDataPlaneDeployment after its TTL")
+		return ctrl.Result{}, err
+	}
+	if deleted {
+		metrics.DeleteDeploymentMetrics(instance.Namespace, instance.Name)
+		return ctrl.Result{}, nil
+	}
+
 	// If the deploy is already done, return immediately.
 	if instance.Status.Deployed {
 		Log.Info("Already deployed", "instance.Status.Deployed", instance.Status.Deployed)
-		return ctrl.Result{}, nil
+		return ctrl.Result{RequeueAfter: ttlRemaining}, nil
+	}
+
+	// If the deployment was cancelled, return immediately.
+	if instance.Status.Cancelled {
+		Log.Info("Already cancelled", "instance.Status.Cancelled", instance.Status.Cancelled)
+		return ctrl.Result{RequeueAfter: ttlRemaining}, nil
 	}
 
 	// initialize status if Conditions is nil, but do not reset if it already
@@ -132,6 +171,10 @@ func (r *OpenStackDataPlaneDeploymentReconciler) Reconcile(ctx context.Context,
 			instance.Status.Conditions.Set(
 				instance.Status.Conditions.Mirror(condition.ReadyCondition))
 		}
+		deployment.UpdateDeploymentFinishTime(instance)
+		if err := deployment.UpdateDeploymentProgress(ctx, helper, instance); err != nil {
+			Log.Error(err, "Error updating the Deployment progress")
+		}
 
 		err := helper.PatchInstance(ctx, instance)
 		if err != nil {
@@ -151,6 +194,11 @@ func (r *OpenStackDataPlaneDeploymentReconciler) Reconcile(ctx context.Context,
 		instance.Status.NodeSetHashes = make(map[string]string)
 	}
 
+	if instance.IsCancelRequested() {
+		Log.Info("Cancelling OpenStackDeployment")
+		return ctrl.Result{}, r.cancelDeployment(ctx, helper, instance)
+	}
+
 	// Ensure NodeSets
 	nodeSets := dataplanev1.OpenStackDataPlaneNodeSetList{}
 	for _, nodeSet := range instance.Spec.NodeSets {
@@ -168,7 +216,7 @@ func (r *OpenStackDataPlaneDeploymentReconciler) Reconcile(ctx context.Context,
 			// NodeSet not found, force a requeue
 			if k8s_errors.IsNotFound(err) {
 				Log.Info("NodeSet not found", "NodeSet", nodeSet)
-				return ctrl.Result{RequeueAfter: time.Second * time.Duration(instance.Spec.DeploymentRequeueTime)}, nil
+				return r.waitForNodeSet(ctx, instance, nodeSet), nil
 			}
 			instance.Status.Conditions.MarkFalse(
 				dataplanev1.SetupReadyCondition,
@@ -182,25 +230,65 @@ func (r *OpenStackDataPlaneDeploymentReconciler) Reconcile(ctx context.Context,
 		nodeSets.Items = append(nodeSets.Items, *nodeSetInstance)
 	}
 
+	// Publish the plan of the Deployment before any ansible execution
+	plan, err := deployment.GetDeploymentPlan(ctx, helper, instance, nodeSets.Items)
+	if err != nil {
+		instance.Status.Conditions.MarkFalse(
+			condition.InputReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityError,
+			dataplanev1.ServiceErrorMessage,
+			err.Error())
+		return ctrl.Result{}, err
+	}
+	planHash, err := util.ObjectHash(plan)
+	if err != nil {
+		instance.Status.Conditions.MarkFalse(
+			condition.InputReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityError,
+			dataplanev1.ServiceErrorMessage,
+			err.Error())
+		return ctrl.Result{}, err
+	}
+	instance.Status.Plan = plan
+	instance.Status.PlanHash = planHash
+
 	// Check that all nodeSets are SetupReady
 	for _, nodeSet := range nodeSets.Items {
 		if !nodeSet.Status.Conditions.IsTrue(dataplanev1.SetupReadyCondition) {
 			Log.Info("NodeSet SetupReadyCondition is not True", "NodeSet", nodeSet.Name)
-			return ctrl.Result{RequeueAfter: time.Second * time.Duration(instance.Spec.DeploymentRequeueTime)}, nil
+			return r.waitForNodeSet(ctx, instance, nodeSet.Name), nil
+		}
+	}
+
+	// Hold the execution of the plan until the Deployment is approved. The
+	// approval is bound to the hash of the plan, a plan which changed since
+	// it was approved needs to be approved again.
+	if !instance.IsApproved() {
+		Log.Info("Deployment waiting for approval", "planHash", planHash)
+		if approved := instance.Annotations[dataplanev1.DeploymentApprovedAnnotation]; approved != "" {
+			instance.Status.Conditions.MarkFalse(
+				condition.InputReadyCondition,
+				dataplanev1.WaitingForApprovalReason,
+				condition.SeverityInfo,
+				dataplanev1.DeploymentPlanChangedMessage,
+				approved, planHash)
+		} else {
+			instance.Status.Conditions.MarkFalse(
+				condition.InputReadyCondition,
+				dataplanev1.WaitingForApprovalReason,
+				condition.SeverityInfo,
+				dataplanev1.DeploymentWaitingForApprovalMessage,
+				planHash)
 		}
+		return r.waitForInput(ctx, instance, "approval", dataplanev1.DeploymentApprovedAnnotation), nil
 	}
 
 	// get TLS certs
 	for _, nodeSet := range nodeSets.Items {
 		if nodeSet.Spec.TLSEnabled {
-			var services []string
-			if len(instance.Spec.ServicesOverride) != 0 {
-				services = instance.Spec.ServicesOverride
-			} else {
-				services = nodeSet.Spec.Services
-			}
-
-			for _, serviceName := range services {
+			for _, serviceName := range deployment.GetDeploymentServices(instance, &nodeSet) {
 				service, err := deployment.GetService(ctx, helper, serviceName)
 				if err != nil {
 					instance.Status.Conditions.MarkFalse(
@@ -213,7 +301,7 @@ func (r *OpenStackDataPlaneDeploymentReconciler) Reconcile(ctx context.Context,
 				}
 				if service.Spec.TLSCert != nil {
 					result, err := deployment.EnsureTLSCerts(ctx, helper, &nodeSet,
-						nodeSet.Status.AllHostnames, nodeSet.Status.AllIPs, service)
+						nodeSet.Status.AllHostnames, nodeSet.Status.AllIPs, service, r.Recorder)
 					if err != nil {
 						instance.Status.Conditions.MarkFalse(
 							condition.InputReadyCondition,
@@ -230,10 +318,59 @@ func (r *OpenStackDataPlaneDeploymentReconciler) Reconcile(ctx context.Context,
 		}
 	}
 
+	// Fetch the OpenStackDataPlaneDeployment being resumed
+	var resumeFrom *dataplanev1.OpenStackDataPlaneDeployment
+	if len(instance.Spec.ResumeFrom) > 0 {
+		resumeFrom = &dataplanev1.OpenStackDataPlaneDeployment{}
+		err := r.Client.Get(
+			ctx,
+			types.NamespacedName{
+				Namespace: instance.GetNamespace(),
+				Name:      instance.Spec.ResumeFrom,
+			},
+			resumeFrom)
+		if err != nil {
+			instance.Status.Conditions.MarkFalse(
+				condition.InputReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityError,
+				dataplanev1.ResumeFromErrorMessage,
+				instance.Spec.ResumeFrom,
+				err.Error())
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Only one Deployment deploys a NodeSet at a time, wait for the older
+	// Deployments of the NodeSets to finish
+	blocking, err := deployment.GetBlockingDeployments(ctx, helper, instance)
+	if err != nil {
+		instance.Status.Conditions.MarkFalse(
+			condition.InputReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityError,
+			condition.InputReadyErrorMessage,
+			err.Error())
+		return ctrl.Result{}, err
+	}
+	if len(blocking) > 0 {
+		Log.Info("Waiting for the older Deployments of the NodeSets", "deployments", blocking)
+		return r.waitForDeployments(ctx, instance, blocking), nil
+	}
+
 	// All nodeSets successfully fetched.
 	// Mark InputReadyCondition=True
 	instance.Status.Conditions.MarkTrue(condition.InputReadyCondition, condition.ReadyMessage)
+	if instance.Status.StartTime == nil {
+		startTime := metav1.Now()
+		instance.Status.StartTime = &startTime
+	}
+	if !savedConditions.IsTrue(condition.InputReadyCondition) {
+		r.Recorder.Eventf(instance, corev1.EventTypeNormal, deployment.DeploymentStartedReason,
+			"Deploying NodeSets %s", strings.Join(instance.Spec.NodeSets, ","))
+	}
 	shouldRequeue := false
+	var requeueAfter time.Duration
 	haveError := false
 	deploymentErrMsg := ""
 
@@ -265,81 +402,151 @@ func (r *OpenStackDataPlaneDeploymentReconciler) Reconcile(ctx context.Context,
 	// The loop starts and checks NodeSet deployments sequentially. However, after they
 	// are started, they are running in parallel, since the loop does not wait
 	// for the first started NodeSet to finish before starting the next.
-	for _, nodeSet := range nodeSets.Items {
-
-		Log.Info(fmt.Sprintf("Deploying NodeSet: %s", nodeSet.Name))
-		Log.Info("Set Status.Deployed to false", "instance", instance)
-		instance.Status.Deployed = false
-		Log.Info("Set DeploymentReadyCondition false")
-		instance.Status.Conditions.MarkFalse(
-			condition.DeploymentReadyCondition, condition.RequestedReason,
-			condition.SeverityInfo, condition.DeploymentReadyRunningMessage)
-		ansibleEESpec := nodeSet.GetAnsibleEESpec()
-		ansibleEESpec.AnsibleTags = instance.Spec.AnsibleTags
-		ansibleEESpec.AnsibleSkipTags = instance.Spec.AnsibleSkipTags
-		ansibleEESpec.AnsibleLimit = instance.Spec.AnsibleLimit
-		ansibleEESpec.ExtraVars = instance.Spec.AnsibleExtraVars
-
-		if nodeSet.Status.DNSClusterAddresses != nil && nodeSet.Status.CtlplaneSearchDomain != "" {
-			ansibleEESpec.DNSConfig = &corev1.PodDNSConfig{
-				Nameservers: nodeSet.Status.DNSClusterAddresses,
-				Searches:    []string{nodeSet.Status.CtlplaneSearchDomain},
+	// NodeSets are grouped in stages by the nodeSetStrategy, the NodeSets of a
+	// stage are only started once all NodeSets of the previous stage are deployed.
+	nodeSetStages := deployment.GetNodeSetStages(instance, nodeSets.Items)
+	for stageIdx, stage := range nodeSetStages {
+		stageDeployed := true
+		for _, nodeSet := range stage {
+
+			Log.Info(fmt.Sprintf("Deploying NodeSet: %s", nodeSet.Name))
+			Log.Info("Set Status.Deployed to false", "instance", instance)
+			instance.Status.Deployed = false
+			Log.Info("Set DeploymentReadyCondition false")
+			instance.Status.Conditions.MarkFalse(
+				condition.DeploymentReadyCondition, condition.RequestedReason,
+				condition.SeverityInfo, condition.DeploymentReadyRunningMessage)
+			ansibleEESpec := nodeSet.GetAnsibleEESpec()
+			ansibleEESpec.AnsibleTags = instance.Spec.AnsibleTags
+			ansibleEESpec.AnsibleSkipTags = instance.Spec.AnsibleSkipTags
+			ansibleEESpec.AnsibleLimit = instance.Spec.AnsibleLimit
+			ansibleEESpec.ExtraVars = instance.Spec.AnsibleExtraVars
+
+			if nodeSet.Status.DNSClusterAddresses != nil && nodeSet.Status.CtlplaneSearchDomain != "" {
+				ansibleEESpec.DNSConfig = &corev1.PodDNSConfig{
+					Nameservers: nodeSet.Status.DNSClusterAddresses,
+					Searches:    []string{nodeSet.Status.CtlplaneSearchDomain},
+				}
 			}
-		}
 
-		deployer := deployment.Deployer{
-			Ctx:                         ctx,
-			Helper:                      helper,
-			NodeSet:                     &nodeSet,
-			Deployment:                  instance,
-			Status:                      &instance.Status,
-			AeeSpec:                     &ansibleEESpec,
-			InventorySecrets:            globalInventorySecrets,
-			AnsibleSSHPrivateKeySecrets: globalSSHKeySecrets,
-		}
+			deployer := deployment.Deployer{
+				Ctx:                         ctx,
+				Helper:                      helper,
+				NodeSet:                     &nodeSet,
+				Deployment:                  instance,
+				Status:                      &instance.Status,
+				AeeSpec:                     &ansibleEESpec,
+				InventorySecrets:            globalInventorySecrets,
+				AnsibleSSHPrivateKeySecrets: globalSSHKeySecrets,
+				ResumeFrom:                  resumeFrom,
+				Recorder:                    r.Recorder,
+				ExecutionQueue:              r.ExecutionQueue,
+			}
 
-		// When ServicesOverride is set on the OpenStackDataPlaneDeployment,
-		// deploy those services for each OpenStackDataPlaneNodeSet. Otherwise,
-		// deploy with the OpenStackDataPlaneNodeSet's Services.
-		var deployResult *ctrl.Result
-		if len(instance.Spec.ServicesOverride) != 0 {
-			deployResult, err = deployer.Deploy(instance.Spec.ServicesOverride)
-		} else {
-			deployResult, err = deployer.Deploy(nodeSet.Spec.Services)
-		}
+			// When ServicesOverride is set on the OpenStackDataPlaneDeployment,
+			// deploy those services for each OpenStackDataPlaneNodeSet. Otherwise,
+			// deploy with the OpenStackDataPlaneNodeSet's Services.
+			var deployResult *ctrl.Result
+			if len(instance.Spec.ServicesOverride) != 0 {
+				deployResult, err = deployer.Deploy(instance.Spec.ServicesOverride)
+			} else {
+				deployResult, err = deployer.Deploy(nodeSet.Spec.Services)
+			}
 
-		nsConditions := instance.Status.NodeSetConditions[nodeSet.Name]
+			nsConditions := instance.Status.NodeSetConditions[nodeSet.Name]
+
+			if err != nil {
+				util.LogErrorForObject(helper, err, fmt.Sprintf("OpenStackDeployment error for NodeSet %s", nodeSet.Name), instance)
+				Log.Info("Set NodeSetDeploymentReadyCondition false", "nodeSet", nodeSet.Name)
+				haveError = true
+				errMsg := fmt.Sprintf("nodeSet: %s error: %s", nodeSet.Name, err.Error())
+				if len(deploymentErrMsg) == 0 {
+					deploymentErrMsg = errMsg
+				} else {
+					deploymentErrMsg = fmt.Sprintf("%s & %s", deploymentErrMsg, errMsg)
+				}
+				nsConditions.MarkFalse(
+					dataplanev1.NodeSetDeploymentReadyCondition,
+					condition.ErrorReason,
+					condition.SeverityError,
+					condition.DeploymentReadyErrorMessage,
+					err.Error())
+			}
 
-		if err != nil {
-			util.LogErrorForObject(helper, err, fmt.Sprintf("OpenStackDeployment error for NodeSet %s", nodeSet.Name), instance)
-			Log.Info("Set NodeSetDeploymentReadyCondition false", "nodeSet", nodeSet.Name)
-			haveError = true
-			errMsg := fmt.Sprintf("nodeSet: %s error: %s", nodeSet.Name, err.Error())
-			if len(deploymentErrMsg) == 0 {
-				deploymentErrMsg = errMsg
+			if deployResult != nil {
+				shouldRequeue = true
+				stageDeployed = false
+				// Keep the shortest delay requested by a NodeSet, e.g. when
+				// pausing between batches
+				if deployResult.RequeueAfter > 0 && (requeueAfter == 0 || deployResult.RequeueAfter < requeueAfter) {
+					requeueAfter = deployResult.RequeueAfter
+				}
 			} else {
-				deploymentErrMsg = fmt.Sprintf("%s & %s", deploymentErrMsg, errMsg)
+				Log.Info("OpenStackDeployment succeeded for NodeSet", "NodeSet", nodeSet.Name)
+				Log.Info("Set NodeSetDeploymentReadyCondition true", "nodeSet", nodeSet.Name)
+				nsConditions.MarkTrue(
+					dataplanev1.NodeSetDeploymentReadyCondition,
+					condition.DeploymentReadyMessage)
 			}
-			nsConditions.MarkFalse(
-				dataplanev1.NodeSetDeploymentReadyCondition,
-				condition.ErrorReason,
-				condition.SeverityError,
-				condition.DeploymentReadyErrorMessage,
-				err.Error())
 		}
 
-		if deployResult != nil {
-			shouldRequeue = true
-		} else {
-			Log.Info("OpenStackDeployment succeeded for NodeSet", "NodeSet", nodeSet.Name)
-			Log.Info("Set NodeSetDeploymentReadyCondition true", "nodeSet", nodeSet.Name)
-			nsConditions.MarkTrue(
-				dataplanev1.NodeSetDeploymentReadyCondition,
-				condition.DeploymentReadyMessage)
+		if !stageDeployed {
+			// Do not start the following stages until this one is deployed
+			stageNodeSets := strings.Join(deployment.GetNodeSetNames(stage), ",")
+			for _, laterStage := range nodeSetStages[stageIdx+1:] {
+				for _, nodeSet := range laterStage {
+					nsConditions := instance.Status.NodeSetConditions[nodeSet.Name]
+					if haveError {
+						nsConditions.MarkFalse(
+							dataplanev1.NodeSetDeploymentReadyCondition,
+							condition.RequestedReason,
+							condition.SeverityWarning,
+							dataplanev1.NodeSetDeploymentStageFailedMessage,
+							stageNodeSets)
+					} else {
+						nsConditions.MarkFalse(
+							dataplanev1.NodeSetDeploymentReadyCondition,
+							condition.RequestedReason,
+							condition.SeverityInfo,
+							dataplanev1.NodeSetDeploymentWaitingForStageMessage,
+							stageNodeSets)
+					}
+					instance.Status.NodeSetConditions[nodeSet.Name] = nsConditions
+				}
+			}
+			break
 		}
 	}
 
+	// Record the results of the finished ansible executions, whatever the
+	// outcome of the Deployment
+	err = deployment.UpdateDeploymentResults(ctx, helper, instance)
+	if err != nil {
+		util.LogErrorForObject(helper, err, "Unable to update the Deployment results", instance)
+	}
+	metrics.SetExecutionsInFlight(instance.Namespace, instance.Name, deployment.GetExecutionsInFlight(&instance.Status))
+
+	if deployment.DeploymentTimedOut(instance) && (haveError || shouldRequeue) {
+		// The NodeSets not deployed yet are not started anymore, stop
+		// requeueing
+		Log.Info("OpenStackDeployment timed out")
+		if savedReady := savedConditions.Get(condition.DeploymentReadyCondition); savedReady == nil || savedReady.Reason != dataplanev1.TimedOutReason {
+			r.Recorder.Eventf(instance, corev1.EventTypeWarning, deployment.DeploymentTimedOutReason,
+				"Deployment timed out after %s", deployment.GetDeploymentTimeout(instance).String())
+		}
+		instance.Status.Conditions.MarkFalse(
+			condition.DeploymentReadyCondition,
+			dataplanev1.TimedOutReason,
+			condition.SeverityError,
+			dataplanev1.DeploymentTimedOutMessage,
+			deployment.GetDeploymentTimeout(instance).String())
+		return ctrl.Result{}, nil
+	}
+
 	if haveError {
+		if savedReady := savedConditions.Get(condition.DeploymentReadyCondition); savedReady == nil || savedReady.Reason != condition.ErrorReason {
+			r.Recorder.Event(instance, corev1.EventTypeWarning, deployment.DeploymentFailedReason, deploymentErrMsg)
+		}
 		instance.Status.Conditions.MarkFalse(
 			condition.DeploymentReadyCondition,
 			condition.ErrorReason,
@@ -350,21 +557,174 @@ func (r *OpenStackDataPlaneDeploymentReconciler) Reconcile(ctx context.Context,
 	}
 
 	if shouldRequeue {
+		if instance.IsPaused() {
+			Log.Info("OpenStackDeployment paused")
+			instance.Status.Conditions.MarkFalse(
+				condition.DeploymentReadyCondition,
+				dataplanev1.PausedReason,
+				condition.SeverityInfo,
+				dataplanev1.DeploymentPausedMessage)
+		}
 		Log.Info("Not all NodeSets done for OpenStackDeployment")
-		return ctrl.Result{}, nil
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	}
+
+	// Check mode Deployments report the changes which would have been made,
+	// and do not record the hashes of a deployed configuration
+	if instance.IsCheckMode() {
+		err = deployment.GenerateCheckReport(ctx, helper, instance)
+		if err != nil {
+			util.LogErrorForObject(helper, err, "Unable to generate the check report", instance)
+			instance.Status.Conditions.MarkFalse(
+				condition.DeploymentReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityError,
+				condition.DeploymentReadyErrorMessage,
+				err.Error())
+			return ctrl.Result{}, err
+		}
 	}
 
 	Log.Info("Set DeploymentReadyCondition true")
 	instance.Status.Conditions.MarkTrue(condition.DeploymentReadyCondition, condition.DeploymentReadyMessage)
 	instance.Status.Deployed = true
-	err = r.setHashes(ctx, helper, instance, nodeSets)
-	if err != nil {
-		Log.Error(err, "Error setting service hashes")
+	if !instance.IsCheckMode() {
+		err = r.setHashes(ctx, helper, instance, nodeSets)
+		if err != nil {
+			Log.Error(err, "Error setting service hashes")
+		}
 	}
+	r.Recorder.Eventf(instance, corev1.EventTypeNormal, deployment.DeploymentSucceededReason,
+		"Deployed NodeSets %s", strings.Join(instance.Spec.NodeSets, ","))
+	metrics.DeleteDeploymentMetrics(instance.Namespace, instance.Name)
 	Log.Info("Set status deploy true", "instance", instance)
 	return ctrl.Result{}, nil
 }
 
+// cancelDeployment stops the Deployment, the services of the NodeSets which
+// can not be fetched or split in batches are not marked as Cancelled
+func (r *OpenStackDataPlaneDeploymentReconciler) cancelDeployment(
+	ctx context.Context,
+	helper *helper.Helper,
+	instance *dataplanev1.OpenStackDataPlaneDeployment,
+) error {
+	nodeSetServices := map[string][]string{}
+	nodeSetBatches := map[string]int{}
+	for _, nodeSet := range instance.Spec.NodeSets {
+		nodeSetInstance := &dataplanev1.OpenStackDataPlaneNodeSet{}
+		err := r.Client.Get(
+			ctx,
+			types.NamespacedName{
+				Namespace: instance.GetNamespace(),
+				Name:      nodeSet,
+			},
+			nodeSetInstance)
+		if err != nil {
+			if k8s_errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		batches, _, err := deployment.GetNodeSetBatches(
+			nodeSetInstance, instance.Spec.RolloutStrategy, instance.Spec.Canary)
+		if err != nil {
+			continue
+		}
+		nodeSetBatches[nodeSet] = len(batches)
+		if len(instance.Spec.ServicesOverride) != 0 {
+			nodeSetServices[nodeSet] = instance.Spec.ServicesOverride
+		} else {
+			nodeSetServices[nodeSet] = nodeSetInstance.Spec.Services
+		}
+	}
+
+	err := deployment.CancelDeployment(ctx, helper, instance, nodeSetServices, nodeSetBatches)
+	if err != nil {
+		util.LogErrorForObject(helper, err, "Unable to cancel OpenStackDeployment", instance)
+		instance.Status.Conditions.MarkFalse(
+			condition.DeploymentReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityError,
+			condition.DeploymentReadyErrorMessage,
+			err.Error())
+	} else if instance.Status.Cancelled {
+		r.Recorder.Event(instance, corev1.EventTypeWarning, deployment.DeploymentCancelledReason,
+			dataplanev1.DeploymentCancelledMessage)
+		metrics.DeleteDeploymentMetrics(instance.Namespace, instance.Name)
+	}
+	return err
+}
+
+// waitForNodeSet returns when to check again whether a NodeSet is SetupReady.
+// The Deployment stops waiting for the NodeSet once it timed out.
+func (r *OpenStackDataPlaneDeploymentReconciler) waitForNodeSet(
+	ctx context.Context,
+	instance *dataplanev1.OpenStackDataPlaneDeployment,
+	nodeSet string,
+) ctrl.Result {
+	return r.waitForInput(ctx, instance, "NodeSet", nodeSet)
+}
+
+// waitForDeployments returns when to check again whether the older Deployments
+// of the NodeSets are finished. The Deployment stops waiting once it timed out.
+func (r *OpenStackDataPlaneDeploymentReconciler) waitForDeployments(
+	ctx context.Context,
+	instance *dataplanev1.OpenStackDataPlaneDeployment,
+	blocking map[string][]string,
+) ctrl.Result {
+	names := []string{}
+	for nodeSet, deployments := range blocking {
+		nsConditions := instance.Status.NodeSetConditions[nodeSet]
+		nsConditions.MarkFalse(
+			dataplanev1.NodeSetDeploymentReadyCondition,
+			dataplanev1.WaitingForDeploymentReason,
+			condition.SeverityInfo,
+			dataplanev1.NodeSetDeploymentWaitingForDeploymentsMessage,
+			strings.Join(deployments, ","))
+		instance.Status.NodeSetConditions[nodeSet] = nsConditions
+		for _, name := range deployments {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	instance.Status.Conditions.MarkFalse(
+		condition.InputReadyCondition,
+		dataplanev1.WaitingForDeploymentReason,
+		condition.SeverityInfo,
+		dataplanev1.DeploymentWaitingForDeploymentsMessage,
+		strings.Join(names, ","))
+
+	return r.waitForInput(ctx, instance, "Deployments", strings.Join(names, ","))
+}
+
+// waitForInput returns when to check again whether the input of the
+// Deployment is ready, or marks the Deployment as timed out
+func (r *OpenStackDataPlaneDeploymentReconciler) waitForInput(
+	ctx context.Context,
+	instance *dataplanev1.OpenStackDataPlaneDeployment,
+	input string,
+	name string,
+) ctrl.Result {
+	if deployment.DeploymentTimedOut(instance) {
+		r.GetLogger(ctx).Info(fmt.Sprintf("Deployment timed out waiting for %s", input), input, name)
+		instance.Status.Conditions.MarkFalse(
+			condition.InputReadyCondition,
+			dataplanev1.TimedOutReason,
+			condition.SeverityError,
+			dataplanev1.DeploymentTimedOutMessage,
+			deployment.GetDeploymentTimeout(instance).String())
+		return ctrl.Result{}
+	}
+
+	requeueAfter := time.Second * time.Duration(instance.Spec.DeploymentRequeueTime)
+	if deadline := deployment.GetDeploymentDeadline(instance); !deadline.IsZero() && time.Until(deadline) < requeueAfter {
+		requeueAfter = time.Until(deadline)
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}
+}
+
 func (r *OpenStackDataPlaneDeploymentReconciler) setHashes(
 	ctx context.Context,
 	helper *helper.Helper,
@@ -0,0 +1,310 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/go-logr/logr"
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/dataplane-operator/pkg/deployment"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+)
+
+// OpenStackDataPlaneDeploymentScheduleReconciler reconciles a OpenStackDataPlaneDeploymentSchedule object
+type OpenStackDataPlaneDeploymentScheduleReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+}
+
+// GetLogger returns a logger object with a prefix of "controller.name" and additional controller context fields
+func (r *OpenStackDataPlaneDeploymentScheduleReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("OpenStackDataPlaneDeploymentSchedule")
+}
+
+//+kubebuilder:rbac:groups=dataplane.openstack.org,resources=openstackdataplanedeploymentschedules,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=dataplane.openstack.org,resources=openstackdataplanedeploymentschedules/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=dataplane.openstack.org,resources=openstackdataplanedeploymentschedules/finalizers,verbs=update
+//+kubebuilder:rbac:groups=dataplane.openstack.org,resources=openstackdataplanedeployments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=ansibleee.openstack.org,resources=openstackansibleees,verbs=get;list;watch;delete;deletecollection
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *OpenStackDataPlaneDeploymentScheduleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling Deployment Schedule")
+
+	// Check if schedule name matches RFC1123 for use in labels
+	validate := validator.New()
+	if err := validate.Var(req.Name, "hostname_rfc1123"); err != nil {
+		Log.Error(err, "error validating OpenStackDataPlaneDeploymentSchedule name, the name must follow RFC1123")
+		return ctrl.Result{}, err
+	}
+	// Fetch the OpenStackDataPlaneDeploymentSchedule instance
+	instance := &dataplanev1.OpenStackDataPlaneDeploymentSchedule{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected.
+			// For additional cleanup logic use finalizers. Return and don't requeue.
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, _ := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		Log,
+	)
+
+	// initialize status if Conditions is nil, but do not reset if it already
+	// exists
+	isNewInstance := instance.Status.Conditions == nil
+	if isNewInstance {
+		instance.Status.Conditions = condition.Conditions{}
+	}
+
+	// Save a copy of the conditions so that we can restore the LastTransitionTime
+	// when a condition's state doesn't change.
+	savedConditions := instance.Status.Conditions.DeepCopy()
+
+	// Reset all conditions to Unknown as the state is not yet known for
+	// this reconcile loop.
+	instance.InitConditions()
+	// Set ObservedGeneration since we've reset conditions
+	instance.Status.ObservedGeneration = instance.Generation
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() { // update the Ready condition based on the sub conditions
+		condition.RestoreLastTransitionTimes(
+			&instance.Status.Conditions, savedConditions)
+		if instance.Status.Conditions.AllSubConditionIsTrue() {
+			instance.Status.Conditions.MarkTrue(
+				condition.ReadyCondition, condition.ReadyMessage)
+		} else if instance.Status.Conditions.IsUnknown(condition.ReadyCondition) {
+			// Recalculate ReadyCondition based on the state of the rest of the conditions
+			instance.Status.Conditions.Set(
+				instance.Status.Conditions.Mirror(condition.ReadyCondition))
+		}
+
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			Log.Error(err, "Error updating instance status conditions")
+			_err = err
+			return
+		}
+	}()
+
+	schedule, err := dataplanev1.ParseCronSchedule(instance.Spec.Schedule)
+	if err != nil {
+		// The schedule is only parsed again once the spec is updated
+		instance.Status.Conditions.MarkFalse(
+			dataplanev1.DeploymentScheduleReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityError,
+			dataplanev1.DeploymentScheduleErrorMessage,
+			err.Error())
+		return ctrl.Result{}, nil
+	}
+
+	// Record the running Deployments of the schedule, and delete the
+	// finished ones beyond the history limit
+	deployments, err := deployment.GetScheduleDeployments(ctx, helper, instance)
+	if err != nil {
+		instance.Status.Conditions.MarkFalse(
+			dataplanev1.DeploymentScheduleReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityError,
+			dataplanev1.DeploymentScheduleErrorMessage,
+			err.Error())
+		return ctrl.Result{}, err
+	}
+	instance.Status.Active = nil
+	for idx := range deployments {
+		if deployment.GetDeploymentOutcome(&deployments[idx]) == dataplanev1.DeploymentOutcomeRunning {
+			instance.Status.Active = append(instance.Status.Active, deployments[idx].Name)
+		}
+	}
+	err = deployment.PruneScheduleDeployments(ctx, helper, instance, deployments)
+	if err != nil {
+		instance.Status.Conditions.MarkFalse(
+			dataplanev1.DeploymentScheduleReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityError,
+			dataplanev1.DeploymentScheduleErrorMessage,
+			err.Error())
+		return ctrl.Result{}, err
+	}
+
+	if instance.Spec.Suspend {
+		Log.Info("Schedule suspended")
+		instance.Status.NextScheduleTime = nil
+		instance.Status.Conditions.MarkTrue(
+			dataplanev1.DeploymentScheduleReadyCondition,
+			dataplanev1.DeploymentScheduleSuspendedMessage)
+		return ctrl.Result{}, nil
+	}
+
+	now := time.Now().UTC()
+	scheduled, next := deployment.GetScheduleTimes(schedule, instance, now)
+	if next.IsZero() {
+		instance.Status.NextScheduleTime = nil
+		instance.Status.Conditions.MarkFalse(
+			dataplanev1.DeploymentScheduleReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityError,
+			dataplanev1.DeploymentScheduleErrorMessage,
+			"the schedule never runs")
+		return ctrl.Result{}, nil
+	}
+	instance.Status.NextScheduleTime = &metav1.Time{Time: next}
+	instance.Status.Conditions.MarkTrue(
+		dataplanev1.DeploymentScheduleReadyCondition,
+		dataplanev1.DeploymentScheduleReadyMessage,
+		next.Format(time.RFC3339))
+	nextResult := ctrl.Result{RequeueAfter: next.Sub(now)}
+
+	if scheduled.IsZero() {
+		return nextResult, nil
+	}
+
+	// Never run two Deployments on the same NodeSets at the same time
+	running, err := deployment.GetRunningDeployments(ctx, helper, instance.Namespace, instance.Spec.DeploymentTemplate.NodeSets)
+	if err != nil {
+		instance.Status.Conditions.MarkFalse(
+			dataplanev1.DeploymentScheduleReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityError,
+			dataplanev1.DeploymentScheduleErrorMessage,
+			err.Error())
+		return ctrl.Result{}, err
+	}
+	if len(running) > 0 {
+		runningNames := []string{}
+		replace := instance.Spec.ConcurrencyPolicy == dataplanev1.ConcurrencyPolicyReplace
+		for idx := range running {
+			runningNames = append(runningNames, running[idx].Name)
+			if running[idx].Labels[dataplanev1.DeploymentScheduleLabel] != instance.Name {
+				replace = false
+			}
+		}
+
+		if replace {
+			Log.Info("Cancelling running Deployments of the schedule", "deployments", runningNames)
+			for idx := range running {
+				err = r.cancelDeployment(ctx, &running[idx])
+				if err != nil {
+					instance.Status.Conditions.MarkFalse(
+						dataplanev1.DeploymentScheduleReadyCondition,
+						condition.ErrorReason,
+						condition.SeverityError,
+						dataplanev1.DeploymentScheduleErrorMessage,
+						err.Error())
+					return ctrl.Result{}, err
+				}
+			}
+			// The scheduled run starts once the Deployments are cancelled
+			return ctrl.Result{RequeueAfter: time.Second * time.Duration(instance.Spec.DeploymentTemplate.DeploymentRequeueTime)}, nil
+		}
+
+		Log.Info("Skipping scheduled Deployment, Deployments are running on the NodeSets",
+			"scheduled", scheduled, "deployments", runningNames)
+		instance.Status.LastScheduleTime = &metav1.Time{Time: scheduled}
+		instance.Status.LastSkippedTime = &metav1.Time{Time: scheduled}
+		return nextResult, nil
+	}
+
+	deploymentInstance := &dataplanev1.OpenStackDataPlaneDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deployment.GetScheduleDeploymentName(instance, scheduled),
+			Namespace: instance.Namespace,
+			Labels: map[string]string{
+				dataplanev1.DeploymentScheduleLabel: instance.Name,
+			},
+			Annotations: map[string]string{
+				dataplanev1.DeploymentScheduledTimeAnnotation: scheduled.Format(time.RFC3339),
+			},
+		},
+		Spec: *instance.Spec.DeploymentTemplate.DeepCopy(),
+	}
+	err = controllerutil.SetControllerReference(instance, deploymentInstance, helper.GetScheme())
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	err = r.Client.Create(ctx, deploymentInstance)
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		instance.Status.Conditions.MarkFalse(
+			dataplanev1.DeploymentScheduleReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityError,
+			dataplanev1.DeploymentScheduleErrorMessage,
+			err.Error())
+		return ctrl.Result{}, err
+	}
+	if err == nil {
+		Log.Info("Created scheduled Deployment", "deployment", deploymentInstance.Name, "scheduled", scheduled)
+		instance.Status.Active = append(instance.Status.Active, deploymentInstance.Name)
+	}
+	instance.Status.LastScheduleTime = &metav1.Time{Time: scheduled}
+	instance.Status.LastDeployment = deploymentInstance.Name
+
+	return nextResult, nil
+}
+
+// cancelDeployment requests the cancellation of a running Deployment of the
+// schedule
+func (r *OpenStackDataPlaneDeploymentScheduleReconciler) cancelDeployment(
+	ctx context.Context,
+	instance *dataplanev1.OpenStackDataPlaneDeployment,
+) error {
+	if instance.IsCancelRequested() {
+		return nil
+	}
+	patch := client.MergeFrom(instance.DeepCopy())
+	if instance.Annotations == nil {
+		instance.Annotations = map[string]string{}
+	}
+	instance.Annotations[dataplanev1.DeploymentCancelAnnotation] = "true"
+	return r.Client.Patch(ctx, instance, patch)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *OpenStackDataPlaneDeploymentScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dataplanev1.OpenStackDataPlaneDeploymentSchedule{}).
+		Owns(&dataplanev1.OpenStackDataPlaneDeployment{}).
+		Complete(r)
+}
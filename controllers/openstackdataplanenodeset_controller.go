@@ -32,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -43,6 +44,7 @@ import (
 	"github.com/go-logr/logr"
 	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
 	"github.com/openstack-k8s-operators/dataplane-operator/pkg/deployment"
+	"github.com/openstack-k8s-operators/dataplane-operator/pkg/metrics"
 	infranetworkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
@@ -111,8 +113,9 @@ const (
 // OpenStackDataPlaneNodeSetReconciler reconciles a OpenStackDataPlaneNodeSet object
 type OpenStackDataPlaneNodeSetReconciler struct {
 	client.Client
-	Kclient kubernetes.Interface
-	Scheme  *runtime.Scheme
+	Kclient  kubernetes.Interface
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 }
 
 // GetLogger returns a logger object with a prefix of "controller.name" and additional controller context fields
@@ -124,6 +127,8 @@ func (r *OpenStackDataPlaneNodeSetReconciler) GetLogger(ctx context.Context) log
 //+kubebuilder:rbac:groups=dataplane.openstack.org,resources=openstackdataplanenodesets/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=dataplane.openstack.org,resources=openstackdataplanenodesets/finalizers,verbs=update
 //+kubebuilder:rbac:groups=dataplane.openstack.org,resources=openstackdataplaneservices,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=dataplane.openstack.org,resources=openstackdataplanedeployments,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups=ansibleee.openstack.org,resources=openstackansibleees,verbs=get;list;watch;delete;deletecollection
 //+kubebuilder:rbac:groups=dataplane.openstack.org,resources=openstackdataplaneservices/finalizers,verbs=update
 //+kubebuilder:rbac:groups=baremetal.openstack.org,resources=openstackbaremetalsets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=baremetal.openstack.org,resources=openstackbaremetalsets/status,verbs=get
@@ -142,6 +147,7 @@ func (r *OpenStackDataPlaneNodeSetReconciler) GetLogger(ctx context.Context) log
 //+kubebuilder:rbac:groups=network.openstack.org,resources=dnsdata/status,verbs=get
 //+kubebuilder:rbac:groups=network.openstack.org,resources=dnsdata/finalizers,verbs=update
 //+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete;
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // RBAC for the ServiceAccount for the internal image registry
 //+kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;update
@@ -187,6 +193,7 @@ func (r *OpenStackDataPlaneNodeSetReconciler) Reconcile(ctx context.Context, req
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected.
 			// For additional cleanup logic use finalizers. Return and don't requeue.
+			metrics.DeleteNodeSetMetrics(req.Namespace, req.Name)
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -220,6 +227,8 @@ func (r *OpenStackDataPlaneNodeSetReconciler) Reconcile(ctx context.Context, req
 
 	// Always patch the instance status when exiting this function so we can persist any changes.
 	defer func() { // update the Ready condition based on the sub conditions
+		deployment.RecordNodeSetReadinessEvents(r.Recorder, instance, savedConditions)
+		metrics.SetNodeSetMetrics(instance)
 		condition.RestoreLastTransitionTimes(
 			&instance.Status.Conditions, savedConditions)
 		if instance.Status.Conditions.AllSubConditionIsTrue() {
@@ -289,6 +298,7 @@ func (r *OpenStackDataPlaneNodeSetReconciler) Reconcile(ctx context.Context, req
 	instance.Status.CtlplaneSearchDomain = dnsData.CtlplaneSearchDomain
 	instance.Status.AllHostnames = dnsData.Hostnames
 	instance.Status.AllIPs = dnsData.AllIPs
+	deployment.InitNodeStatuses(instance)
 
 	ansibleSSHPrivateKeySecret := instance.Spec.NodeTemplate.AnsibleSSHPrivateKeySecret
 
@@ -445,6 +455,20 @@ func (r *OpenStackDataPlaneNodeSetReconciler) Reconcile(ctx context.Context, req
 	}
 
 	deploymentExists, isDeploymentReady, err := checkDeployment(helper, instance)
+
+	// Record the results of the finished Deployments on each node, before
+	// they are pruned
+	if nodesErr := deployment.UpdateNodeDeploymentStatuses(ctx, helper, instance); nodesErr != nil {
+		Log.Error(nodesErr, "Unable to update the deployment status of the nodes")
+		return ctrl.Result{}, nodesErr
+	}
+
+	// Delete the finished Deployments of the NodeSet beyond its retention or
+	// after their TTL, including failed ones
+	if pruneErr := deployment.PruneNodeSetDeployments(ctx, helper, instance); pruneErr != nil {
+		Log.Error(pruneErr, "Unable to delete the finished OpenStackDataPlaneDeployments")
+		return ctrl.Result{}, pruneErr
+	}
 	if err != nil {
 		instance.Status.Conditions.MarkFalse(
 			condition.DeploymentReadyCondition,
@@ -470,7 +494,14 @@ func (r *OpenStackDataPlaneNodeSetReconciler) Reconcile(ctx context.Context, req
 			condition.RequestedReason, condition.SeverityInfo,
 			condition.DeploymentReadyInitMessage)
 	}
-	return ctrl.Result{}, nil
+
+	// Deploy the configuration drift when the AutoDeploy policy is set
+	result, err = deployment.ReconcileAutoDeploy(ctx, helper, instance)
+	if err != nil {
+		Log.Error(err, "Unable to deploy the NodeSet configuration automatically")
+		return ctrl.Result{}, err
+	}
+	return result, nil
 }
 
 func checkDeployment(helper *helper.Helper,
@@ -492,16 +523,7 @@ func checkDeployment(helper *helper.Helper,
 
 	// Sort deployments from oldest to newest by the LastTransitionTime of
 	// their DeploymentReadyCondition
-	slices.SortFunc(deployments.Items, func(a, b dataplanev1.OpenStackDataPlaneDeployment) int {
-		aReady := a.Status.Conditions.Get(condition.DeploymentReadyCondition)
-		bReady := b.Status.Conditions.Get(condition.DeploymentReadyCondition)
-		if aReady != nil && bReady != nil {
-			if aReady.LastTransitionTime.Before(&bReady.LastTransitionTime) {
-				return -1
-			}
-		}
-		return 1
-	})
+	deployment.SortDeploymentsByReadyTime(deployments.Items)
 
 	for _, deployment := range deployments.Items {
 		if !deployment.DeletionTimestamp.IsZero() {
@@ -509,6 +531,23 @@ func checkDeployment(helper *helper.Helper,
 		}
 		if slices.Contains(
 			deployment.Spec.NodeSets, instance.Name) {
+			if instance.Status.DeploymentStatuses == nil {
+				instance.Status.DeploymentStatuses = make(map[string]condition.Conditions)
+			}
+			// Check mode Deployments do not change the nodes, so they do not
+			// affect the deployment state of the NodeSet
+			if deployment.IsCheckMode() {
+				instance.Status.DeploymentStatuses[deployment.Name] = deployment.Status.NodeSetConditions[instance.Name]
+				continue
+			}
+			// A cancelled Deployment is finished, without having deployed
+			// the NodeSet
+			if deployment.Status.Cancelled {
+				instance.Status.DeploymentStatuses[deployment.Name] = deployment.Status.NodeSetConditions[instance.Name]
+				deploymentExists = false
+				isDeploymentReady = false
+				continue
+			}
 			deploymentExists = true
 			isDeploymentReady = false
 			if deployment.Status.Deployed {
@@ -522,16 +561,21 @@ func checkDeployment(helper *helper.Helper,
 				instance.Status.DeployedConfigHash = deployment.Status.NodeSetHashes[instance.Name]
 			}
 			deploymentConditions := deployment.Status.NodeSetConditions[instance.Name]
-			if instance.Status.DeploymentStatuses == nil {
-				instance.Status.DeploymentStatuses = make(map[string]condition.Conditions)
-			}
 			instance.Status.DeploymentStatuses[deployment.Name] = deploymentConditions
-			if condition.IsError(deployment.Status.Conditions.Get(condition.ReadyCondition)) {
+			if deployment.IsFailed() {
 				err = fmt.Errorf("check deploymentStatuses for more details")
 			}
 		}
 	}
 
+	// Record the Deployments of the NodeSet in its history
+	for idx := range deployments.Items {
+		if deployments.Items[idx].DeletionTimestamp.IsZero() &&
+			slices.Contains(deployments.Items[idx].Spec.NodeSets, instance.Name) {
+			deployment.UpdateDeploymentHistory(instance, &deployments.Items[idx])
+		}
+	}
+
 	return deploymentExists, isDeploymentReady, err
 }
 
@@ -701,8 +745,14 @@ func (r *OpenStackDataPlaneNodeSetReconciler) deploymentWatcherFn(
 
 // GetSpecConfigHash initialises a new struct with only the field we want to check for variances in.
 // We then hash the contents of the new struct using md5 and return the hashed string.
+// The AutoDeploy policy and the MaintenanceWindows do not change the
+// configuration of the nodes, so they are not part of the hash.
 func (r *OpenStackDataPlaneNodeSetReconciler) GetSpecConfigHash(instance *dataplanev1.OpenStackDataPlaneNodeSet) (string, error) {
-	configHash, err := util.ObjectHash(&instance.Spec)
+	spec := instance.Spec.DeepCopy()
+	spec.AutoDeploy = nil
+	spec.MaintenanceWindows = nil
+	spec.DeploymentRetention = nil
+	configHash, err := util.ObjectHash(spec)
 	if err != nil {
 		return "", err
 	}
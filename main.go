@@ -48,6 +48,7 @@ import (
 	certmgrmetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
 	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
 	"github.com/openstack-k8s-operators/dataplane-operator/controllers"
+	"github.com/openstack-k8s-operators/dataplane-operator/pkg/deployment"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -74,12 +75,18 @@ func main() {
 	var enableLeaderElection bool
 	var probeAddr string
 	var enableHTTP2 bool
+	var maxExecutions int
+	var maxExecutionsPerNamespace int
 	flag.BoolVar(&enableHTTP2, "enable-http2", enableHTTP2, "If HTTP/2 should be enabled for the metrics and webhook servers.")
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.IntVar(&maxExecutions, "max-concurrent-ansible-executions", 0,
+		"The maximum number of OpenStackAnsibleEE executions running at the same time, 0 for no limit.")
+	flag.IntVar(&maxExecutionsPerNamespace, "max-concurrent-ansible-executions-per-namespace", 0,
+		"The maximum number of OpenStackAnsibleEE executions running at the same time in a namespace, 0 for no limit.")
 	devMode, err := strconv.ParseBool(os.Getenv("DEV_MODE"))
 	if err != nil {
 		devMode = true
@@ -131,9 +138,10 @@ func main() {
 
 	controllers.SetupAnsibleImageDefaults()
 	if err = (&controllers.OpenStackDataPlaneNodeSetReconciler{
-		Client:  mgr.GetClient(),
-		Scheme:  mgr.GetScheme(),
-		Kclient: kclient,
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Kclient:  kclient,
+		Recorder: mgr.GetEventRecorderFor("openstackdataplanenodeset-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "OpenStackDataPlaneNodeSet")
 		os.Exit(1)
@@ -159,11 +167,21 @@ func main() {
 	}
 
 	if err = (&controllers.OpenStackDataPlaneDeploymentReconciler{
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+		Kclient:        kclient,
+		Recorder:       mgr.GetEventRecorderFor("openstackdataplanedeployment-controller"),
+		ExecutionQueue: deployment.NewExecutionQueue(maxExecutions, maxExecutionsPerNamespace),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "OpenStackDataPlaneDeployment")
+		os.Exit(1)
+	}
+	if err = (&controllers.OpenStackDataPlaneDeploymentScheduleReconciler{
 		Client:  mgr.GetClient(),
 		Scheme:  mgr.GetScheme(),
 		Kclient: kclient,
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "OpenStackDataPlaneDeployment")
+		setupLog.Error(err, "unable to create controller", "controller", "OpenStackDataPlaneDeploymentSchedule")
 		os.Exit(1)
 	}
 	//+kubebuilder:scaffold:builder
@@ -0,0 +1,214 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+)
+
+// GetAutoDeployHash - returns the hash of the NodeSet configuration deployed
+// by the AutoDeploy policy, and whether it differs from the deployed one
+func GetAutoDeployHash(
+	ctx context.Context,
+	helper *helper.Helper,
+	instance *dataplanev1.OpenStackDataPlaneNodeSet,
+) (string, bool, error) {
+	drift := instance.Status.ConfigHash != instance.Status.DeployedConfigHash
+
+	configMapHashes := make(map[string]string)
+	secretHashes := make(map[string]string)
+	if instance.Spec.AutoDeploy.Trigger == dataplanev1.AutoDeployTriggerSpecAndInputs {
+		for _, serviceName := range instance.Spec.Services {
+			err := GetDeploymentHashesForService(
+				ctx,
+				helper,
+				instance.Namespace,
+				serviceName,
+				configMapHashes,
+				secretHashes,
+				dataplanev1.OpenStackDataPlaneNodeSetList{Items: []dataplanev1.OpenStackDataPlaneNodeSet{*instance}})
+			if err != nil {
+				return "", false, err
+			}
+		}
+		for name, hash := range configMapHashes {
+			drift = drift || instance.Status.ConfigMapHashes[name] != hash
+		}
+		for name, hash := range secretHashes {
+			drift = drift || instance.Status.SecretHashes[name] != hash
+		}
+	}
+
+	hash, err := util.ObjectHash(struct {
+		ConfigHash      string
+		ConfigMapHashes map[string]string
+		SecretHashes    map[string]string
+	}{
+		ConfigHash:      instance.Status.ConfigHash,
+		ConfigMapHashes: configMapHashes,
+		SecretHashes:    secretHashes,
+	})
+	return hash, drift, err
+}
+
+// GetAutoDeployDeploymentName - returns the name of the given run of the
+// OpenStackDataPlaneDeployment created to deploy the configuration hash
+func GetAutoDeployDeploymentName(instance *dataplanev1.OpenStackDataPlaneNodeSet, hash string, run int) string {
+	autoHash := fmt.Sprintf("-auto-%s", hash)
+	if len(autoHash) > 16 {
+		autoHash = autoHash[:16]
+	}
+	suffix := fmt.Sprintf("%s-%d", autoHash, run)
+	name := instance.Name
+	// The Deployment name is used in labels
+	if len(name)+len(suffix) > 63 {
+		name = name[:63-len(suffix)]
+	}
+	return name + suffix
+}
+
+// getAutoDeployRun returns the run of a Deployment created by the AutoDeploy
+// policy, from the end of its name
+func getAutoDeployRun(deployment *dataplanev1.OpenStackDataPlaneDeployment) int {
+	run, err := strconv.Atoi(deployment.Name[strings.LastIndex(deployment.Name, "-")+1:])
+	if err != nil {
+		return 0
+	}
+	return run
+}
+
+// ReconcileAutoDeploy - creates an OpenStackDataPlaneDeployment for the
+// NodeSet once its configuration differs from the deployed one and did not
+// change for the debounce time. No Deployment is created while another one of
+// the NodeSet is running.
+func ReconcileAutoDeploy(
+	ctx context.Context,
+	helper *helper.Helper,
+	instance *dataplanev1.OpenStackDataPlaneNodeSet,
+) (ctrl.Result, error) {
+	log := helper.GetLogger()
+	if instance.Spec.AutoDeploy == nil {
+		instance.Status.AutoDeploy = nil
+		return ctrl.Result{}, nil
+	}
+	if instance.Status.AutoDeploy == nil {
+		instance.Status.AutoDeploy = &dataplanev1.AutoDeployStatus{}
+	}
+	status := instance.Status.AutoDeploy
+
+	hash, drift, err := GetAutoDeployHash(ctx, helper, instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !drift {
+		status.PendingHash = ""
+		status.PendingSince = nil
+		return ctrl.Result{}, nil
+	}
+
+	// Each change restarts the debounce time
+	if status.PendingHash != hash || status.PendingSince == nil {
+		now := metav1.Now()
+		status.PendingHash = hash
+		status.PendingSince = &now
+	}
+	debounce := time.Second * time.Duration(instance.Spec.AutoDeploy.DebounceSeconds)
+	if remaining := time.Until(status.PendingSince.Add(debounce)); remaining > 0 {
+		log.Info("Waiting for the NodeSet configuration to settle before deploying", "remaining", remaining)
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	deployments := &dataplanev1.OpenStackDataPlaneDeploymentList{}
+	err = helper.GetClient().List(ctx, deployments, client.InNamespace(instance.Namespace))
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	// The last Deployment created for the same configuration
+	var last *dataplanev1.OpenStackDataPlaneDeployment
+	for idx := range deployments.Items {
+		deployment := &deployments.Items[idx]
+		if !slices.Contains(deployment.Spec.NodeSets, instance.Name) {
+			continue
+		}
+		if deployment.DeletionTimestamp.IsZero() &&
+			!deployment.IsCheckMode() &&
+			GetDeploymentOutcome(deployment) == dataplanev1.DeploymentOutcomeRunning {
+			log.Info("Waiting for running Deployment before deploying the NodeSet configuration", "deployment", deployment.Name)
+			return ctrl.Result{}, nil
+		}
+		if deployment.Labels["openstackdataplanenodeset"] == instance.Name &&
+			deployment.Annotations[dataplanev1.AutoDeployHashAnnotation] == hash &&
+			(last == nil || getAutoDeployRun(deployment) > getAutoDeployRun(last)) {
+			last = deployment
+		}
+	}
+
+	// A failed or cancelled Deployment is not created again for the same
+	// configuration, unless it is rerun or deleted. A configuration which
+	// was deployed before is deployed again by a new run.
+	run := 1
+	if last != nil {
+		if GetDeploymentOutcome(last) != dataplanev1.DeploymentOutcomeSucceeded {
+			log.Info("Not deploying the NodeSet configuration again after a failed Deployment", "deployment", last.Name)
+			status.LastDeployment = last.Name
+			return ctrl.Result{}, nil
+		}
+		run = getAutoDeployRun(last) + 1
+	}
+	deployment := &dataplanev1.OpenStackDataPlaneDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetAutoDeployDeploymentName(instance, hash, run),
+			Namespace: instance.Namespace,
+			Labels: map[string]string{
+				"openstackdataplanenodeset": instance.Name,
+			},
+			Annotations: map[string]string{
+				dataplanev1.AutoDeployHashAnnotation: hash,
+			},
+		},
+		Spec: dataplanev1.OpenStackDataPlaneDeploymentSpec{
+			NodeSets:              []string{instance.Name},
+			DeploymentRequeueTime: 15,
+		},
+	}
+	// The run already exists when the Deployment just created is not listed
+	// yet
+	err = helper.GetClient().Create(ctx, deployment)
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return ctrl.Result{}, err
+	}
+	if err == nil {
+		log.Info("Created Deployment for the NodeSet configuration", "deployment", deployment.Name)
+	}
+	status.LastDeployment = deployment.Name
+
+	return ctrl.Result{}, nil
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+)
+
+func newAutoDeployNodeSet(configHash string, debounceSeconds int) *dataplanev1.OpenStackDataPlaneNodeSet {
+	return &dataplanev1.OpenStackDataPlaneNodeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "edpm-compute", Namespace: "openstack"},
+		Spec: dataplanev1.OpenStackDataPlaneNodeSetSpec{
+			AutoDeploy: &dataplanev1.AutoDeployPolicy{
+				Trigger:         dataplanev1.AutoDeployTriggerSpec,
+				DebounceSeconds: debounceSeconds,
+			},
+		},
+		Status: dataplanev1.OpenStackDataPlaneNodeSetStatus{
+			ConfigHash:         configHash,
+			DeployedConfigHash: "deployed-hash",
+		},
+	}
+}
+
+// newAutoDeployment returns the given run of the Deployment created by the
+// AutoDeploy policy for the configuration of the NodeSet
+func newAutoDeployment(
+	t *testing.T,
+	nodeSet *dataplanev1.OpenStackDataPlaneNodeSet,
+	run int,
+	outcome string,
+) *dataplanev1.OpenStackDataPlaneDeployment {
+	hash, _, err := GetAutoDeployHash(context.Background(), newTestHelper(t, nodeSet), nodeSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deployment := &dataplanev1.OpenStackDataPlaneDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        GetAutoDeployDeploymentName(nodeSet, hash, run),
+			Namespace:   nodeSet.Namespace,
+			Labels:      map[string]string{"openstackdataplanenodeset": nodeSet.Name},
+			Annotations: map[string]string{dataplanev1.AutoDeployHashAnnotation: hash},
+		},
+		Spec: dataplanev1.OpenStackDataPlaneDeploymentSpec{NodeSets: []string{nodeSet.Name}},
+	}
+	switch outcome {
+	case dataplanev1.DeploymentOutcomeSucceeded:
+		deployment.Status.Deployed = true
+	case dataplanev1.DeploymentOutcomeFailed:
+		deployment.Status.Conditions.Set(condition.FalseCondition(
+			condition.ReadyCondition, condition.ErrorReason, condition.SeverityError, "failed"))
+	case dataplanev1.DeploymentOutcomeCancelled:
+		deployment.Status.Cancelled = true
+	}
+	return deployment
+}
+
+func TestReconcileAutoDeploy(t *testing.T) {
+	nodeSet := newAutoDeployNodeSet("config-a", 0)
+	otherNodeSet := newAutoDeployNodeSet("config-b", 0)
+
+	tests := []struct {
+		name     string
+		existing []client.Object
+		want     string
+		created  bool
+	}{
+		{
+			name:    "first Deployment",
+			want:    newAutoDeployment(t, nodeSet, 1, "").Name,
+			created: true,
+		},
+		{
+			// The configuration was deployed, then another one, and the
+			// NodeSet was reverted to the first one
+			name: "configuration deployed before",
+			existing: []client.Object{
+				newAutoDeployment(t, nodeSet, 1, dataplanev1.DeploymentOutcomeSucceeded),
+				newAutoDeployment(t, otherNodeSet, 1, dataplanev1.DeploymentOutcomeSucceeded),
+			},
+			want:    newAutoDeployment(t, nodeSet, 2, "").Name,
+			created: true,
+		},
+		{
+			name: "Deployment deleted by the retention",
+			existing: []client.Object{
+				newAutoDeployment(t, nodeSet, 2, dataplanev1.DeploymentOutcomeSucceeded),
+			},
+			want:    newAutoDeployment(t, nodeSet, 3, "").Name,
+			created: true,
+		},
+		{
+			name: "failed Deployment",
+			existing: []client.Object{
+				newAutoDeployment(t, nodeSet, 1, dataplanev1.DeploymentOutcomeSucceeded),
+				newAutoDeployment(t, nodeSet, 2, dataplanev1.DeploymentOutcomeFailed),
+			},
+			want: newAutoDeployment(t, nodeSet, 2, "").Name,
+		},
+		{
+			name: "cancelled Deployment",
+			existing: []client.Object{
+				newAutoDeployment(t, nodeSet, 1, dataplanev1.DeploymentOutcomeCancelled),
+			},
+			want: newAutoDeployment(t, nodeSet, 1, "").Name,
+		},
+		{
+			name: "running Deployment",
+			existing: []client.Object{
+				newAutoDeployment(t, otherNodeSet, 1, dataplanev1.DeploymentOutcomeRunning),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := nodeSet.DeepCopy()
+			h := newTestHelper(t, instance, tt.existing...)
+			if _, err := ReconcileAutoDeploy(context.Background(), h, instance); err != nil {
+				t.Fatal(err)
+			}
+			if got := instance.Status.AutoDeploy.LastDeployment; got != tt.want {
+				t.Errorf("got last Deployment %q, want %q", got, tt.want)
+			}
+			deployments := &dataplanev1.OpenStackDataPlaneDeploymentList{}
+			if err := h.GetClient().List(context.Background(), deployments); err != nil {
+				t.Fatal(err)
+			}
+			if created := len(deployments.Items) > len(tt.existing); created != tt.created {
+				t.Errorf("got Deployment created %t, want %t", created, tt.created)
+			}
+		})
+	}
+}
+
+func TestReconcileAutoDeployDebounce(t *testing.T) {
+	instance := newAutoDeployNodeSet("config-a", 60)
+	h := newTestHelper(t, instance)
+	deploymentCount := func() int {
+		deployments := &dataplanev1.OpenStackDataPlaneDeploymentList{}
+		if err := h.GetClient().List(context.Background(), deployments); err != nil {
+			t.Fatal(err)
+		}
+		return len(deployments.Items)
+	}
+
+	result, err := ReconcileAutoDeploy(context.Background(), h, instance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.RequeueAfter <= 0 || result.RequeueAfter > time.Minute || deploymentCount() != 0 {
+		t.Fatalf("got result %v with %d Deployments, want to wait for the debounce time", result, deploymentCount())
+	}
+
+	// A change restarts the debounce time
+	pendingSince := metav1.NewTime(time.Now().Add(-50 * time.Second))
+	instance.Status.AutoDeploy.PendingSince = &pendingSince
+	instance.Status.ConfigHash = "config-b"
+	result, err = ReconcileAutoDeploy(context.Background(), h, instance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.RequeueAfter <= 50*time.Second || deploymentCount() != 0 {
+		t.Fatalf("got result %v with %d Deployments, want the debounce time restarted", result, deploymentCount())
+	}
+	if instance.Status.AutoDeploy.PendingSince.Time.Before(pendingSince.Time) ||
+		instance.Status.AutoDeploy.PendingSince.Equal(&pendingSince) {
+		t.Errorf("got pending since %s, want the time of the change", instance.Status.AutoDeploy.PendingSince)
+	}
+
+	// Once the configuration settled, it is deployed
+	pendingSince = metav1.NewTime(time.Now().Add(-2 * time.Minute))
+	instance.Status.AutoDeploy.PendingSince = &pendingSince
+	result, err = ReconcileAutoDeploy(context.Background(), h, instance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.RequeueAfter != 0 || deploymentCount() != 1 || instance.Status.AutoDeploy.LastDeployment == "" {
+		t.Errorf("got result %v with %d Deployments, want a Deployment created", result, deploymentCount())
+	}
+
+	// Once deployed, the pending configuration is cleared
+	instance.Status.DeployedConfigHash = instance.Status.ConfigHash
+	if _, err := ReconcileAutoDeploy(context.Background(), h, instance); err != nil {
+		t.Fatal(err)
+	}
+	if instance.Status.AutoDeploy.PendingHash != "" || instance.Status.AutoDeploy.PendingSince != nil {
+		t.Errorf("got pending configuration %+v, want none", instance.Status.AutoDeploy)
+	}
+}
@@ -92,6 +92,7 @@ func DeployBaremetalSet(
 			dataplanev1.NodeSetBaremetalProvisionErrorMessage)
 		return false, err
 	}
+	UpdateNodeProvisioningStates(instance, baremetalSet)
 
 	// Check if baremetalSet is ready
 	if !baremetalSet.IsReady() {
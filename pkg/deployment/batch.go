@@ -0,0 +1,364 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	dataplaneutil "github.com/openstack-k8s-operators/dataplane-operator/pkg/util"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	"github.com/openstack-k8s-operators/lib-common/modules/storage"
+	ansibleeev1 "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
+)
+
+// GetNodeSetBatches - splits the ansible hosts of the NodeSet into ordered
+// batches according to the canary and rollout strategy. When the NodeSet has
+// canary hosts, they are the first batch. Returns nil when the NodeSet should
+// be deployed in a single run.
+func GetNodeSetBatches(
+	nodeSet *dataplanev1.OpenStackDataPlaneNodeSet,
+	strategy *dataplanev1.RolloutStrategy,
+	canary *dataplanev1.Canary,
+) ([][]string, bool, error) {
+	if (strategy == nil && canary == nil) || len(nodeSet.Spec.Nodes) == 0 {
+		return nil, false, nil
+	}
+
+	// Use the same host names as the inventory of the NodeSet
+	hosts := make([]string, 0, len(nodeSet.Spec.Nodes))
+	for _, node := range nodeSet.Spec.Nodes {
+		hosts = append(hosts, strings.Split(node.HostName, ".")[0])
+	}
+	sort.Strings(hosts)
+
+	batches := [][]string{}
+	canaryHosts, err := getNodeSetCanaryHosts(nodeSet.Name, hosts, canary)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(canaryHosts) > 0 {
+		batches = append(batches, canaryHosts)
+		remaining := []string{}
+		for _, host := range hosts {
+			if !slices.Contains(canaryHosts, host) {
+				remaining = append(remaining, host)
+			}
+		}
+		hosts = remaining
+	}
+	if len(hosts) == 0 {
+		return batches, len(canaryHosts) > 0, nil
+	}
+	if strategy == nil {
+		if len(canaryHosts) == 0 {
+			return nil, false, nil
+		}
+		return append(batches, hosts), true, nil
+	}
+
+	batchSize, err := intstr.GetScaledValueFromIntOrPercent(&strategy.BatchSize, len(hosts), true)
+	if err != nil {
+		return nil, false, err
+	}
+	if batchSize < 1 {
+		return nil, false, fmt.Errorf("invalid batchSize %s", strategy.BatchSize.String())
+	}
+
+	for start := 0; start < len(hosts); start += batchSize {
+		end := start + batchSize
+		if end > len(hosts) {
+			end = len(hosts)
+		}
+		batches = append(batches, hosts[start:end])
+	}
+
+	return batches, len(canaryHosts) > 0, nil
+}
+
+// getNodeSetCanaryHosts returns the canary hosts out of the sorted hosts of
+// the NodeSet, nil when the NodeSet has no canary hosts
+func getNodeSetCanaryHosts(nodeSetName string, hosts []string, canary *dataplanev1.Canary) ([]string, error) {
+	if canary == nil {
+		return nil, nil
+	}
+
+	if canaryHosts, ok := canary.Hosts[nodeSetName]; ok {
+		result := []string{}
+		for _, host := range canaryHosts {
+			host = strings.Split(host, ".")[0]
+			if !slices.Contains(hosts, host) {
+				return nil, fmt.Errorf("canary host %s is not a node of NodeSet %s", host, nodeSetName)
+			}
+			if !slices.Contains(result, host) {
+				result = append(result, host)
+			}
+		}
+		sort.Strings(result)
+		return result, nil
+	}
+
+	if canary.Count <= 0 {
+		return nil, nil
+	}
+	if canary.Count >= len(hosts) {
+		return hosts, nil
+	}
+	return hosts[:canary.Count], nil
+}
+
+// GetBatchReadyCondition returns the NodeSet condition type tracking the
+// deployment of the given batch
+func GetBatchReadyCondition(batch int) condition.Type {
+	return condition.Type(fmt.Sprintf("Batch%dDeploymentReady", batch))
+}
+
+// deployBatches deploys the services on each batch of nodes of the NodeSet,
+// waiting for a batch to succeed and for the configured pause before moving
+// on to the next one. Batches already deployed are skipped.
+// When the first batch holds the canary hosts, the following batches are
+// only deployed once the canary is approved.
+func (d *Deployer) deployBatches(services []string, batches [][]string, canary bool) (*ctrl.Result, error) {
+	log := d.Helper.GetLogger()
+	strategy := d.Deployment.Spec.RolloutStrategy
+
+	nsConditions := d.Status.NodeSetConditions[d.NodeSet.Name]
+	if canary {
+		nsConditions.Set(condition.UnknownCondition(
+			dataplanev1.NodeSetCanaryDeploymentReadyCondition,
+			condition.InitReason,
+			condition.InitReason))
+	}
+	for idx := range batches {
+		batch := idx + 1
+		nsConditions.Set(condition.UnknownCondition(
+			GetBatchReadyCondition(batch),
+			condition.InitReason,
+			dataplanev1.NodeSetBatchDeploymentInitMessage, batch))
+	}
+	d.Status.NodeSetConditions[d.NodeSet.Name] = nsConditions
+
+	// Save a copy of the original ExtraMounts so it can be reset for each batch
+	aeeSpecMounts := make([]storage.VolMounts, len(d.AeeSpec.ExtraMounts))
+	copy(aeeSpecMounts, d.AeeSpec.ExtraMounts)
+
+	for idx, hosts := range batches {
+		batch := idx + 1
+		batchCondition := GetBatchReadyCondition(batch)
+
+		if d.batchDeployed(services, batch) {
+			log.Info("Batch already deployed", "batch", batch)
+			d.setServiceConditionsReady(services)
+			d.setBatchCondition(condition.TrueCondition(
+				batchCondition,
+				dataplanev1.NodeSetBatchDeploymentReadyMessage, batch))
+			if canary && idx == 0 {
+				d.setBatchCondition(condition.TrueCondition(
+					dataplanev1.NodeSetCanaryDeploymentReadyCondition,
+					dataplanev1.NodeSetCanaryDeploymentReadyMessage, strings.Join(hosts, ",")))
+			}
+			continue
+		}
+
+		if canary && idx == 1 && !d.Deployment.IsCanaryApproved() && !d.batchStarted(batch) {
+			log.Info("Waiting for the canary approval before deploying batch", "batch", batch)
+			d.setBatchCondition(condition.FalseCondition(
+				batchCondition,
+				condition.RequestedReason,
+				condition.SeverityInfo,
+				dataplanev1.NodeSetBatchDeploymentWaitingForApprovalMessage, batch))
+			return &ctrl.Result{}, nil
+		}
+
+		if idx > 0 && strategy != nil && strategy.PauseSeconds > 0 {
+			completionTime, err := d.batchCompletionTime(batch - 1)
+			if err != nil {
+				d.setBatchCondition(condition.FalseCondition(
+					batchCondition,
+					condition.ErrorReason,
+					condition.SeverityError,
+					dataplanev1.NodeSetBatchDeploymentErrorMessage, batch, err.Error()))
+				return &ctrl.Result{}, err
+			}
+			resumeTime := completionTime.Add(time.Second * time.Duration(strategy.PauseSeconds))
+			if remaining := time.Until(resumeTime); remaining > 0 && !d.batchStarted(batch) {
+				log.Info("Pausing before deploying batch", "batch", batch, "until", resumeTime)
+				d.setBatchCondition(condition.FalseCondition(
+					batchCondition,
+					condition.RequestedReason,
+					condition.SeverityInfo,
+					dataplanev1.NodeSetBatchDeploymentPausedMessage, batch, resumeTime.Format(time.RFC3339)))
+				return &ctrl.Result{RequeueAfter: remaining}, nil
+			}
+		}
+
+		log.Info("Deploying batch", "batch", batch, "hosts", hosts)
+		d.Batch = batch
+		d.AeeSpec.AnsibleLimit = strings.Join(hosts, ",")
+		d.AeeSpec.ExtraMounts = make([]storage.VolMounts, len(aeeSpecMounts))
+		copy(d.AeeSpec.ExtraMounts, aeeSpecMounts)
+		// Service conditions are tracked for the batch being deployed
+		d.resetServiceConditions(services)
+
+		canaryHosts := strings.Join(hosts, ",")
+		result, err := d.deployServices(services)
+		if err != nil {
+			d.setBatchCondition(condition.FalseCondition(
+				batchCondition,
+				condition.ErrorReason,
+				condition.SeverityError,
+				dataplanev1.NodeSetBatchDeploymentErrorMessage, batch, err.Error()))
+			if canary && idx == 0 {
+				d.setBatchCondition(condition.FalseCondition(
+					dataplanev1.NodeSetCanaryDeploymentReadyCondition,
+					condition.ErrorReason,
+					condition.SeverityError,
+					dataplanev1.NodeSetCanaryDeploymentErrorMessage, canaryHosts, err.Error()))
+			}
+			return result, err
+		}
+		if result != nil {
+			d.setBatchCondition(condition.FalseCondition(
+				batchCondition,
+				condition.RequestedReason,
+				condition.SeverityInfo,
+				dataplanev1.NodeSetBatchDeploymentReadyWaitingMessage, batch))
+			if canary && idx == 0 {
+				d.setBatchCondition(condition.FalseCondition(
+					dataplanev1.NodeSetCanaryDeploymentReadyCondition,
+					condition.RequestedReason,
+					condition.SeverityInfo,
+					dataplanev1.NodeSetCanaryDeploymentReadyWaitingMessage, canaryHosts))
+			}
+			return result, nil
+		}
+
+		d.setBatchCondition(condition.TrueCondition(
+			batchCondition,
+			dataplanev1.NodeSetBatchDeploymentReadyMessage, batch))
+		if canary && idx == 0 {
+			d.setBatchCondition(condition.TrueCondition(
+				dataplanev1.NodeSetCanaryDeploymentReadyCondition,
+				dataplanev1.NodeSetCanaryDeploymentReadyMessage, canaryHosts))
+		}
+	}
+
+	return nil, nil
+}
+
+// setBatchCondition sets a batch condition on the NodeSet conditions
+func (d *Deployer) setBatchCondition(c *condition.Condition) {
+	nsConditions := d.Status.NodeSetConditions[d.NodeSet.Name]
+	nsConditions.Set(c)
+	d.Status.NodeSetConditions[d.NodeSet.Name] = nsConditions
+}
+
+// resetServiceConditions removes the service conditions of the NodeSet so
+// they can be computed again for the next batch
+func (d *Deployer) resetServiceConditions(services []string) {
+	nsConditions := d.Status.NodeSetConditions[d.NodeSet.Name]
+	for _, service := range services {
+		nsConditions.Remove(GetServiceReadyCondition(service))
+	}
+	d.Status.NodeSetConditions[d.NodeSet.Name] = nsConditions
+}
+
+// setServiceConditionsReady marks the services of the NodeSet as ready, as
+// they are when their last batch is deployed
+func (d *Deployer) setServiceConditionsReady(services []string) {
+	nsConditions := d.Status.NodeSetConditions[d.NodeSet.Name]
+	for _, service := range services {
+		nsConditions.Set(condition.TrueCondition(
+			GetServiceReadyCondition(service),
+			dataplanev1.NodeSetServiceDeploymentReadyMessage, service))
+	}
+	d.Status.NodeSetConditions[d.NodeSet.Name] = nsConditions
+}
+
+// batchDeployed returns true when the last attempt of each service on the
+// batch succeeded. The attempts are recorded once a reconcile sees their
+// executions finish, so the executions of a deployed batch are not checked
+// again.
+func (d *Deployer) batchDeployed(services []string, batch int) bool {
+	for _, service := range services {
+		var last *dataplanev1.AnsibleExecutionAttempt
+		attempts := d.Status.NodeSetAttempts[d.NodeSet.Name][service]
+		for idx := range attempts {
+			if attempts[idx].Batch == batch && (last == nil || attempts[idx].Attempt > last.Attempt) {
+				last = &attempts[idx]
+			}
+		}
+		if last == nil || last.JobStatus != ansibleeev1.JobStatusSucceeded {
+			return false
+		}
+	}
+	return true
+}
+
+// listBatchExecutions returns the OpenStackAnsibleEEs created for a batch
+func (d *Deployer) listBatchExecutions(batch int) ([]ansibleeev1.OpenStackAnsibleEE, error) {
+	labels := dataplaneutil.GetDeploymentExecutionLabels(d.Deployment)
+	labels["openstackdataplanenodeset"] = d.NodeSet.Name
+	labels["openstackdataplanebatch"] = strconv.Itoa(batch)
+	ansibleEEs := &ansibleeev1.OpenStackAnsibleEEList{}
+	err := d.Helper.GetClient().List(d.Ctx, ansibleEEs,
+		client.InNamespace(d.Deployment.Namespace),
+		client.MatchingLabels(labels))
+	if err != nil {
+		return nil, err
+	}
+	return ansibleEEs.Items, nil
+}
+
+// batchStarted returns true when executions already exist for the batch,
+// so the pause is not applied again once the batch is running
+func (d *Deployer) batchStarted(batch int) bool {
+	ansibleEEs, err := d.listBatchExecutions(batch)
+	return err == nil && len(ansibleEEs) > 0
+}
+
+// batchCompletionTime returns the time the last OpenStackAnsibleEE of the
+// batch became ready
+func (d *Deployer) batchCompletionTime(batch int) (time.Time, error) {
+	ansibleEEs, err := d.listBatchExecutions(batch)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var completionTime time.Time
+	for _, ansibleEE := range ansibleEEs {
+		readyCondition := ansibleEE.Status.Conditions.Get(condition.ReadyCondition)
+		if readyCondition == nil || readyCondition.Status != corev1.ConditionTrue {
+			continue
+		}
+		if readyCondition.LastTransitionTime.Time.After(completionTime) {
+			completionTime = readyCondition.LastTransitionTime.Time
+		}
+	}
+
+	return completionTime, nil
+}
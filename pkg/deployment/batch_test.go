@@ -0,0 +1,296 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	ansibleeev1 "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
+)
+
+// newBatchNodeSet returns the edpm-compute NodeSet with the given number of
+// nodes
+func newBatchNodeSet(nodes int) *dataplanev1.OpenStackDataPlaneNodeSet {
+	nodeSet := &dataplanev1.OpenStackDataPlaneNodeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "edpm-compute", Namespace: "openstack"},
+		Spec: dataplanev1.OpenStackDataPlaneNodeSetSpec{
+			Nodes: map[string]dataplanev1.NodeSection{},
+		},
+	}
+	for idx := 0; idx < nodes; idx++ {
+		nodeSet.Spec.Nodes[fmt.Sprintf("edpm-compute-%d", idx)] = dataplanev1.NodeSection{
+			HostName: fmt.Sprintf("edpm-compute-%d.example.com", idx),
+		}
+	}
+	return nodeSet
+}
+
+func TestGetNodeSetBatches(t *testing.T) {
+	tests := []struct {
+		name       string
+		nodes      int
+		strategy   *dataplanev1.RolloutStrategy
+		canary     *dataplanev1.Canary
+		want       [][]string
+		wantCanary bool
+		wantErr    string
+	}{
+		{
+			name:     "no strategy",
+			nodes:    3,
+			strategy: nil,
+			want:     nil,
+		},
+		{
+			name:     "no nodes",
+			nodes:    0,
+			strategy: &dataplanev1.RolloutStrategy{BatchSize: intstr.FromInt(1)},
+			want:     nil,
+		},
+		{
+			name:     "batch size",
+			nodes:    3,
+			strategy: &dataplanev1.RolloutStrategy{BatchSize: intstr.FromInt(2)},
+			want:     [][]string{{"edpm-compute-0", "edpm-compute-1"}, {"edpm-compute-2"}},
+		},
+		{
+			name:     "batch size larger than the NodeSet",
+			nodes:    3,
+			strategy: &dataplanev1.RolloutStrategy{BatchSize: intstr.FromInt(10)},
+			want:     [][]string{{"edpm-compute-0", "edpm-compute-1", "edpm-compute-2"}},
+		},
+		{
+			name:     "percentage rounded up",
+			nodes:    3,
+			strategy: &dataplanev1.RolloutStrategy{BatchSize: intstr.FromString("50%")},
+			want:     [][]string{{"edpm-compute-0", "edpm-compute-1"}, {"edpm-compute-2"}},
+		},
+		{
+			name:     "small percentage",
+			nodes:    3,
+			strategy: &dataplanev1.RolloutStrategy{BatchSize: intstr.FromString("1%")},
+			want:     [][]string{{"edpm-compute-0"}, {"edpm-compute-1"}, {"edpm-compute-2"}},
+		},
+		{
+			name:       "canary count",
+			nodes:      3,
+			canary:     &dataplanev1.Canary{Count: 1},
+			want:       [][]string{{"edpm-compute-0"}, {"edpm-compute-1", "edpm-compute-2"}},
+			wantCanary: true,
+		},
+		{
+			name:       "canary hosts and batch size",
+			nodes:      3,
+			strategy:   &dataplanev1.RolloutStrategy{BatchSize: intstr.FromInt(1)},
+			canary:     &dataplanev1.Canary{Hosts: map[string][]string{"edpm-compute": {"edpm-compute-2.example.com"}}},
+			want:       [][]string{{"edpm-compute-2"}, {"edpm-compute-0"}, {"edpm-compute-1"}},
+			wantCanary: true,
+		},
+		{
+			name:       "canary larger than the NodeSet",
+			nodes:      2,
+			canary:     &dataplanev1.Canary{Count: 5},
+			want:       [][]string{{"edpm-compute-0", "edpm-compute-1"}},
+			wantCanary: true,
+		},
+		{
+			name:    "unknown canary host",
+			nodes:   2,
+			canary:  &dataplanev1.Canary{Hosts: map[string][]string{"edpm-compute": {"edpm-compute-9"}}},
+			wantErr: "canary host edpm-compute-9 is not a node of NodeSet edpm-compute",
+		},
+		{
+			name:     "invalid batch size",
+			nodes:    3,
+			strategy: &dataplanev1.RolloutStrategy{BatchSize: intstr.FromInt(0)},
+			wantErr:  "invalid batchSize 0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotCanary, err := GetNodeSetBatches(newBatchNodeSet(tt.nodes), tt.strategy, tt.canary)
+			if len(tt.wantErr) > 0 {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("GetNodeSetBatches() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) || gotCanary != tt.wantCanary {
+				t.Errorf("GetNodeSetBatches() = %v, %t, want %v, %t", got, gotCanary, tt.want, tt.wantCanary)
+			}
+		})
+	}
+}
+
+func TestDeployBatchesSkipsDeployedBatches(t *testing.T) {
+	nodeSet := newBatchNodeSet(2)
+	deployment := &dataplanev1.OpenStackDataPlaneDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "edpm-deployment", Namespace: "openstack"},
+		Spec: dataplanev1.OpenStackDataPlaneDeploymentSpec{
+			NodeSets:        []string{"edpm-compute"},
+			RolloutStrategy: &dataplanev1.RolloutStrategy{BatchSize: intstr.FromInt(1), PauseSeconds: 600},
+		},
+	}
+	// install-os succeeded on the first batch after a failed attempt, and
+	// configure-network at once
+	deployment.Status.NodeSetAttempts = map[string]map[string][]dataplanev1.AnsibleExecutionAttempt{
+		"edpm-compute": {
+			"configure-network": {{Attempt: 1, Batch: 1, JobStatus: ansibleeev1.JobStatusSucceeded}},
+			"install-os": {
+				{Attempt: 1, Batch: 1, JobStatus: ansibleeev1.JobStatusFailed},
+				{Attempt: 2, Batch: 1, JobStatus: ansibleeev1.JobStatusSucceeded},
+			},
+		},
+	}
+	// The last execution of the first batch finished a minute ago, the
+	// second batch waits for the pause
+	completion := metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+	ansibleEE := &ansibleeev1.OpenStackAnsibleEE{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "install-os-edpm-deployment-edpm-compute-batch-1",
+			Namespace: "openstack",
+			Labels: map[string]string{
+				"openstackdataplanedeployment": "edpm-deployment",
+				"openstackdataplanenodeset":    "edpm-compute",
+				"openstackdataplanebatch":      "1",
+			},
+		},
+		Status: ansibleeev1.OpenStackAnsibleEEStatus{
+			JobStatus: ansibleeev1.JobStatusSucceeded,
+			Conditions: condition.Conditions{{
+				Type:               condition.ReadyCondition,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: completion,
+			}},
+		},
+	}
+	d := &Deployer{
+		Ctx:        context.Background(),
+		Helper:     newTestHelper(t, deployment, nodeSet, ansibleEE),
+		NodeSet:    nodeSet,
+		Deployment: deployment,
+		Status:     &deployment.Status,
+		AeeSpec:    &dataplanev1.AnsibleEESpec{},
+	}
+	d.Status.NodeSetConditions = map[string]condition.Conditions{"edpm-compute": {}}
+	batches, canary, err := GetNodeSetBatches(nodeSet, deployment.Spec.RolloutStrategy, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remaining := time.Until(completion.Add(10 * time.Minute))
+	result, err := d.deployBatches([]string{"configure-network", "install-os"}, batches, canary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result == nil || result.RequeueAfter <= 0 || result.RequeueAfter > remaining {
+		t.Errorf("got result %v, want to requeue within %s", result, remaining)
+	}
+	if d.Batch != 0 {
+		t.Errorf("batch %d deployed, want none", d.Batch)
+	}
+	nsConditions := d.Status.NodeSetConditions["edpm-compute"]
+	for _, conditionType := range []condition.Type{
+		GetBatchReadyCondition(1),
+		GetServiceReadyCondition("configure-network"),
+		GetServiceReadyCondition("install-os"),
+	} {
+		if !nsConditions.IsTrue(conditionType) {
+			t.Errorf("condition %s is not True", conditionType)
+		}
+	}
+	paused := nsConditions.Get(GetBatchReadyCondition(2))
+	if paused == nil || paused.Status != corev1.ConditionFalse || paused.Reason != condition.RequestedReason {
+		t.Errorf("got batch 2 condition %v, want paused", paused)
+	}
+
+	// Once all batches are deployed, nothing is deployed again
+	for _, service := range []string{"configure-network", "install-os"} {
+		attempts := d.Status.NodeSetAttempts["edpm-compute"][service]
+		d.Status.NodeSetAttempts["edpm-compute"][service] = append(attempts,
+			dataplanev1.AnsibleExecutionAttempt{Attempt: 1, Batch: 2, JobStatus: ansibleeev1.JobStatusSucceeded})
+	}
+	result, err = d.deployBatches([]string{"configure-network", "install-os"}, batches, canary)
+	if err != nil || result != nil {
+		t.Fatalf("got result %v and error %v, want the NodeSet deployed", result, err)
+	}
+	nsConditions = d.Status.NodeSetConditions["edpm-compute"]
+	if !nsConditions.IsTrue(GetBatchReadyCondition(2)) || d.Batch != 0 {
+		t.Errorf("batch 2 is not ready or was deployed again")
+	}
+}
+
+func TestDeployBatchesCanary(t *testing.T) {
+	nodeSet := newBatchNodeSet(3)
+	deployment := &dataplanev1.OpenStackDataPlaneDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "edpm-deployment", Namespace: "openstack"},
+		Spec: dataplanev1.OpenStackDataPlaneDeploymentSpec{
+			NodeSets: []string{"edpm-compute"},
+			Canary:   &dataplanev1.Canary{Count: 1},
+		},
+	}
+	// The canary batch was deployed by a previous reconcile
+	deployment.Status.NodeSetAttempts = map[string]map[string][]dataplanev1.AnsibleExecutionAttempt{
+		"edpm-compute": {
+			"install-os": {{Attempt: 1, Batch: 1, JobStatus: ansibleeev1.JobStatusSucceeded}},
+		},
+	}
+	d := &Deployer{
+		Ctx:        context.Background(),
+		Helper:     newTestHelper(t, deployment, nodeSet),
+		NodeSet:    nodeSet,
+		Deployment: deployment,
+		Status:     &deployment.Status,
+		AeeSpec:    &dataplanev1.AnsibleEESpec{},
+	}
+	d.Status.NodeSetConditions = map[string]condition.Conditions{"edpm-compute": {}}
+	batches, canary, err := GetNodeSetBatches(nodeSet, nil, deployment.Spec.Canary)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := d.deployBatches([]string{"install-os"}, batches, canary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result == nil || d.Batch != 0 {
+		t.Fatalf("got result %v after deploying batch %d, want to wait without deploying", result, d.Batch)
+	}
+	nsConditions := d.Status.NodeSetConditions["edpm-compute"]
+	canaryReady := nsConditions.Get(dataplanev1.NodeSetCanaryDeploymentReadyCondition)
+	if canaryReady == nil || canaryReady.Status != corev1.ConditionTrue ||
+		canaryReady.Message != fmt.Sprintf(dataplanev1.NodeSetCanaryDeploymentReadyMessage, "edpm-compute-0") {
+		t.Errorf("got canary condition %v, want ready on edpm-compute-0", canaryReady)
+	}
+	waiting := nsConditions.Get(GetBatchReadyCondition(2))
+	if waiting == nil || waiting.Message != fmt.Sprintf(dataplanev1.NodeSetBatchDeploymentWaitingForApprovalMessage, 2) {
+		t.Errorf("got batch 2 condition %v, want waiting for the canary approval", waiting)
+	}
+}
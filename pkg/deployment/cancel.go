@@ -0,0 +1,156 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	dataplaneutil "github.com/openstack-k8s-operators/dataplane-operator/pkg/util"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	ansibleeev1 "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
+)
+
+// CancelDeployment - stops the running ansible executions of the Deployment
+// and marks every service which did not succeed as Cancelled. nodeSetServices
+// holds the services deployed on each NodeSet, a NodeSet without services is
+// only marked as Cancelled. nodeSetBatches holds the number of batches each
+// NodeSet is deployed in, a service only succeeded once it succeeded on every
+// batch.
+func CancelDeployment(
+	ctx context.Context,
+	helper *helper.Helper,
+	instance *dataplanev1.OpenStackDataPlaneDeployment,
+	nodeSetServices map[string][]string,
+	nodeSetBatches map[string]int,
+) error {
+	log := helper.GetLogger()
+
+	ansibleEEs := &ansibleeev1.OpenStackAnsibleEEList{}
+	err := helper.GetClient().List(ctx, ansibleEEs,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels(dataplaneutil.GetDeploymentExecutionLabels(instance)))
+	if err != nil {
+		return err
+	}
+
+	// Latest execution of each service and batch by NodeSet
+	latest := map[string]map[string]*ansibleeev1.OpenStackAnsibleEE{}
+	for idx := range ansibleEEs.Items {
+		ansibleEE := &ansibleEEs.Items[idx]
+		if ansibleEE.Status.JobStatus != ansibleeev1.JobStatusSucceeded &&
+			ansibleEE.Status.JobStatus != ansibleeev1.JobStatusFailed {
+			log.Info("Stopping ansible execution of cancelled Deployment", "execution", ansibleEE.Name)
+			err = helper.GetClient().Delete(ctx, ansibleEE, client.PropagationPolicy("Background"))
+			if err != nil && !k8s_errors.IsNotFound(err) {
+				return err
+			}
+		}
+
+		nodeSet := ansibleEE.Labels["openstackdataplanenodeset"]
+		batch, _ := strconv.Atoi(ansibleEE.Labels["openstackdataplanebatch"])
+		key := getServiceBatchKey(ansibleEE.Labels["openstackdataplaneservice"], batch)
+		if latest[nodeSet] == nil {
+			latest[nodeSet] = map[string]*ansibleeev1.OpenStackAnsibleEE{}
+		}
+		if previous, ok := latest[nodeSet][key]; !ok || executionBefore(previous, ansibleEE) {
+			latest[nodeSet][key] = ansibleEE
+		}
+	}
+
+	for _, nodeSet := range instance.Spec.NodeSets {
+		nsConditions := instance.Status.NodeSetConditions[nodeSet]
+		services := nodeSetServices[nodeSet]
+		succeeded := len(services) > 0
+		// The whole NodeSet is deployed as batch 0
+		batches := []int{0}
+		if nodeSetBatches[nodeSet] > 0 {
+			batches = []int{}
+			for batch := 1; batch <= nodeSetBatches[nodeSet]; batch++ {
+				batches = append(batches, batch)
+			}
+		}
+		for _, service := range services {
+			readyCondition := GetServiceReadyCondition(service)
+			serviceSucceeded := true
+			for _, batch := range batches {
+				ansibleEE, ok := latest[nodeSet][getServiceBatchKey(service, batch)]
+				if !ok || ansibleEE.Status.JobStatus != ansibleeev1.JobStatusSucceeded {
+					serviceSucceeded = false
+					break
+				}
+			}
+			if serviceSucceeded {
+				nsConditions.Set(condition.TrueCondition(
+					readyCondition,
+					dataplanev1.NodeSetServiceDeploymentReadyMessage,
+					service))
+				continue
+			}
+			succeeded = false
+			nsConditions.Set(condition.FalseCondition(
+				readyCondition,
+				dataplanev1.CancelledReason,
+				condition.SeverityWarning,
+				dataplanev1.NodeSetServiceDeploymentCancelledMessage,
+				service))
+		}
+		if succeeded {
+			nsConditions.MarkTrue(
+				dataplanev1.NodeSetDeploymentReadyCondition,
+				condition.DeploymentReadyMessage)
+		} else {
+			nsConditions.MarkFalse(
+				dataplanev1.NodeSetDeploymentReadyCondition,
+				dataplanev1.CancelledReason,
+				condition.SeverityWarning,
+				dataplanev1.DeploymentCancelledMessage)
+		}
+		instance.Status.NodeSetConditions[nodeSet] = nsConditions
+	}
+
+	instance.Status.Conditions.MarkFalse(
+		condition.DeploymentReadyCondition,
+		dataplanev1.CancelledReason,
+		condition.SeverityWarning,
+		dataplanev1.DeploymentCancelledMessage)
+	instance.Status.Cancelled = true
+
+	return nil
+}
+
+// getServiceBatchKey returns the key of the executions of a service on a batch
+func getServiceBatchKey(service string, batch int) string {
+	return fmt.Sprintf("%s/%d", service, batch)
+}
+
+// executionBefore returns true when the first execution was started before
+// the second one, comparing batches and then attempts
+func executionBefore(first *ansibleeev1.OpenStackAnsibleEE, second *ansibleeev1.OpenStackAnsibleEE) bool {
+	firstBatch, _ := strconv.Atoi(first.Labels["openstackdataplanebatch"])
+	secondBatch, _ := strconv.Atoi(second.Labels["openstackdataplanebatch"])
+	if firstBatch != secondBatch {
+		return firstBatch < secondBatch
+	}
+	return dataplaneutil.GetAnsibleExecutionAttempt(first) < dataplaneutil.GetAnsibleExecutionAttempt(second)
+}
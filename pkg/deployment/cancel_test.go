@@ -0,0 +1,119 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	dataplaneutil "github.com/openstack-k8s-operators/dataplane-operator/pkg/util"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	ansibleeev1 "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
+)
+
+func TestCancelDeployment(t *testing.T) {
+	service := &dataplanev1.OpenStackDataPlaneService{
+		ObjectMeta: metav1.ObjectMeta{Name: "install-os", Namespace: "openstack"},
+	}
+	newAnsibleEE := func(batch int, jobStatus string) client.Object {
+		name, labels := dataplaneutil.GetAnsibleExecutionBatchNameAndLabels(service, "edpm-deployment", "edpm-compute", batch)
+		return &ansibleeev1.OpenStackAnsibleEE{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "openstack", Labels: labels},
+			Status:     ansibleeev1.OpenStackAnsibleEEStatus{JobStatus: jobStatus},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		batches    int
+		ansibleEEs []client.Object
+		want       corev1.ConditionStatus
+	}{
+		{
+			name:       "succeeded on the NodeSet",
+			ansibleEEs: []client.Object{newAnsibleEE(0, ansibleeev1.JobStatusSucceeded)},
+			want:       corev1.ConditionTrue,
+		},
+		{
+			name:       "running on the NodeSet",
+			ansibleEEs: []client.Object{newAnsibleEE(0, ansibleeev1.JobStatusRunning)},
+			want:       corev1.ConditionFalse,
+		},
+		{
+			name:    "succeeded on every batch",
+			batches: 2,
+			ansibleEEs: []client.Object{
+				newAnsibleEE(1, ansibleeev1.JobStatusSucceeded),
+				newAnsibleEE(2, ansibleeev1.JobStatusSucceeded),
+			},
+			want: corev1.ConditionTrue,
+		},
+		{
+			name:       "second batch not started",
+			batches:    2,
+			ansibleEEs: []client.Object{newAnsibleEE(1, ansibleeev1.JobStatusSucceeded)},
+			want:       corev1.ConditionFalse,
+		},
+		{
+			name:    "first batch failed",
+			batches: 2,
+			ansibleEEs: []client.Object{
+				newAnsibleEE(1, ansibleeev1.JobStatusFailed),
+				newAnsibleEE(2, ansibleeev1.JobStatusSucceeded),
+			},
+			want: corev1.ConditionFalse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployment := &dataplanev1.OpenStackDataPlaneDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "edpm-deployment", Namespace: "openstack"},
+				Spec:       dataplanev1.OpenStackDataPlaneDeploymentSpec{NodeSets: []string{"edpm-compute"}},
+			}
+			deployment.Status.NodeSetConditions = map[string]condition.Conditions{"edpm-compute": {}}
+			h := newTestHelper(t, deployment, tt.ansibleEEs...)
+
+			err := CancelDeployment(context.Background(), h, deployment,
+				map[string][]string{"edpm-compute": {"install-os"}},
+				map[string]int{"edpm-compute": tt.batches})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !deployment.Status.Cancelled {
+				t.Error("Deployment not cancelled")
+			}
+			nsConditions := deployment.Status.NodeSetConditions["edpm-compute"]
+			for _, conditionType := range []condition.Type{
+				GetServiceReadyCondition("install-os"),
+				dataplanev1.NodeSetDeploymentReadyCondition,
+			} {
+				ready := nsConditions.Get(conditionType)
+				if ready == nil || ready.Status != tt.want {
+					t.Errorf("got %s condition %v, want %s", conditionType, ready, tt.want)
+				}
+				if tt.want == corev1.ConditionFalse && ready != nil && ready.Reason != dataplanev1.CancelledReason {
+					t.Errorf("got %s reason %s, want %s", conditionType, ready.Reason, dataplanev1.CancelledReason)
+				}
+			}
+		})
+	}
+}
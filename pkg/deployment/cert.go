@@ -18,6 +18,8 @@ package deployment
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"sort"
 	"strconv"
@@ -29,12 +31,14 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	certmgrv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/dataplane-operator/pkg/metrics"
 	infranetworkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
 	"github.com/openstack-k8s-operators/lib-common/modules/certmanager"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
@@ -83,6 +87,7 @@ func EnsureTLSCerts(ctx context.Context, helper *helper.Helper,
 	allHostnames map[string]map[infranetworkv1.NetNameStr]string,
 	allIPs map[string]map[infranetworkv1.NetNameStr]string,
 	service dataplanev1.OpenStackDataPlaneService,
+	recorder record.EventRecorder,
 ) (*ctrl.Result, error) {
 	certsData := map[string][]byte{}
 	secretMaxSize := instance.Spec.SecretMaxSize
@@ -171,16 +176,27 @@ func EnsureTLSCerts(ctx context.Context, helper *helper.Helper,
 		}
 
 		certSecret, result, err = GetTLSNodeCert(ctx, helper, instance, certName,
-			issuer.Name, labels, baseName, hosts, ips, service.Spec.TLSCert.KeyUsages)
+			issuer.Name, labels, baseName, hosts, ips, service.Spec.TLSCert.KeyUsages, recorder)
 
 		// handle cert request errors
-		if (err != nil) || (result != ctrl.Result{}) {
+		if err != nil {
+			recorder.Eventf(instance, corev1.EventTypeWarning, CertificateFailedReason,
+				"Unable to issue the %s certificate of node %s: %s", service.Name, nodeName, err.Error())
 			return &result, err
+		} else if (result != ctrl.Result{}) {
+			return &result, nil
 		}
 
 		// TODO(alee) Add an owner reference to the secret so it can be monitored
 		// We'll do this once stuggi adds a function to do this in libcommon
 
+		expiry, err := getCertificateExpiry(certSecret.Data["tls.crt"])
+		if err != nil {
+			helper.GetLogger().Info("Unable to read the expiry of the certificate", "certificate", certName, "error", err.Error())
+		} else {
+			metrics.SetNodeCertificateExpiry(instance.Namespace, instance.Name, service.Name, hostName, expiry)
+		}
+
 		// To use this cert, add it to the relevant service data
 		certsData[baseName+"-tls.key"] = certSecret.Data["tls.key"]
 		certsData[baseName+"-tls.crt"] = certSecret.Data["tls.crt"]
@@ -216,6 +232,19 @@ func EnsureTLSCerts(ctx context.Context, helper *helper.Helper,
 	return &ctrl.Result{}, nil
 }
 
+// getCertificateExpiry returns the time the PEM encoded certificate expires at
+func getCertificateExpiry(data []byte) (time.Time, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return time.Time{}, fmt.Errorf("no PEM encoded certificate found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
+
 // GetTLSNodeCert creates or retrieves the cert for a node for a given service
 func GetTLSNodeCert(ctx context.Context, helper *helper.Helper,
 	instance *dataplanev1.OpenStackDataPlaneNodeSet,
@@ -223,6 +252,7 @@ func GetTLSNodeCert(ctx context.Context, helper *helper.Helper,
 	labels map[string]string,
 	commonName string,
 	hostnames []string, ips []string, usages []certmgrv1.KeyUsage,
+	recorder record.EventRecorder,
 ) (*corev1.Secret, ctrl.Result, error) {
 	secretName := "cert-" + certName
 	certSecret, _, err := secret.GetSecret(ctx, helper, secretName, instance.Namespace)
@@ -256,6 +286,8 @@ func GetTLSNodeCert(ctx context.Context, helper *helper.Helper,
 		} else if (result != ctrl.Result{}) {
 			return nil, result, nil
 		}
+		recorder.Eventf(instance, corev1.EventTypeNormal, CertificateIssuedReason,
+			"Issued certificate %s by issuer %s", certName, issuer)
 	}
 	return certSecret, ctrl.Result{}, nil
 }
@@ -0,0 +1,115 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+)
+
+// GetServiceDependencies - returns the services each service in the list has
+// to wait for. Services without DependsOn wait for all the services preceding
+// them in the list, including the ones started early because of their own
+// DependsOn. Dependencies on services which are not part of the list are
+// considered already deployed, but must exist.
+func GetServiceDependencies(
+	ctx context.Context,
+	helper *helper.Helper,
+	services []string,
+	foundServices map[string]dataplanev1.OpenStackDataPlaneService,
+) (map[string][]string, error) {
+	dependencies := make(map[string][]string, len(services))
+	for idx, serviceName := range services {
+		service := foundServices[serviceName]
+		if service.Spec.DependsOn == nil {
+			if idx > 0 {
+				dependencies[serviceName] = slices.Clone(services[:idx])
+			}
+			continue
+		}
+		for _, dep := range service.Spec.DependsOn {
+			if slices.Contains(services, dep) {
+				dependencies[serviceName] = append(dependencies[serviceName], dep)
+				continue
+			}
+			_, err := GetService(ctx, helper, dep)
+			if err != nil {
+				if k8s_errors.IsNotFound(err) {
+					return nil, fmt.Errorf("service %s depends on missing service %s", serviceName, dep)
+				}
+				return nil, err
+			}
+			helper.GetLogger().Info("Dependency not in services list, assuming it is deployed",
+				"service", serviceName, "dependency", dep)
+		}
+	}
+
+	return dependencies, nil
+}
+
+// SortServicesByDependencies - orders the services so that each service comes
+// after all of its dependencies, otherwise keeping the order of the list.
+// Returns an error if the dependencies contain a cycle.
+func SortServicesByDependencies(services []string, dependencies map[string][]string) ([]string, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(services))
+	sorted := make([]string, 0, len(services))
+	path := []string{}
+
+	var visit func(service string) error
+	visit = func(service string) error {
+		switch state[service] {
+		case visited:
+			return nil
+		case visiting:
+			cycle := append(path[slices.Index(path, service):], service)
+			return fmt.Errorf("service dependency cycle detected: %s", strings.Join(cycle, " -> "))
+		}
+		state[service] = visiting
+		path = append(path, service)
+		for _, dep := range dependencies[service] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[service] = visited
+		sorted = append(sorted, service)
+		return nil
+	}
+
+	for _, service := range services {
+		if state[service] == unvisited {
+			if err := visit(service); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return sorted, nil
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+)
+
+func newDependencyService(name string, dependsOn ...string) *dataplanev1.OpenStackDataPlaneService {
+	return &dataplanev1.OpenStackDataPlaneService{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "openstack"},
+		Spec:       dataplanev1.OpenStackDataPlaneServiceSpec{DependsOn: dependsOn},
+	}
+}
+
+func TestGetServiceDependencies(t *testing.T) {
+	services := []*dataplanev1.OpenStackDataPlaneService{
+		newDependencyService("run-os"),
+		newDependencyService("reboot-os"),
+		newDependencyService("install-certs"),
+		newDependencyService("ovn"),
+		newDependencyService("neutron-metadata", "run-os", "reboot-os", "install-certs", "ovn"),
+		newDependencyService("libvirt", "run-os", "reboot-os", "install-certs"),
+		newDependencyService("nova"),
+		newDependencyService("telemetry", "run-os", "reboot-os", "install-certs"),
+		newDependencyService("broken", "missing-service"),
+	}
+	objs := make([]client.Object, 0, len(services))
+	foundServices := map[string]dataplanev1.OpenStackDataPlaneService{}
+	for _, service := range services {
+		objs = append(objs, service)
+		foundServices[service.Name] = *service
+	}
+
+	tests := []struct {
+		name     string
+		services []string
+		want     map[string][]string
+		wantErr  string
+	}{
+		{
+			name:     "default services",
+			services: []string{"run-os", "reboot-os", "install-certs", "ovn", "neutron-metadata", "libvirt", "nova", "telemetry"},
+			want: map[string][]string{
+				"reboot-os":        {"run-os"},
+				"install-certs":    {"run-os", "reboot-os"},
+				"ovn":              {"run-os", "reboot-os", "install-certs"},
+				"neutron-metadata": {"run-os", "reboot-os", "install-certs", "ovn"},
+				"libvirt":          {"run-os", "reboot-os", "install-certs"},
+				// nova waits for ovn and neutron-metadata, even though libvirt
+				// started before them
+				"nova":      {"run-os", "reboot-os", "install-certs", "ovn", "neutron-metadata", "libvirt"},
+				"telemetry": {"run-os", "reboot-os", "install-certs"},
+			},
+		},
+		{
+			name:     "dependencies not in the list",
+			services: []string{"ovn", "libvirt", "nova"},
+			want: map[string][]string{
+				"nova": {"ovn", "libvirt"},
+			},
+		},
+		{
+			name:     "missing dependency",
+			services: []string{"run-os", "broken"},
+			wantErr:  "service broken depends on missing service missing-service",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHelper(t, objs[0], objs[1:]...)
+			got, err := GetServiceDependencies(context.Background(), h, tt.services, foundServices)
+			if len(tt.wantErr) > 0 {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("GetServiceDependencies() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetServiceDependencies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortServicesByDependencies(t *testing.T) {
+	tests := []struct {
+		name         string
+		services     []string
+		dependencies map[string][]string
+		want         []string
+		wantErr      string
+	}{
+		{
+			name:         "no dependencies",
+			services:     []string{"configure-network", "install-os", "ovn"},
+			dependencies: map[string][]string{},
+			want:         []string{"configure-network", "install-os", "ovn"},
+		},
+		{
+			name:     "dependency listed after its dependent",
+			services: []string{"nova", "libvirt", "ovn"},
+			dependencies: map[string][]string{
+				"nova": {"libvirt"},
+			},
+			want: []string{"libvirt", "nova", "ovn"},
+		},
+		{
+			name:     "diamond",
+			services: []string{"install-certs", "ovn", "libvirt", "nova"},
+			dependencies: map[string][]string{
+				"ovn":     {"install-certs"},
+				"libvirt": {"install-certs"},
+				"nova":    {"ovn", "libvirt"},
+			},
+			want: []string{"install-certs", "ovn", "libvirt", "nova"},
+		},
+		{
+			name:     "cycle",
+			services: []string{"install-certs", "ovn", "libvirt"},
+			dependencies: map[string][]string{
+				"ovn":           {"install-certs"},
+				"libvirt":       {"ovn"},
+				"install-certs": {"libvirt"},
+			},
+			wantErr: "service dependency cycle detected: install-certs -> libvirt -> ovn -> install-certs",
+		},
+		{
+			name:     "self dependency",
+			services: []string{"ovn"},
+			dependencies: map[string][]string{
+				"ovn": {"ovn"},
+			},
+			wantErr: "service dependency cycle detected: ovn -> ovn",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SortServicesByDependencies(tt.services, tt.dependencies)
+			if len(tt.wantErr) > 0 {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("SortServicesByDependencies() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortServicesByDependencies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -22,9 +22,11 @@ import (
 	"path"
 	"sort"
 	"strconv"
+	"time"
 
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/iancoleman/strcase"
@@ -48,10 +50,58 @@ type Deployer struct {
 	AeeSpec                     *dataplanev1.AnsibleEESpec
 	InventorySecrets            map[string]string
 	AnsibleSSHPrivateKeySecrets map[string]string
+	Batch                       int
+	ResumeFrom                  *dataplanev1.OpenStackDataPlaneDeployment
+	Recorder                    record.EventRecorder
+	ExecutionQueue              *ExecutionQueue
+	requeueAfter                time.Duration
+	nextMaintenanceWindow       time.Time
 }
 
 // Deploy function encapsulating primary deloyment handling
+// When a canary or rollout strategy is set, the nodes of the NodeSet are
+// deployed in batches, one batch after the other.
+// Outside of the maintenance windows of the NodeSet, no new ansible execution
+// is started, running ones are allowed to finish.
 func (d *Deployer) Deploy(services []string) (*ctrl.Result, error) {
+	open, nextWindow, err := GetMaintenanceWindow(d.NodeSet, time.Now())
+	if err != nil {
+		return &ctrl.Result{}, err
+	}
+	if !open {
+		d.nextMaintenanceWindow = nextWindow
+		d.setRequeueAfter(time.Until(nextWindow))
+	}
+
+	batches, canary, err := GetNodeSetBatches(d.NodeSet, d.Deployment.Spec.RolloutStrategy, d.Deployment.Spec.Canary)
+	if err != nil {
+		return &ctrl.Result{}, err
+	}
+	var result *ctrl.Result
+	if len(batches) > 0 {
+		result, err = d.deployBatches(services, batches, canary)
+	} else {
+		result, err = d.deployServices(services)
+	}
+
+	if err == nil && result != nil && d.waitingForMaintenanceWindow() {
+		d.Helper.GetLogger().Info("Waiting for the NodeSet maintenance window", "nodeSet", d.NodeSet.Name, "start", nextWindow)
+		nsConditions := d.Status.NodeSetConditions[d.NodeSet.Name]
+		nsConditions.Set(condition.FalseCondition(
+			dataplanev1.NodeSetDeploymentReadyCondition,
+			dataplanev1.WaitingForMaintenanceWindowReason,
+			condition.SeverityInfo,
+			dataplanev1.NodeSetDeploymentWaitingForWindowMessage,
+			nextWindow.Format(time.RFC3339)))
+		d.Status.NodeSetConditions[d.NodeSet.Name] = nsConditions
+	}
+	return result, err
+}
+
+// deployServices deploys the services on the NodeSet
+// Services are started as soon as all of the services they depend on are
+// ready, so independent services run in parallel.
+func (d *Deployer) deployServices(services []string) (*ctrl.Result, error) {
 	log := d.Helper.GetLogger()
 
 	var readyCondition condition.Type
@@ -60,23 +110,58 @@ func (d *Deployer) Deploy(services []string) (*ctrl.Result, error) {
 	var readyErrorMessage string
 	var deployName string
 
+	foundServices := make(map[string]dataplanev1.OpenStackDataPlaneService, len(services))
+	for _, service := range services {
+		foundService, err := GetService(d.Ctx, d.Helper, service)
+		if err != nil {
+			return &ctrl.Result{}, err
+		}
+		foundServices[service] = foundService
+	}
+
+	dependencies, err := GetServiceDependencies(d.Ctx, d.Helper, services, foundServices)
+	if err != nil {
+		return &ctrl.Result{}, fmt.Errorf(dataplanev1.NodeSetServiceDependencyErrorMessage, err.Error())
+	}
+	orderedServices, err := SortServicesByDependencies(services, dependencies)
+	if err != nil {
+		return &ctrl.Result{}, fmt.Errorf(dataplanev1.NodeSetServiceDependencyErrorMessage, err.Error())
+	}
+
 	// Save a copy of the original ExtraMounts so it can be reset after each
 	// service deployment
 	aeeSpecMounts := make([]storage.VolMounts, len(d.AeeSpec.ExtraMounts))
 	copy(aeeSpecMounts, d.AeeSpec.ExtraMounts)
+	// Save a copy of the original ansible options so the service overrides
+	// only apply to their own service
+	aeeSpecOptions := *d.AeeSpec
+	allReady := true
 	// Deploy the composable services
-	for _, service := range services {
-		log.Info("Deploying service", "service", service)
-		foundService, err := GetService(d.Ctx, d.Helper, service)
+	for _, service := range orderedServices {
+		if !d.dependenciesReady(dependencies[service]) {
+			log.Info("Waiting for service dependencies", "service", service, "dependencies", dependencies[service])
+			allReady = false
+			continue
+		}
+
+		foundService := foundServices[service]
+		resumed, err := d.resumeService(foundService, GetServiceReadyCondition(service))
 		if err != nil {
 			return &ctrl.Result{}, err
 		}
+		if resumed {
+			log.Info("Skipping service deployed by the resumed Deployment", "service", service, "resumeFrom", d.ResumeFrom.Name)
+			continue
+		}
+
+		log.Info("Deploying service", "service", service)
 		deployName = foundService.Name
-		readyCondition = condition.Type(fmt.Sprintf("Service%sDeploymentReady", strcase.ToCamel(service)))
+		readyCondition = GetServiceReadyCondition(service)
 		readyWaitingMessage = fmt.Sprintf(dataplanev1.NodeSetServiceDeploymentReadyWaitingMessage, deployName)
 		readyMessage = fmt.Sprintf(dataplanev1.NodeSetServiceDeploymentReadyMessage, deployName)
 		readyErrorMessage = fmt.Sprintf(dataplanev1.NodeSetServiceDeploymentErrorMessage, deployName)
 		d.AeeSpec.OpenStackAnsibleEERunnerImage = foundService.Spec.OpenStackAnsibleEERunnerImage
+		d.applyServiceOverride(service, aeeSpecOptions)
 
 		// Reset ExtraMounts to its original value, and then add in service
 		// specific mounts.
@@ -105,19 +190,47 @@ func (d *Deployer) Deploy(services []string) (*ctrl.Result, error) {
 			deployName,
 			foundService,
 		)
+		d.updateAttemptHistory(foundService)
 
 		nsConditions := d.Status.NodeSetConditions[d.NodeSet.Name]
-		if err != nil || !nsConditions.IsTrue(readyCondition) {
+		if err != nil {
 			log.Info(fmt.Sprintf("Condition %s not ready", readyCondition))
 			return &ctrl.Result{}, err
 		}
+		if !nsConditions.IsTrue(readyCondition) {
+			log.Info(fmt.Sprintf("Condition %s not ready", readyCondition))
+			allReady = false
+			continue
+		}
 
 		log.Info(fmt.Sprintf("Condition %s ready", readyCondition))
 	}
 
+	if !allReady {
+		return &ctrl.Result{RequeueAfter: d.requeueAfter}, nil
+	}
+
 	return nil, nil
 }
 
+// dependenciesReady returns true when the ready conditions of all given
+// services are True for the NodeSet
+func (d *Deployer) dependenciesReady(dependencies []string) bool {
+	nsConditions := d.Status.NodeSetConditions[d.NodeSet.Name]
+	for _, dep := range dependencies {
+		if !nsConditions.IsTrue(GetServiceReadyCondition(dep)) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetServiceReadyCondition returns the NodeSet condition type tracking the
+// deployment of the given service
+func GetServiceReadyCondition(service string) condition.Type {
+	return condition.Type(fmt.Sprintf("Service%sDeploymentReady", strcase.ToCamel(service)))
+}
+
 // ConditionalDeploy function encapsulating primary deloyment handling with
 // conditions.
 func (d *Deployer) ConditionalDeploy(
@@ -132,7 +245,41 @@ func (d *Deployer) ConditionalDeploy(
 	log := d.Helper.GetLogger()
 
 	nsConditions := d.Status.NodeSetConditions[d.NodeSet.Name]
-	if nsConditions.IsUnknown(readyCondition) {
+	if timedOut := d.getTimedOutAttempt(foundService.Name); timedOut != nil {
+		log.Info(fmt.Sprintf("Condition %s timed out", readyCondition), "execution", timedOut.Name)
+		nsConditions.Set(condition.FalseCondition(
+			readyCondition,
+			dataplanev1.TimedOutReason,
+			condition.SeverityError,
+			dataplanev1.NodeSetServiceDeploymentTimedOutMessage,
+			deployName,
+			timedOut.Name))
+		d.Status.NodeSetConditions[d.NodeSet.Name] = nsConditions
+		return fmt.Errorf(dataplanev1.NodeSetServiceDeploymentTimedOutMessage, deployName, timedOut.Name)
+	}
+
+	// Services are not started while the Deployment is paused, outside of the
+	// NodeSet maintenance windows, nor once the Deployment timed out. They are
+	// queued while too many ansible executions are running.
+	if nsConditions.IsUnknown(readyCondition) && !d.Deployment.IsPaused() &&
+		!d.waitingForMaintenanceWindow() && !DeploymentTimedOut(d.Deployment) {
+		queued, position, queueErr := d.queueExecution(foundService, 1)
+		if queueErr != nil {
+			util.LogErrorForObject(d.Helper, queueErr, fmt.Sprintf("Unable to queue %s for %s", deployName, d.NodeSet.Name), d.NodeSet)
+			return queueErr
+		}
+		if queued {
+			log.Info(fmt.Sprintf("Condition %s not started, execution queued", readyCondition), "position", position)
+			nsConditions.Set(condition.FalseCondition(
+				readyCondition,
+				dataplanev1.QueuedReason,
+				condition.SeverityInfo,
+				dataplanev1.NodeSetServiceDeploymentQueuedMessage,
+				deployName,
+				position))
+			d.Status.NodeSetConditions[d.NodeSet.Name] = nsConditions
+			return nil
+		}
 		log.Info(fmt.Sprintf("%s Unknown, starting %s", readyCondition, deployName))
 		err = d.DeployService(
 			foundService)
@@ -148,13 +295,48 @@ func (d *Deployer) ConditionalDeploy(
 
 	}
 
-	if nsConditions.IsFalse(readyCondition) {
+	if !nsConditions.IsTrue(readyCondition) {
 		var ansibleEE *ansibleeev1.OpenStackAnsibleEE
-		_, labelSelector := dataplaneutil.GetAnsibleExecutionNameAndLabels(&foundService, d.Deployment.Name, d.NodeSet.Name)
+		_, labelSelector := dataplaneutil.GetAnsibleExecutionDeploymentNameAndLabels(&foundService, d.Deployment, d.NodeSet.Name, d.Batch)
 		ansibleEE, err = dataplaneutil.GetAnsibleExecution(d.Ctx, d.Helper, d.Deployment, labelSelector)
 		if err != nil {
 			// Return nil if we don't have AnsibleEE available yet
 			if k8s_errors.IsNotFound(err) {
+				if DeploymentTimedOut(d.Deployment) {
+					timeout := GetDeploymentTimeout(d.Deployment).String()
+					log.Info(fmt.Sprintf("Condition %s not started before the Deployment timed out", readyCondition))
+					nsConditions.Set(condition.FalseCondition(
+						readyCondition,
+						dataplanev1.TimedOutReason,
+						condition.SeverityError,
+						dataplanev1.DeploymentTimedOutMessage,
+						timeout))
+					d.Status.NodeSetConditions[d.NodeSet.Name] = nsConditions
+					return fmt.Errorf(dataplanev1.DeploymentTimedOutMessage, timeout)
+				}
+				if d.Deployment.IsPaused() {
+					log.Info(fmt.Sprintf("Condition %s not started, Deployment paused", readyCondition))
+					nsConditions.Set(condition.FalseCondition(
+						readyCondition,
+						dataplanev1.PausedReason,
+						condition.SeverityInfo,
+						dataplanev1.NodeSetServiceDeploymentPausedMessage,
+						deployName))
+					d.Status.NodeSetConditions[d.NodeSet.Name] = nsConditions
+					return nil
+				}
+				if d.waitingForMaintenanceWindow() {
+					log.Info(fmt.Sprintf("Condition %s not started, waiting for the maintenance window", readyCondition))
+					nsConditions.Set(condition.FalseCondition(
+						readyCondition,
+						dataplanev1.WaitingForMaintenanceWindowReason,
+						condition.SeverityInfo,
+						dataplanev1.NodeSetServiceDeploymentWaitingForWindowMessage,
+						deployName,
+						d.nextMaintenanceWindow.Format(time.RFC3339)))
+					d.Status.NodeSetConditions[d.NodeSet.Name] = nsConditions
+					return nil
+				}
 				log.Info(fmt.Sprintf("%s OpenStackAnsibleEE not yet found", readyCondition))
 				return nil
 			}
@@ -165,6 +347,8 @@ func (d *Deployer) ConditionalDeploy(
 				condition.SeverityError,
 				readyErrorMessage,
 				err.Error()))
+			d.Status.NodeSetConditions[d.NodeSet.Name] = nsConditions
+			return err
 		}
 
 		if ansibleEE.Status.JobStatus == ansibleeev1.JobStatusSucceeded {
@@ -175,6 +359,27 @@ func (d *Deployer) ConditionalDeploy(
 		}
 
 		if ansibleEE.Status.JobStatus == ansibleeev1.JobStatusRunning || ansibleEE.Status.JobStatus == ansibleeev1.JobStatusPending {
+			deadline, timeout := d.getExecutionDeadline(ansibleEE, foundService)
+			if !deadline.IsZero() && !time.Now().Before(deadline) {
+				log.Info(fmt.Sprintf("Condition %s timed out", readyCondition), "execution", ansibleEE.Name, "timeout", timeout)
+				err = d.stopExecution(ansibleEE, foundService)
+				if err != nil {
+					util.LogErrorForObject(d.Helper, err, fmt.Sprintf("Unable to stop %s for %s", deployName, d.NodeSet.Name), d.NodeSet)
+					return err
+				}
+				nsConditions.Set(condition.FalseCondition(
+					readyCondition,
+					dataplanev1.TimedOutReason,
+					condition.SeverityError,
+					dataplanev1.NodeSetServiceDeploymentTimedOutMessage,
+					deployName,
+					ansibleEE.Name))
+				d.Status.NodeSetConditions[d.NodeSet.Name] = nsConditions
+				return fmt.Errorf(dataplanev1.NodeSetServiceDeploymentTimedOutMessage, deployName, ansibleEE.Name)
+			}
+			if !deadline.IsZero() {
+				d.setRequeueAfter(time.Until(deadline))
+			}
 			log.Info(fmt.Sprintf("AnsibleEE job is not yet completed: Execution: %s, Status: %s", ansibleEE.Name, ansibleEE.Status.JobStatus))
 			nsConditions.Set(condition.FalseCondition(
 				readyCondition,
@@ -184,14 +389,80 @@ func (d *Deployer) ConditionalDeploy(
 		}
 
 		if ansibleEE.Status.JobStatus == ansibleeev1.JobStatusFailed {
-			log.Info(fmt.Sprintf("Condition %s error", readyCondition))
-			err = fmt.Errorf("execution.name %s Execution.namespace %s Execution.status.jobstatus: %s", ansibleEE.Name, ansibleEE.Namespace, ansibleEE.Status.JobStatus)
-			nsConditions.Set(condition.FalseCondition(
-				readyCondition,
-				condition.ErrorReason,
-				condition.SeverityError,
-				readyErrorMessage,
-				err.Error()))
+			attempt := dataplaneutil.GetAnsibleExecutionAttempt(ansibleEE)
+			retryPolicy := GetRetryPolicy(d.Deployment, foundService)
+			if retryPolicy != nil && attempt <= retryPolicy.MaxRetries && !DeploymentTimedOut(d.Deployment) {
+				retryTime := getAnsibleExecutionFinishTime(ansibleEE).Add(GetRetryBackoff(retryPolicy, attempt))
+				if time.Now().Before(retryTime) {
+					log.Info(fmt.Sprintf("Condition %s error, retrying", readyCondition), "attempt", attempt, "retryTime", retryTime)
+					nsConditions.Set(condition.FalseCondition(
+						readyCondition,
+						dataplanev1.RetryingReason,
+						condition.SeverityWarning,
+						dataplanev1.NodeSetServiceDeploymentRetryingMessage,
+						deployName,
+						attempt,
+						retryTime.Format(time.RFC3339)))
+					d.setRequeueAfter(time.Until(retryTime))
+				} else if d.Deployment.IsPaused() {
+					log.Info(fmt.Sprintf("Not retrying %s, Deployment paused", deployName), "attempt", attempt+1)
+					nsConditions.Set(condition.FalseCondition(
+						readyCondition,
+						dataplanev1.PausedReason,
+						condition.SeverityInfo,
+						dataplanev1.NodeSetServiceDeploymentPausedMessage,
+						deployName))
+				} else if d.waitingForMaintenanceWindow() {
+					log.Info(fmt.Sprintf("Not retrying %s, waiting for the maintenance window", deployName), "attempt", attempt+1)
+					nsConditions.Set(condition.FalseCondition(
+						readyCondition,
+						dataplanev1.WaitingForMaintenanceWindowReason,
+						condition.SeverityInfo,
+						dataplanev1.NodeSetServiceDeploymentWaitingForWindowMessage,
+						deployName,
+						d.nextMaintenanceWindow.Format(time.RFC3339)))
+				} else if queued, position, queueErr := d.queueExecution(foundService, attempt+1); queueErr != nil {
+					util.LogErrorForObject(d.Helper, queueErr, fmt.Sprintf("Unable to queue %s for %s", deployName, d.NodeSet.Name), d.NodeSet)
+					return queueErr
+				} else if queued {
+					log.Info(fmt.Sprintf("Not retrying %s, execution queued", deployName), "attempt", attempt+1, "position", position)
+					nsConditions.Set(condition.FalseCondition(
+						readyCondition,
+						dataplanev1.QueuedReason,
+						condition.SeverityInfo,
+						dataplanev1.NodeSetServiceDeploymentQueuedMessage,
+						deployName,
+						position))
+				} else {
+					log.Info(fmt.Sprintf("Retrying %s", deployName), "attempt", attempt+1)
+					err = d.deployServiceAttempt(foundService, attempt+1)
+					if err != nil {
+						util.LogErrorForObject(d.Helper, err, fmt.Sprintf("Unable to retry %s for %s", deployName, d.NodeSet.Name), d.NodeSet)
+						return err
+					}
+					nsConditions.Set(condition.FalseCondition(
+						readyCondition,
+						condition.RequestedReason,
+						condition.SeverityInfo,
+						readyWaitingMessage))
+				}
+			} else {
+				log.Info(fmt.Sprintf("Condition %s error", readyCondition))
+				err = fmt.Errorf("execution.name %s Execution.namespace %s Execution.status.jobstatus: %s", ansibleEE.Name, ansibleEE.Namespace, ansibleEE.Status.JobStatus)
+				// Copy why the execution failed into the condition
+				if failure := d.getExecutionFailure(foundService.Name, ansibleEE); failure != "" {
+					err = fmt.Errorf("%w, %s", err, failure)
+				}
+				if attempt > 1 {
+					readyErrorMessage = fmt.Sprintf(dataplanev1.NodeSetServiceDeploymentRetryErrorMessage, deployName, attempt)
+				}
+				nsConditions.Set(condition.FalseCondition(
+					readyCondition,
+					condition.ErrorReason,
+					condition.SeverityError,
+					readyErrorMessage,
+					err.Error()))
+			}
 		}
 	}
 	d.Status.NodeSetConditions[d.NodeSet.Name] = nsConditions
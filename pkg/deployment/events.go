@@ -0,0 +1,170 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/dataplane-operator/pkg/metrics"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	ansibleeev1 "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
+)
+
+// Reasons of the events recorded on OpenStackDataPlaneDeployments
+const (
+	// DeploymentStartedReason - the Deployment started deploying its NodeSets
+	DeploymentStartedReason = "DeploymentStarted"
+	// DeploymentSucceededReason - all NodeSets of the Deployment are deployed
+	DeploymentSucceededReason = "DeploymentSucceeded"
+	// DeploymentFailedReason - the Deployment failed
+	DeploymentFailedReason = "DeploymentFailed"
+	// DeploymentTimedOutReason - the Deployment did not complete before its
+	// timeout
+	DeploymentTimedOutReason = "DeploymentTimedOut"
+	// DeploymentCancelledReason - the Deployment was cancelled
+	DeploymentCancelledReason = "DeploymentCancelled"
+	// DeploymentRerunReason - the finished Deployment was started again
+	DeploymentRerunReason = "DeploymentRerun"
+
+	// ServiceExecutionCreatedReason - the ansible execution of a service was
+	// created
+	ServiceExecutionCreatedReason = "ServiceExecutionCreated"
+	// ServiceExecutionRetriedReason - a new attempt of the ansible execution
+	// of a service was created after a failure
+	ServiceExecutionRetriedReason = "ServiceExecutionRetried"
+	// ServiceExecutionSucceededReason - the ansible execution of a service
+	// succeeded
+	ServiceExecutionSucceededReason = "ServiceExecutionSucceeded"
+	// ServiceExecutionFailedReason - the ansible execution of a service failed
+	ServiceExecutionFailedReason = "ServiceExecutionFailed"
+	// ServiceExecutionTimedOutReason - the ansible execution of a service was
+	// stopped after its timeout
+	ServiceExecutionTimedOutReason = "ServiceExecutionTimedOut"
+)
+
+// Reasons of the events recorded on OpenStackDataPlaneNodeSets
+const (
+	// IPReservationReadyReason - the IPSets of the NodeSet are ready
+	IPReservationReadyReason = "IPReservationReady"
+	// IPReservationFailedReason - the IPSets of the NodeSet failed
+	IPReservationFailedReason = "IPReservationFailed"
+	// DNSDataReadyReason - the DNSData of the NodeSet is ready
+	DNSDataReadyReason = "DNSDataReady"
+	// DNSDataFailedReason - the DNSData of the NodeSet failed
+	DNSDataFailedReason = "DNSDataFailed"
+	// BaremetalProvisionReadyReason - the nodes of the NodeSet are provisioned
+	BaremetalProvisionReadyReason = "BaremetalProvisionReady"
+	// BaremetalProvisionFailedReason - the provisioning of the nodes of the
+	// NodeSet failed
+	BaremetalProvisionFailedReason = "BaremetalProvisionFailed"
+	// CertificateIssuedReason - a TLS certificate was issued for a node of the
+	// NodeSet
+	CertificateIssuedReason = "CertificateIssued"
+	// CertificateFailedReason - a TLS certificate could not be issued for the
+	// NodeSet
+	CertificateFailedReason = "CertificateFailed"
+)
+
+// nodeSetReadinessEvents are the reasons of the events recorded when the
+// conditions of the resources of a NodeSet become ready or fail
+var nodeSetReadinessEvents = []struct {
+	condition    condition.Type
+	readyReason  string
+	failedReason string
+}{
+	{dataplanev1.NodeSetIPReservationReadyCondition, IPReservationReadyReason, IPReservationFailedReason},
+	{dataplanev1.NodeSetDNSDataReadyCondition, DNSDataReadyReason, DNSDataFailedReason},
+	{dataplanev1.NodeSetBareMetalProvisionReadyCondition, BaremetalProvisionReadyReason, BaremetalProvisionFailedReason},
+}
+
+// RecordNodeSetReadinessEvents - records an event on the NodeSet for each of
+// its IPSet, DNSData and BaremetalSet conditions which became True, or failed,
+// since the saved conditions
+func RecordNodeSetReadinessEvents(
+	recorder record.EventRecorder,
+	instance *dataplanev1.OpenStackDataPlaneNodeSet,
+	savedConditions condition.Conditions,
+) {
+	for _, event := range nodeSetReadinessEvents {
+		current := instance.Status.Conditions.Get(event.condition)
+		if current == nil {
+			continue
+		}
+		saved := savedConditions.Get(event.condition)
+		switch {
+		case current.Status == corev1.ConditionTrue:
+			if saved == nil || saved.Status != corev1.ConditionTrue {
+				recorder.Event(instance, corev1.EventTypeNormal, event.readyReason, current.Message)
+			}
+		case current.Status == corev1.ConditionFalse && current.Reason == condition.ErrorReason:
+			if saved == nil || saved.Reason != condition.ErrorReason {
+				recorder.Event(instance, corev1.EventTypeWarning, event.failedReason, current.Message)
+			}
+		}
+	}
+}
+
+// recordAttemptChanges records an event on the Deployment, and the metrics,
+// for each attempt of the ansible execution of the service which was created,
+// succeeded or failed since the previous attempt history
+func (d *Deployer) recordAttemptChanges(
+	service string,
+	previous []dataplanev1.AnsibleExecutionAttempt,
+	attempts []dataplanev1.AnsibleExecutionAttempt,
+	finishTimes map[string]time.Time,
+) {
+	previousStatus := make(map[string]string, len(previous))
+	for _, attempt := range previous {
+		previousStatus[attempt.Name] = attempt.JobStatus
+	}
+
+	for _, attempt := range attempts {
+		jobStatus, known := previousStatus[attempt.Name]
+		if !known {
+			if attempt.Attempt > 1 {
+				metrics.RecordServiceExecutionRetry(d.Deployment.Namespace, d.NodeSet.Name, service)
+				d.Recorder.Eventf(d.Deployment, corev1.EventTypeWarning, ServiceExecutionRetriedReason,
+					"Retrying service %s on NodeSet %s, attempt %d, execution %s",
+					service, d.NodeSet.Name, attempt.Attempt, attempt.Name)
+			} else {
+				d.Recorder.Eventf(d.Deployment, corev1.EventTypeNormal, ServiceExecutionCreatedReason,
+					"Deploying service %s on NodeSet %s, execution %s",
+					service, d.NodeSet.Name, attempt.Name)
+			}
+		}
+		if attempt.JobStatus == jobStatus {
+			continue
+		}
+		duration := finishTimes[attempt.Name].Sub(attempt.StartTime.Time)
+		switch attempt.JobStatus {
+		case ansibleeev1.JobStatusSucceeded:
+			metrics.ObserveServiceExecution(d.Deployment.Namespace, d.NodeSet.Name, service, metrics.ResultSucceeded, duration)
+			d.Recorder.Eventf(d.Deployment, corev1.EventTypeNormal, ServiceExecutionSucceededReason,
+				"Service %s succeeded on NodeSet %s, execution %s",
+				service, d.NodeSet.Name, attempt.Name)
+		case ansibleeev1.JobStatusFailed:
+			metrics.ObserveServiceExecution(d.Deployment.Namespace, d.NodeSet.Name, service, metrics.ResultFailed, duration)
+			d.Recorder.Eventf(d.Deployment, corev1.EventTypeWarning, ServiceExecutionFailedReason,
+				"Service %s failed on NodeSet %s, execution %s",
+				service, d.NodeSet.Name, attempt.Name)
+		}
+	}
+}
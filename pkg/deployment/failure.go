@@ -0,0 +1,152 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	ansibleeev1 "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
+)
+
+const (
+	// failureLogTailLines is the number of lines read from the end of the
+	// output of a failed ansible execution
+	failureLogTailLines int64 = 10
+
+	// failureLogMaxLength is the maximum length of the output of a failed
+	// ansible execution copied into a condition message
+	failureLogMaxLength = 1024
+
+	// redactedValue replaces the values which look like secrets
+	redactedValue = "<redacted>"
+)
+
+var (
+	// Values of keys such as password, token or private_key
+	secretValueRegex = regexp.MustCompile(
+		`(?i)([\w.-]*(?:password|passwd|secret|token|credential|private_?key|api_?key)[\w.-]*["']?\s*[:=]\s*)("[^"]*"|'[^']*'|[^\s,;}]+)`)
+	// PEM encoded keys and certificates, possibly truncated
+	pemBlockRegex = regexp.MustCompile(`-----BEGIN [A-Z ]+-----(?s:.*?)(?:-----END [A-Z ]+-----|$)`)
+	// Long base64 strings, such as the data of a Secret
+	base64ValueRegex = regexp.MustCompile(`[A-Za-z0-9+/]{40,}={0,2}`)
+	// Terminal color codes of the ansible output
+	colorCodeRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+)
+
+// RedactSecrets - replaces anything which looks like a secret in the text
+func RedactSecrets(text string) string {
+	text = pemBlockRegex.ReplaceAllString(text, redactedValue)
+	text = secretValueRegex.ReplaceAllString(text, "${1}"+redactedValue)
+	return base64ValueRegex.ReplaceAllString(text, redactedValue)
+}
+
+// GetAnsibleExecutionFailure - returns the reason the latest pod of a failed
+// OpenStackAnsibleEE failed, its exit code and the end of its output. Values
+// which look like secrets are redacted, and the output is limited to
+// failureLogMaxLength. An empty string is returned when the pod can not be
+// found.
+func GetAnsibleExecutionFailure(
+	ctx context.Context,
+	helper *helper.Helper,
+	ansibleEE *ansibleeev1.OpenStackAnsibleEE,
+) string {
+	log := helper.GetLogger()
+
+	pod, err := getAnsibleExecutionPod(ctx, helper, ansibleEE)
+	if err != nil {
+		log.Info("Unable to find the pod of the failed execution", "execution", ansibleEE.Name, "error", err.Error())
+		return ""
+	}
+
+	details := []string{fmt.Sprintf("pod %s", pod.Name)}
+	if pod.Status.Reason != "" {
+		details = append(details, fmt.Sprintf("reason: %s", pod.Status.Reason))
+	}
+	if pod.Status.Message != "" {
+		details = append(details, fmt.Sprintf("message: %s", pod.Status.Message))
+	}
+	if terminated := getFailedContainerState(pod); terminated != nil {
+		if terminated.Reason != "" {
+			details = append(details, fmt.Sprintf("container reason: %s", terminated.Reason))
+		}
+		details = append(details, fmt.Sprintf("exit code: %d", terminated.ExitCode))
+	}
+
+	output, err := getPodOutput(ctx, helper, pod, failureLogTailLines)
+	if err != nil {
+		log.Info("Unable to read the output of the failed execution", "execution", ansibleEE.Name, "error", err.Error())
+	} else if output = formatFailureOutput(output); output != "" {
+		details = append(details, fmt.Sprintf("output: %s", output))
+	}
+
+	return RedactSecrets(strings.Join(details, ", "))
+}
+
+// getExecutionFailure returns why the ansible execution of the service
+// failed. The failure is cached in the attempt history, so the pod and its
+// output are only looked up once per execution.
+func (d *Deployer) getExecutionFailure(serviceName string, ansibleEE *ansibleeev1.OpenStackAnsibleEE) string {
+	attempts := d.Status.NodeSetAttempts[d.NodeSet.Name][serviceName]
+	for idx := range attempts {
+		if attempts[idx].Name != ansibleEE.Name {
+			continue
+		}
+		if attempts[idx].Failure == "" {
+			attempts[idx].Failure = GetAnsibleExecutionFailure(d.Ctx, d.Helper, ansibleEE)
+		}
+		return attempts[idx].Failure
+	}
+	return GetAnsibleExecutionFailure(d.Ctx, d.Helper, ansibleEE)
+}
+
+// getFailedContainerState returns the state of the first container of the pod
+// which terminated with an error
+func getFailedContainerState(pod *corev1.Pod) *corev1.ContainerStateTerminated {
+	for _, status := range pod.Status.ContainerStatuses {
+		for _, terminated := range []*corev1.ContainerStateTerminated{
+			status.State.Terminated, status.LastTerminationState.Terminated} {
+			if terminated != nil && terminated.ExitCode != 0 {
+				return terminated
+			}
+		}
+	}
+	return nil
+}
+
+// formatFailureOutput returns the output of a failed execution on a single
+// line, redacted and limited to its last failureLogMaxLength characters
+func formatFailureOutput(output string) string {
+	output = RedactSecrets(colorCodeRegex.ReplaceAllString(output, ""))
+	lines := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	output = strings.Join(lines, " | ")
+	if len(output) > failureLogMaxLength {
+		output = "..." + strings.ToValidUTF8(output[len(output)-failureLogMaxLength:], "")
+	}
+	return output
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+)
+
+// GetDeploymentOutcome - returns the outcome of the Deployment, a Deployment
+// which timed out failed
+func GetDeploymentOutcome(deployment *dataplanev1.OpenStackDataPlaneDeployment) string {
+	switch {
+	case deployment.Status.Cancelled:
+		return dataplanev1.DeploymentOutcomeCancelled
+	case deployment.Status.Deployed:
+		return dataplanev1.DeploymentOutcomeSucceeded
+	case deployment.IsFailed():
+		return dataplanev1.DeploymentOutcomeFailed
+	}
+	return dataplanev1.DeploymentOutcomeRunning
+}
+
+// UpdateDeploymentHistory - records the current run of the Deployment in the
+// DeploymentHistory of the NodeSet. Entries are ordered by StartTime, and only
+// the DeploymentHistoryLimit most recent ones are kept.
+func UpdateDeploymentHistory(
+	nodeSet *dataplanev1.OpenStackDataPlaneNodeSet,
+	deployment *dataplanev1.OpenStackDataPlaneDeployment,
+) {
+	var entry *dataplanev1.DeploymentHistoryEntry
+	for idx := range nodeSet.Status.DeploymentHistory {
+		if nodeSet.Status.DeploymentHistory[idx].Deployment == deployment.Name &&
+			nodeSet.Status.DeploymentHistory[idx].Run == deployment.Status.Run {
+			entry = &nodeSet.Status.DeploymentHistory[idx]
+			break
+		}
+	}
+	if entry == nil {
+		services := deployment.Spec.ServicesOverride
+		if len(services) == 0 {
+			services = nodeSet.Spec.Services
+		}
+		nodeSet.Status.DeploymentHistory = append(nodeSet.Status.DeploymentHistory, dataplanev1.DeploymentHistoryEntry{
+			Deployment: deployment.Name,
+			Run:        deployment.Status.Run,
+			Mode:       deployment.Spec.Mode,
+			StartTime:  deployment.GetRunStartTime(),
+			Services:   append([]string{}, services...),
+		})
+		entry = &nodeSet.Status.DeploymentHistory[len(nodeSet.Status.DeploymentHistory)-1]
+	}
+
+	// Completed entries are not updated anymore, they hold what was deployed
+	if entry.FinishTime != nil {
+		return
+	}
+	entry.Outcome = GetDeploymentOutcome(deployment)
+	if entry.Outcome != dataplanev1.DeploymentOutcomeRunning {
+		now := metav1.Now()
+		entry.FinishTime = &now
+		entry.NodeSetHash = deployment.Status.NodeSetHashes[nodeSet.Name]
+		entry.ConfigMapHashes = deployment.Status.ConfigMapHashes
+		entry.SecretHashes = deployment.Status.SecretHashes
+	}
+
+	sort.SliceStable(nodeSet.Status.DeploymentHistory, func(i, j int) bool {
+		return nodeSet.Status.DeploymentHistory[i].StartTime.Before(&nodeSet.Status.DeploymentHistory[j].StartTime)
+	})
+	if extra := len(nodeSet.Status.DeploymentHistory) - dataplanev1.DeploymentHistoryLimit; extra > 0 {
+		nodeSet.Status.DeploymentHistory = nodeSet.Status.DeploymentHistory[extra:]
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+)
+
+func newHistoryDeployment(name string, created time.Time, readyReason condition.Reason) *dataplanev1.OpenStackDataPlaneDeployment {
+	deployment := &dataplanev1.OpenStackDataPlaneDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: dataplanev1.OpenStackDataPlaneDeploymentSpec{
+			NodeSets: []string{"edpm-compute"},
+		},
+	}
+	if len(readyReason) > 0 {
+		deployment.Status.Conditions.Set(condition.FalseCondition(
+			condition.ReadyCondition, readyReason, condition.SeverityError, "deployment error"))
+	}
+	return deployment
+}
+
+func TestGetDeploymentOutcome(t *testing.T) {
+	now := time.Now()
+	paused := newHistoryDeployment("paused", now, "")
+	paused.Status.Conditions.Set(condition.FalseCondition(
+		condition.ReadyCondition, dataplanev1.PausedReason, condition.SeverityInfo, "paused"))
+	deployed := newHistoryDeployment("deployed", now, "")
+	deployed.Status.Deployed = true
+	cancelled := newHistoryDeployment("cancelled", now, dataplanev1.CancelledReason)
+	cancelled.Status.Cancelled = true
+
+	tests := []struct {
+		name       string
+		deployment *dataplanev1.OpenStackDataPlaneDeployment
+		want       string
+	}{
+		{"running", newHistoryDeployment("running", now, ""), dataplanev1.DeploymentOutcomeRunning},
+		{"paused", paused, dataplanev1.DeploymentOutcomeRunning},
+		{"deployed", deployed, dataplanev1.DeploymentOutcomeSucceeded},
+		{"cancelled", cancelled, dataplanev1.DeploymentOutcomeCancelled},
+		{"failed", newHistoryDeployment("failed", now, condition.ErrorReason), dataplanev1.DeploymentOutcomeFailed},
+		{"timed out", newHistoryDeployment("timed-out", now, dataplanev1.TimedOutReason), dataplanev1.DeploymentOutcomeFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetDeploymentOutcome(tt.deployment); got != tt.want {
+				t.Errorf("GetDeploymentOutcome() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateDeploymentHistoryFailed(t *testing.T) {
+	nodeSet := &dataplanev1.OpenStackDataPlaneNodeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "edpm-compute"},
+		Spec: dataplanev1.OpenStackDataPlaneNodeSetSpec{
+			Services: []string{"configure-network", "install-os"},
+		},
+	}
+	deployment := newHistoryDeployment("edpm-deployment", time.Now(), "")
+
+	UpdateDeploymentHistory(nodeSet, deployment)
+	if len(nodeSet.Status.DeploymentHistory) != 1 {
+		t.Fatalf("got %d history entries, want 1", len(nodeSet.Status.DeploymentHistory))
+	}
+	entry := nodeSet.Status.DeploymentHistory[0]
+	if entry.Outcome != dataplanev1.DeploymentOutcomeRunning || entry.FinishTime != nil {
+		t.Errorf("running entry has outcome %s and finish time %v", entry.Outcome, entry.FinishTime)
+	}
+
+	deployment.Status.Conditions.Set(condition.FalseCondition(
+		condition.ReadyCondition, condition.ErrorReason, condition.SeverityError, "deployment error"))
+	deployment.Status.NodeSetHashes = map[string]string{"edpm-compute": "n5d8h"}
+	UpdateDeploymentHistory(nodeSet, deployment)
+	if len(nodeSet.Status.DeploymentHistory) != 1 {
+		t.Fatalf("got %d history entries, want 1", len(nodeSet.Status.DeploymentHistory))
+	}
+	entry = nodeSet.Status.DeploymentHistory[0]
+	if entry.Outcome != dataplanev1.DeploymentOutcomeFailed {
+		t.Errorf("got outcome %s, want %s", entry.Outcome, dataplanev1.DeploymentOutcomeFailed)
+	}
+	if entry.FinishTime == nil {
+		t.Fatal("failed entry has no finish time")
+	}
+	if entry.NodeSetHash != "n5d8h" {
+		t.Errorf("got NodeSet hash %q, want n5d8h", entry.NodeSetHash)
+	}
+
+	// A completed entry is not updated anymore
+	finishTime := *entry.FinishTime
+	deployment.Status.Conditions = condition.Conditions{}
+	deployment.Status.Deployed = true
+	UpdateDeploymentHistory(nodeSet, deployment)
+	entry = nodeSet.Status.DeploymentHistory[0]
+	if entry.Outcome != dataplanev1.DeploymentOutcomeFailed || !entry.FinishTime.Equal(&finishTime) {
+		t.Errorf("completed entry changed to outcome %s and finish time %v", entry.Outcome, entry.FinishTime)
+	}
+}
+
+func TestUpdateDeploymentHistoryLimit(t *testing.T) {
+	nodeSet := &dataplanev1.OpenStackDataPlaneNodeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "edpm-compute"},
+	}
+	start := time.Now().Add(-time.Hour)
+	// Recorded out of order, the history is sorted by start time
+	for idx := dataplanev1.DeploymentHistoryLimit; idx >= 0; idx-- {
+		deployment := newHistoryDeployment(fmt.Sprintf("deployment-%d", idx),
+			start.Add(time.Duration(idx)*time.Minute), condition.ErrorReason)
+		UpdateDeploymentHistory(nodeSet, deployment)
+	}
+
+	history := nodeSet.Status.DeploymentHistory
+	if len(history) != dataplanev1.DeploymentHistoryLimit {
+		t.Fatalf("got %d history entries, want %d", len(history), dataplanev1.DeploymentHistoryLimit)
+	}
+	// The oldest Deployment was dropped
+	for idx, entry := range history {
+		if want := fmt.Sprintf("deployment-%d", idx+1); entry.Deployment != want {
+			t.Errorf("history entry %d is %s, want %s", idx, entry.Deployment, want)
+		}
+	}
+
+	// A newer Deployment drops the oldest remaining entry
+	UpdateDeploymentHistory(nodeSet, newHistoryDeployment("deployment-new", time.Now(), ""))
+	history = nodeSet.Status.DeploymentHistory
+	if len(history) != dataplanev1.DeploymentHistoryLimit {
+		t.Fatalf("got %d history entries, want %d", len(history), dataplanev1.DeploymentHistoryLimit)
+	}
+	if history[0].Deployment != "deployment-2" || history[len(history)-1].Deployment != "deployment-new" {
+		t.Errorf("got history from %s to %s, want from deployment-2 to deployment-new",
+			history[0].Deployment, history[len(history)-1].Deployment)
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"sort"
+
+	"golang.org/x/exp/slices"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+)
+
+// GetBlockingDeployments - returns, by NodeSet of the Deployment, the names of
+// the Deployments still running on the NodeSet which started executing or are
+// older. A NodeSet is only deployed by one Deployment at a time, the
+// Deployments of a NodeSet are started in the order they were created.
+// Deployments waiting for approval do not hold the NodeSets, paused ones do.
+func GetBlockingDeployments(
+	ctx context.Context,
+	helper *helper.Helper,
+	instance *dataplanev1.OpenStackDataPlaneDeployment,
+) (map[string][]string, error) {
+	running, err := GetRunningDeployments(ctx, helper, instance.Namespace, instance.Spec.NodeSets)
+	if err != nil {
+		return nil, err
+	}
+
+	blocking := map[string][]string{}
+	for _, deployment := range running {
+		if deployment.Name == instance.Name || !deployment.IsApproved() {
+			continue
+		}
+		if deployment.Status.StartTime == nil && !deployment.DeploysBefore(*instance) {
+			continue
+		}
+		for _, nodeSet := range instance.Spec.NodeSets {
+			if slices.Contains(deployment.Spec.NodeSets, nodeSet) {
+				blocking[nodeSet] = append(blocking[nodeSet], deployment.Name)
+			}
+		}
+	}
+	for nodeSet := range blocking {
+		sort.Strings(blocking[nodeSet])
+	}
+
+	return blocking, nil
+}
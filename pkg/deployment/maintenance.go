@@ -0,0 +1,69 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"fmt"
+	"time"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+)
+
+// GetMaintenanceWindow - returns true if ansible executions may start on the
+// NodeSet at the given time. Otherwise, it also returns the start time of the
+// next maintenance window.
+func GetMaintenanceWindow(
+	nodeSet *dataplanev1.OpenStackDataPlaneNodeSet,
+	now time.Time,
+) (bool, time.Time, error) {
+	if len(nodeSet.Spec.MaintenanceWindows) == 0 {
+		return true, time.Time{}, nil
+	}
+
+	now = now.UTC()
+	var next time.Time
+	for _, window := range nodeSet.Spec.MaintenanceWindows {
+		schedule, err := dataplanev1.ParseCronSchedule(window.Schedule)
+		if err != nil {
+			return false, time.Time{}, fmt.Errorf("invalid maintenance window of NodeSet %s: %w", nodeSet.Name, err)
+		}
+		// The first start after now minus the duration is either the start
+		// of the current window, or of the next one
+		duration := time.Minute * time.Duration(window.DurationMinutes)
+		start := schedule.Next(now.Add(-duration))
+		if start.IsZero() {
+			continue
+		}
+		if !start.After(now) {
+			return true, time.Time{}, nil
+		}
+		if next.IsZero() || start.Before(next) {
+			next = start
+		}
+	}
+
+	if next.IsZero() {
+		return false, time.Time{}, fmt.Errorf("the maintenance windows of NodeSet %s never open", nodeSet.Name)
+	}
+	return false, next, nil
+}
+
+// waitingForMaintenanceWindow returns true when no ansible execution may start
+// on the NodeSet, outside of its maintenance windows
+func (d *Deployer) waitingForMaintenanceWindow() bool {
+	return !d.nextMaintenanceWindow.IsZero()
+}
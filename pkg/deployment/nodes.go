@@ -0,0 +1,215 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	baremetalv1 "github.com/openstack-k8s-operators/openstack-baremetal-operator/api/v1beta1"
+)
+
+// InitNodeStatuses - ensures the NodeSet status has an entry for each of its
+// nodes, and only for them, holding the IPs of the node
+func InitNodeStatuses(instance *dataplanev1.OpenStackDataPlaneNodeSet) {
+	nodes := make(map[string]dataplanev1.NodeStatus, len(instance.Spec.Nodes))
+	for _, node := range instance.Spec.Nodes {
+		nodeStatus := instance.Status.Nodes[node.HostName]
+		nodeStatus.IPs = instance.Status.AllIPs[node.HostName]
+		nodes[node.HostName] = nodeStatus
+	}
+	instance.Status.Nodes = nodes
+}
+
+// UpdateNodeProvisioningStates - records the provisioning state of the
+// baremetal host of each node of the NodeSet
+func UpdateNodeProvisioningStates(
+	instance *dataplanev1.OpenStackDataPlaneNodeSet,
+	baremetalSet *baremetalv1.OpenStackBaremetalSet,
+) {
+	for hostName, host := range baremetalSet.Status.BaremetalHosts {
+		nodeStatus, ok := instance.Status.Nodes[hostName]
+		if !ok {
+			continue
+		}
+		nodeStatus.ProvisioningState = string(host.ProvisioningState)
+		instance.Status.Nodes[hostName] = nodeStatus
+	}
+}
+
+// UpdateNodeDeploymentStatuses - records, in the status of each node of the
+// NodeSet, the last Deployment which succeeded on it and the last service
+// which failed on it. Only the results of the Deployments which finished since
+// the last update are read, so the state of a node is kept once its
+// Deployments are deleted. Check mode Deployments are ignored.
+func UpdateNodeDeploymentStatuses(
+	ctx context.Context,
+	helper *helper.Helper,
+	instance *dataplanev1.OpenStackDataPlaneNodeSet,
+) error {
+	deployments := &dataplanev1.OpenStackDataPlaneDeploymentList{}
+	err := helper.GetClient().List(ctx, deployments, client.InNamespace(instance.Namespace))
+	if err != nil {
+		return err
+	}
+
+	finished := []dataplanev1.OpenStackDataPlaneDeployment{}
+	for _, deployment := range deployments.Items {
+		if !deployment.DeletionTimestamp.IsZero() || deployment.IsCheckMode() ||
+			!slices.Contains(deployment.Spec.NodeSets, instance.Name) {
+			continue
+		}
+		finishTime := GetDeploymentFinishTime(&deployment)
+		if finishTime.IsZero() ||
+			(instance.Status.NodesDeploymentTime != nil && finishTime.Before(instance.Status.NodesDeploymentTime.Time)) {
+			continue
+		}
+		finished = append(finished, deployment)
+	}
+	sort.SliceStable(finished, func(i, j int) bool {
+		return GetDeploymentFinishTime(&finished[i]).Before(GetDeploymentFinishTime(&finished[j]))
+	})
+
+	// Results are recorded by inventory host, the short name of the node
+	hostNames := make(map[string]string, len(instance.Spec.Nodes))
+	for _, node := range instance.Spec.Nodes {
+		hostNames[strings.Split(node.HostName, ".")[0]] = node.HostName
+	}
+
+	for idx := range finished {
+		deployment := &finished[idx]
+		hostResults, err := getNodeSetHostResults(ctx, helper, deployment, instance.Name)
+		if err != nil {
+			return err
+		}
+		finishTime := metav1.NewTime(GetDeploymentFinishTime(deployment))
+
+		// Without readable results, a Deployment which succeeded without a
+		// limit deployed all the nodes
+		if len(hostResults) == 0 && deployment.Status.Deployed && len(deployment.Spec.AnsibleLimit) == 0 {
+			for host := range hostNames {
+				hostResults[host] = map[string]AnsibleHostResult{}
+			}
+		}
+
+		for host, services := range hostResults {
+			hostName, ok := hostNames[host]
+			if !ok {
+				continue
+			}
+			nodeStatus := instance.Status.Nodes[hostName]
+			failedService := getLastFailedService(deployment, instance.Name, services)
+			if len(failedService) > 0 {
+				nodeStatus.LastFailedService = failedService
+				nodeStatus.LastFailedDeployment = deployment.Name
+			} else if deployment.Status.Deployed {
+				nodeStatus.LastDeployment = deployment.Name
+				nodeStatus.LastDeploymentTime = &finishTime
+				nodeStatus.DeployedConfigHash = deployment.Status.NodeSetHashes[instance.Name]
+			}
+			if instance.Status.Nodes == nil {
+				instance.Status.Nodes = map[string]dataplanev1.NodeStatus{}
+			}
+			instance.Status.Nodes[hostName] = nodeStatus
+		}
+		instance.Status.NodesDeploymentTime = &finishTime
+	}
+	return nil
+}
+
+// getNodeSetHostResults returns the ansible results of each service on each
+// host of the NodeSet, from the results ConfigMap of the Deployment. Only the
+// last execution of a service recorded for a host is kept.
+func getNodeSetHostResults(
+	ctx context.Context,
+	helper *helper.Helper,
+	deployment *dataplanev1.OpenStackDataPlaneDeployment,
+	nodeSet string,
+) (map[string]map[string]AnsibleHostResult, error) {
+	hostResults := map[string]map[string]AnsibleHostResult{}
+	if len(deployment.Status.Results) == 0 {
+		return hostResults, nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+	err := helper.GetClient().Get(ctx, types.NamespacedName{
+		Name:      deployment.Status.Results,
+		Namespace: deployment.Namespace,
+	}, configMap)
+	if k8s_errors.IsNotFound(err) {
+		return hostResults, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	services := map[string]map[string]AnsibleExecutionResult{}
+	if err := json.Unmarshal([]byte(configMap.Data[nodeSet]), &services); err != nil {
+		helper.GetLogger().Info("Ignoring invalid Deployment results", "deployment", deployment.Name, "nodeSet", nodeSet, "error", err.Error())
+		return hostResults, nil
+	}
+	for service, executions := range services {
+		for _, execution := range executions {
+			for host, result := range execution.Hosts {
+				if hostResults[host] == nil {
+					hostResults[host] = map[string]AnsibleHostResult{}
+				}
+				hostResults[host][service] = result
+			}
+		}
+	}
+	return hostResults, nil
+}
+
+// getLastFailedService returns the service which failed, or was unreachable,
+// on a host the latest in the Deployment, empty when none failed
+func getLastFailedService(
+	deployment *dataplanev1.OpenStackDataPlaneDeployment,
+	nodeSet string,
+	services map[string]AnsibleHostResult,
+) string {
+	failedService := ""
+	var failedStart time.Time
+	for service, result := range services {
+		if result.Failed == 0 && result.Unreachable == 0 {
+			continue
+		}
+		var start time.Time
+		for _, attempt := range deployment.Status.NodeSetAttempts[nodeSet][service] {
+			if attempt.StartTime.After(start) {
+				start = attempt.StartTime.Time
+			}
+		}
+		if len(failedService) == 0 || start.After(failedStart) ||
+			(start.Equal(failedStart) && service > failedService) {
+			failedService = service
+			failedStart = start
+		}
+	}
+	return failedService
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	ansibleeev1 "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
+)
+
+// newNodesDeployment returns a finished Deployment of the edpm-compute
+// NodeSet, and its results ConfigMap holding the results of each host by
+// service
+func newNodesDeployment(
+	t *testing.T,
+	name string,
+	finishTime time.Time,
+	deployed bool,
+	hosts map[string]map[string]AnsibleHostResult,
+) (*dataplanev1.OpenStackDataPlaneDeployment, *corev1.ConfigMap) {
+	t.Helper()
+	finish := metav1.NewTime(finishTime)
+	deployment := &dataplanev1.OpenStackDataPlaneDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "openstack",
+		},
+		Spec: dataplanev1.OpenStackDataPlaneDeploymentSpec{
+			NodeSets: []string{"edpm-compute"},
+		},
+		Status: dataplanev1.OpenStackDataPlaneDeploymentStatus{
+			Deployed:      deployed,
+			FinishTime:    &finish,
+			Results:       name + "-results",
+			NodeSetHashes: map[string]string{"edpm-compute": name + "-hash"},
+		},
+	}
+	if !deployed {
+		deployment.Status.Conditions.Set(condition.FalseCondition(
+			condition.ReadyCondition, condition.ErrorReason, condition.SeverityError, "deployment error"))
+	}
+
+	services := map[string]map[string]AnsibleExecutionResult{}
+	for host, hostServices := range hosts {
+		for service, result := range hostServices {
+			if services[service] == nil {
+				services[service] = map[string]AnsibleExecutionResult{
+					name + "-" + service: {JobStatus: ansibleeev1.JobStatusSucceeded, Hosts: map[string]AnsibleHostResult{}},
+				}
+			}
+			execution := services[service][name+"-"+service]
+			if result.Failed > 0 || result.Unreachable > 0 {
+				execution.JobStatus = ansibleeev1.JobStatusFailed
+			}
+			execution.Hosts[host] = result
+			services[service][name+"-"+service] = execution
+		}
+		for service := range hostServices {
+			deployment.Status.NodeSetAttempts = addTestAttempt(deployment.Status.NodeSetAttempts, service, finishTime)
+		}
+	}
+	data, err := json.Marshal(services)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name + "-results",
+			Namespace: "openstack",
+		},
+		Data: map[string]string{"edpm-compute": string(data)},
+	}
+	return deployment, results
+}
+
+// testServiceOrder is the order the services of the tests run in
+var testServiceOrder = map[string]int{"configure-network": 0, "install-os": 1}
+
+func addTestAttempt(
+	attempts map[string]map[string][]dataplanev1.AnsibleExecutionAttempt,
+	service string,
+	finishTime time.Time,
+) map[string]map[string][]dataplanev1.AnsibleExecutionAttempt {
+	if attempts == nil {
+		attempts = map[string]map[string][]dataplanev1.AnsibleExecutionAttempt{"edpm-compute": {}}
+	}
+	start := finishTime.Add(-time.Hour).Add(time.Duration(testServiceOrder[service]) * time.Minute)
+	attempts["edpm-compute"][service] = []dataplanev1.AnsibleExecutionAttempt{{
+		Name:      service,
+		StartTime: metav1.NewTime(start),
+	}}
+	return attempts
+}
+
+func TestUpdateNodeDeploymentStatuses(t *testing.T) {
+	nodeSet := &dataplanev1.OpenStackDataPlaneNodeSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "edpm-compute",
+			Namespace: "openstack",
+		},
+		Spec: dataplanev1.OpenStackDataPlaneNodeSetSpec{
+			Nodes: map[string]dataplanev1.NodeSection{
+				"edpm-compute-0": {HostName: "edpm-compute-0.example.com"},
+				"edpm-compute-1": {HostName: "edpm-compute-1.example.com"},
+			},
+		},
+	}
+	InitNodeStatuses(nodeSet)
+
+	// Times are stored with a precision of a second
+	now := time.Now().Truncate(time.Second)
+	ok := AnsibleHostResult{AnsibleHostRecap: AnsibleHostRecap{Ok: 3}}
+	failed := AnsibleHostResult{AnsibleHostRecap: AnsibleHostRecap{Ok: 1, Failed: 1}, FailedTask: "Install packages"}
+	// edpm-compute-0 fails install-os, edpm-compute-1 is not reached
+	first, firstResults := newNodesDeployment(t, "first", now.Add(-2*time.Hour), false,
+		map[string]map[string]AnsibleHostResult{
+			"edpm-compute-0": {"configure-network": ok, "install-os": failed},
+			"edpm-compute-1": {"configure-network": ok, "install-os": {AnsibleHostRecap: AnsibleHostRecap{Unreachable: 1}}},
+		})
+	// The second Deployment succeeds on edpm-compute-1 only
+	second, secondResults := newNodesDeployment(t, "second", now.Add(-time.Hour), true,
+		map[string]map[string]AnsibleHostResult{
+			"edpm-compute-1": {"configure-network": ok, "install-os": ok},
+		})
+	second.Spec.AnsibleLimit = "edpm-compute-1"
+	// Running Deployments are ignored
+	running, runningResults := newNodesDeployment(t, "running", now, false,
+		map[string]map[string]AnsibleHostResult{
+			"edpm-compute-0": {"install-os": ok},
+		})
+	running.Status.Conditions = condition.Conditions{}
+	running.Status.FinishTime = nil
+
+	h := newTestHelper(t, nodeSet, first, firstResults, second, secondResults, running, runningResults)
+	if err := UpdateNodeDeploymentStatuses(context.Background(), h, nodeSet); err != nil {
+		t.Fatal(err)
+	}
+
+	failedNode := nodeSet.Status.Nodes["edpm-compute-0.example.com"]
+	if failedNode.LastFailedService != "install-os" || failedNode.LastFailedDeployment != "first" {
+		t.Errorf("edpm-compute-0 last failed %q in %q, want install-os in first",
+			failedNode.LastFailedService, failedNode.LastFailedDeployment)
+	}
+	if len(failedNode.LastDeployment) != 0 || failedNode.LastDeploymentTime != nil || len(failedNode.DeployedConfigHash) != 0 {
+		t.Errorf("edpm-compute-0 has last deployment %q, want none", failedNode.LastDeployment)
+	}
+
+	deployedNode := nodeSet.Status.Nodes["edpm-compute-1.example.com"]
+	if deployedNode.LastFailedService != "install-os" || deployedNode.LastFailedDeployment != "first" {
+		t.Errorf("edpm-compute-1 last failed %q in %q, want install-os in first",
+			deployedNode.LastFailedService, deployedNode.LastFailedDeployment)
+	}
+	if deployedNode.LastDeployment != "second" || deployedNode.DeployedConfigHash != "second-hash" {
+		t.Errorf("edpm-compute-1 last deployment %q with hash %q, want second with second-hash",
+			deployedNode.LastDeployment, deployedNode.DeployedConfigHash)
+	}
+	if deployedNode.LastDeploymentTime == nil || !deployedNode.LastDeploymentTime.Equal(second.Status.FinishTime) {
+		t.Errorf("edpm-compute-1 last deployment time %v, want %v",
+			deployedNode.LastDeploymentTime, second.Status.FinishTime)
+	}
+	if nodeSet.Status.NodesDeploymentTime == nil || !nodeSet.Status.NodesDeploymentTime.Equal(second.Status.FinishTime) {
+		t.Errorf("got nodes deployment time %v, want %v", nodeSet.Status.NodesDeploymentTime, second.Status.FinishTime)
+	}
+
+	// The state of the nodes is kept once the Deployments are deleted
+	h = newTestHelper(t, nodeSet)
+	if err := UpdateNodeDeploymentStatuses(context.Background(), h, nodeSet); err != nil {
+		t.Fatal(err)
+	}
+	if kept := nodeSet.Status.Nodes["edpm-compute-0.example.com"]; kept.LastFailedService != "install-os" ||
+		kept.LastFailedDeployment != "first" {
+		t.Errorf("edpm-compute-0 last failed %q in %q once the Deployments are deleted, want install-os in first",
+			kept.LastFailedService, kept.LastFailedDeployment)
+	}
+}
+
+func TestGetLastFailedService(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	deployment := &dataplanev1.OpenStackDataPlaneDeployment{
+		Status: dataplanev1.OpenStackDataPlaneDeploymentStatus{
+			NodeSetAttempts: map[string]map[string][]dataplanev1.AnsibleExecutionAttempt{
+				"edpm-compute": {
+					"configure-network": {{StartTime: metav1.NewTime(start)}},
+					"install-os":        {{StartTime: metav1.NewTime(start.Add(time.Minute))}},
+					"run-os":            {{StartTime: metav1.NewTime(start.Add(2 * time.Minute))}},
+				},
+			},
+		},
+	}
+	ok := AnsibleHostResult{AnsibleHostRecap: AnsibleHostRecap{Ok: 1}}
+	failed := AnsibleHostResult{AnsibleHostRecap: AnsibleHostRecap{Failed: 1}}
+	unreachable := AnsibleHostResult{AnsibleHostRecap: AnsibleHostRecap{Unreachable: 1}}
+
+	tests := []struct {
+		name     string
+		services map[string]AnsibleHostResult
+		want     string
+	}{
+		{"none failed", map[string]AnsibleHostResult{"configure-network": ok, "install-os": ok}, ""},
+		{"one failed", map[string]AnsibleHostResult{"configure-network": ok, "install-os": failed}, "install-os"},
+		{"unreachable", map[string]AnsibleHostResult{"configure-network": unreachable}, "configure-network"},
+		{"latest failed", map[string]AnsibleHostResult{"configure-network": failed, "run-os": failed, "install-os": failed}, "run-os"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getLastFailedService(deployment, "edpm-compute", tt.services); got != tt.want {
+				t.Errorf("getLastFailedService() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
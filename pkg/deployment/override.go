@@ -0,0 +1,57 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"encoding/json"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+)
+
+// applyServiceOverride sets the ansible options of the AnsibleEESpec for the
+// given service, from the original options and the ServiceOverrides of the
+// Deployment
+func (d *Deployer) applyServiceOverride(service string, original dataplanev1.AnsibleEESpec) {
+	d.AeeSpec.AnsibleTags = original.AnsibleTags
+	d.AeeSpec.AnsibleSkipTags = original.AnsibleSkipTags
+	d.AeeSpec.AnsibleLimit = original.AnsibleLimit
+	d.AeeSpec.ExtraVars = original.ExtraVars
+
+	override, ok := d.Deployment.Spec.ServiceOverrides[service]
+	if !ok {
+		return
+	}
+	if len(override.AnsibleTags) > 0 {
+		d.AeeSpec.AnsibleTags = override.AnsibleTags
+	}
+	if len(override.AnsibleSkipTags) > 0 {
+		d.AeeSpec.AnsibleSkipTags = override.AnsibleSkipTags
+	}
+	if len(override.AnsibleLimit) > 0 {
+		d.AeeSpec.AnsibleLimit = override.AnsibleLimit
+	}
+	if len(override.AnsibleExtraVars) > 0 {
+		extraVars := make(map[string]json.RawMessage, len(original.ExtraVars)+len(override.AnsibleExtraVars))
+		for name, value := range original.ExtraVars {
+			extraVars[name] = value
+		}
+		for name, value := range override.AnsibleExtraVars {
+			extraVars[name] = value
+		}
+		d.AeeSpec.ExtraVars = extraVars
+	}
+}
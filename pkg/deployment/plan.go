@@ -0,0 +1,94 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"fmt"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+)
+
+// GetDeploymentServices - returns the services the Deployment executes on the
+// NodeSet, its ServicesOverride when set, otherwise the Services of the NodeSet
+func GetDeploymentServices(
+	instance *dataplanev1.OpenStackDataPlaneDeployment,
+	nodeSet *dataplanev1.OpenStackDataPlaneNodeSet,
+) []string {
+	if len(instance.Spec.ServicesOverride) != 0 {
+		return instance.Spec.ServicesOverride
+	}
+	return nodeSet.Spec.Services
+}
+
+// GetDeploymentPlan - returns the plan of the Deployment: the services
+// executed on each NodeSet, in the order of the stages of its nodeSetStrategy
+// and of the service dependencies. The plan marks the global services, which
+// are executed only once, and the TLS certificates issued for the nodes.
+func GetDeploymentPlan(
+	ctx context.Context,
+	helper *helper.Helper,
+	instance *dataplanev1.OpenStackDataPlaneDeployment,
+	nodeSets []dataplanev1.OpenStackDataPlaneNodeSet,
+) (*dataplanev1.DeploymentPlan, error) {
+	plan := &dataplanev1.DeploymentPlan{}
+	foundServices := map[string]dataplanev1.OpenStackDataPlaneService{}
+	for stageIdx, stage := range GetNodeSetStages(instance, nodeSets) {
+		for _, nodeSet := range stage {
+			services := GetDeploymentServices(instance, &nodeSet)
+			for _, serviceName := range services {
+				if _, ok := foundServices[serviceName]; ok {
+					continue
+				}
+				service, err := GetService(ctx, helper, serviceName)
+				if err != nil {
+					return nil, fmt.Errorf("service %s of NodeSet %s: %w", serviceName, nodeSet.Name, err)
+				}
+				foundServices[serviceName] = service
+			}
+
+			dependencies, err := GetServiceDependencies(ctx, helper, services, foundServices)
+			if err != nil {
+				return nil, fmt.Errorf(dataplanev1.NodeSetServiceDependencyErrorMessage, err.Error())
+			}
+			orderedServices, err := SortServicesByDependencies(services, dependencies)
+			if err != nil {
+				return nil, fmt.Errorf(dataplanev1.NodeSetServiceDependencyErrorMessage, err.Error())
+			}
+
+			nodeSetPlan := dataplanev1.NodeSetPlan{
+				Name:     nodeSet.Name,
+				Stage:    stageIdx,
+				Services: make([]dataplanev1.ServicePlan, 0, len(orderedServices)),
+			}
+			for _, serviceName := range orderedServices {
+				service := foundServices[serviceName]
+				servicePlan := dataplanev1.ServicePlan{
+					Name:   serviceName,
+					Global: service.Spec.DeployOnAllNodeSets,
+				}
+				if nodeSet.Spec.TLSEnabled && service.Spec.TLSCert != nil {
+					servicePlan.TLSCert = service.Spec.TLSCert.DeepCopy()
+				}
+				nodeSetPlan.Services = append(nodeSetPlan.Services, servicePlan)
+			}
+			plan.NodeSets = append(plan.NodeSets, nodeSetPlan)
+		}
+	}
+	return plan, nil
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	ansibleeev1 "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
+)
+
+// serviceDurationHistoryLimit is the number of previous durations of a
+// service on a NodeSet averaged to estimate its next duration
+const serviceDurationHistoryLimit = 5
+
+// UpdateDeploymentProgress - records the progress of the Deployment on each
+// NodeSet of its plan: the services completed, the first service not
+// completed yet and, while the Deployment is running, the time it is expected
+// to finish at. The remaining services of a NodeSet are assumed to run one
+// after the other, and the stages of the nodeSetStrategy as well.
+func UpdateDeploymentProgress(
+	ctx context.Context,
+	helper *helper.Helper,
+	instance *dataplanev1.OpenStackDataPlaneDeployment,
+) error {
+	instance.Status.EstimatedFinishTime = nil
+	if instance.Status.Plan == nil {
+		instance.Status.Progress = ""
+		instance.Status.NodeSetProgress = nil
+		return nil
+	}
+
+	running := GetDeploymentOutcome(instance) == dataplanev1.DeploymentOutcomeRunning
+	var durations map[string]map[string]time.Duration
+	if running {
+		var err error
+		durations, err = getServiceDurations(ctx, helper, instance)
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	progress := make(map[string]dataplanev1.NodeSetProgress, len(instance.Status.Plan.NodeSets))
+	remaining := make(map[string]time.Duration, len(instance.Status.Plan.NodeSets))
+	stageRemaining := map[int]time.Duration{}
+	lastStage := 0
+	estimated := running
+	completed, total := 0, 0
+	for _, nodeSetPlan := range instance.Status.Plan.NodeSets {
+		nsConditions := instance.Status.NodeSetConditions[nodeSetPlan.Name]
+		nodeSetDeployed := nsConditions.IsTrue(dataplanev1.NodeSetDeploymentReadyCondition)
+		nodeSetProgress := dataplanev1.NodeSetProgress{TotalServices: len(nodeSetPlan.Services)}
+		for _, service := range nodeSetPlan.Services {
+			if nodeSetDeployed || nsConditions.IsTrue(GetServiceReadyCondition(service.Name)) {
+				nodeSetProgress.CompletedServices++
+				continue
+			}
+			if len(nodeSetProgress.CurrentService) == 0 {
+				nodeSetProgress.CurrentService = service.Name
+			}
+			if !running {
+				continue
+			}
+			duration, ok := durations[nodeSetPlan.Name][service.Name]
+			if !ok {
+				estimated = false
+				continue
+			}
+			// The time already spent on a started service is deducted
+			if start := getServiceStartTime(instance, nodeSetPlan.Name, service.Name); !start.IsZero() {
+				duration -= now.Sub(start)
+			}
+			if duration > 0 {
+				remaining[nodeSetPlan.Name] += duration
+			}
+		}
+		progress[nodeSetPlan.Name] = nodeSetProgress
+		completed += nodeSetProgress.CompletedServices
+		total += nodeSetProgress.TotalServices
+		if remaining[nodeSetPlan.Name] > stageRemaining[nodeSetPlan.Stage] {
+			stageRemaining[nodeSetPlan.Stage] = remaining[nodeSetPlan.Name]
+		}
+		if nodeSetPlan.Stage > lastStage {
+			lastStage = nodeSetPlan.Stage
+		}
+	}
+
+	if estimated {
+		// A stage starts once the previous ones are deployed
+		stageStart := make(map[int]time.Time, lastStage+1)
+		start := now
+		for stage := 0; stage <= lastStage; stage++ {
+			stageStart[stage] = start
+			start = start.Add(stageRemaining[stage])
+		}
+		for _, nodeSetPlan := range instance.Status.Plan.NodeSets {
+			nodeSetProgress := progress[nodeSetPlan.Name]
+			finishTime := metav1.NewTime(stageStart[nodeSetPlan.Stage].Add(remaining[nodeSetPlan.Name]))
+			nodeSetProgress.EstimatedFinishTime = &finishTime
+			progress[nodeSetPlan.Name] = nodeSetProgress
+		}
+		finishTime := metav1.NewTime(start)
+		instance.Status.EstimatedFinishTime = &finishTime
+	}
+
+	instance.Status.NodeSetProgress = progress
+	instance.Status.Progress = fmt.Sprintf("%d/%d", completed, total)
+	return nil
+}
+
+// getServiceStartTime returns the time the first attempt of the service was
+// started on the NodeSet in the current run of the Deployment, zero if none
+func getServiceStartTime(
+	instance *dataplanev1.OpenStackDataPlaneDeployment,
+	nodeSet string,
+	service string,
+) time.Time {
+	var start time.Time
+	for _, attempt := range instance.Status.NodeSetAttempts[nodeSet][service] {
+		if start.IsZero() || attempt.StartTime.Time.Before(start) {
+			start = attempt.StartTime.Time
+		}
+	}
+	return start
+}
+
+// getServiceDurations returns the estimated duration of each service, by
+// NodeSet: the average of its last durations in the other Deployments of the
+// same mode. The duration of a service runs from the start of its first
+// attempt to the finish of its last one, and is only recorded when the last
+// attempt succeeded.
+func getServiceDurations(
+	ctx context.Context,
+	helper *helper.Helper,
+	instance *dataplanev1.OpenStackDataPlaneDeployment,
+) (map[string]map[string]time.Duration, error) {
+	deployments := &dataplanev1.OpenStackDataPlaneDeploymentList{}
+	err := helper.GetClient().List(ctx, deployments, client.InNamespace(instance.Namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	type serviceDuration struct {
+		duration   time.Duration
+		finishTime time.Time
+	}
+	history := map[string]map[string][]serviceDuration{}
+	for _, deployment := range deployments.Items {
+		if deployment.Name == instance.Name || deployment.IsCheckMode() != instance.IsCheckMode() {
+			continue
+		}
+		for nodeSet, services := range deployment.Status.NodeSetAttempts {
+			if !planHasNodeSet(instance.Status.Plan, nodeSet) {
+				continue
+			}
+			for service, attempts := range services {
+				if len(attempts) == 0 {
+					continue
+				}
+				last := attempts[len(attempts)-1]
+				if last.JobStatus != ansibleeev1.JobStatusSucceeded || last.FinishTime == nil {
+					continue
+				}
+				start := attempts[0].StartTime.Time
+				for _, attempt := range attempts {
+					if attempt.StartTime.Time.Before(start) {
+						start = attempt.StartTime.Time
+					}
+				}
+				if history[nodeSet] == nil {
+					history[nodeSet] = map[string][]serviceDuration{}
+				}
+				history[nodeSet][service] = append(history[nodeSet][service], serviceDuration{
+					duration:   last.FinishTime.Sub(start),
+					finishTime: last.FinishTime.Time,
+				})
+			}
+		}
+	}
+
+	durations := make(map[string]map[string]time.Duration, len(history))
+	for nodeSet, services := range history {
+		durations[nodeSet] = make(map[string]time.Duration, len(services))
+		for service, serviceDurations := range services {
+			sort.Slice(serviceDurations, func(i, j int) bool {
+				return serviceDurations[j].finishTime.Before(serviceDurations[i].finishTime)
+			})
+			if len(serviceDurations) > serviceDurationHistoryLimit {
+				serviceDurations = serviceDurations[:serviceDurationHistoryLimit]
+			}
+			var sum time.Duration
+			for _, serviceDuration := range serviceDurations {
+				sum += serviceDuration.duration
+			}
+			durations[nodeSet][service] = sum / time.Duration(len(serviceDurations))
+		}
+	}
+	return durations, nil
+}
+
+// planHasNodeSet returns true when the NodeSet is part of the plan
+func planHasNodeSet(plan *dataplanev1.DeploymentPlan, nodeSet string) bool {
+	for _, nodeSetPlan := range plan.NodeSets {
+		if nodeSetPlan.Name == nodeSet {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	ansibleeev1 "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
+)
+
+// newProgressAttempt returns a succeeded attempt which ran for the duration
+func newProgressAttempt(start time.Time, duration time.Duration) dataplanev1.AnsibleExecutionAttempt {
+	finish := metav1.NewTime(start.Add(duration))
+	return dataplanev1.AnsibleExecutionAttempt{
+		JobStatus:  ansibleeev1.JobStatusSucceeded,
+		StartTime:  metav1.NewTime(start),
+		FinishTime: &finish,
+	}
+}
+
+func TestUpdateDeploymentProgress(t *testing.T) {
+	// Times are stored with a precision of a second
+	start := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	previous := []*dataplanev1.OpenStackDataPlaneDeployment{}
+	// configure-network took 10 then 20 minutes on the compute NodeSet, and
+	// install-os 20 minutes on both runs, the second one after a failed
+	// attempt
+	for idx, networkDuration := range []time.Duration{10 * time.Minute, 20 * time.Minute} {
+		runStart := start.Add(time.Duration(idx) * time.Hour)
+		failed := newProgressAttempt(runStart.Add(networkDuration), 5*time.Minute)
+		failed.JobStatus = ansibleeev1.JobStatusFailed
+		installOS := []dataplanev1.AnsibleExecutionAttempt{newProgressAttempt(runStart.Add(networkDuration), 20*time.Minute)}
+		if idx == 1 {
+			installOS = []dataplanev1.AnsibleExecutionAttempt{failed, newProgressAttempt(runStart.Add(networkDuration+5*time.Minute), 15*time.Minute)}
+		}
+		previous = append(previous, &dataplanev1.OpenStackDataPlaneDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      []string{"first", "second"}[idx],
+				Namespace: "openstack",
+			},
+			Status: dataplanev1.OpenStackDataPlaneDeploymentStatus{
+				NodeSetAttempts: map[string]map[string][]dataplanev1.AnsibleExecutionAttempt{
+					"edpm-compute": {
+						"configure-network": {newProgressAttempt(runStart, networkDuration)},
+						"install-os":        installOS,
+					},
+					"edpm-networker": {
+						"configure-network": {newProgressAttempt(runStart, 10*time.Minute)},
+					},
+				},
+			},
+		})
+	}
+	// Check mode durations are not used for a Deploy mode Deployment
+	check := &dataplanev1.OpenStackDataPlaneDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "check",
+			Namespace: "openstack",
+		},
+		Spec: dataplanev1.OpenStackDataPlaneDeploymentSpec{
+			Mode: dataplanev1.DeploymentModeCheck,
+		},
+		Status: dataplanev1.OpenStackDataPlaneDeploymentStatus{
+			NodeSetAttempts: map[string]map[string][]dataplanev1.AnsibleExecutionAttempt{
+				"edpm-compute": {
+					"configure-network": {newProgressAttempt(start, time.Minute)},
+					"install-os":        {newProgressAttempt(start, time.Minute)},
+				},
+			},
+		},
+	}
+
+	// configure-network is done on the compute NodeSet, install-os started 5
+	// minutes ago. The networker NodeSet is deployed once the compute one is.
+	now := time.Now()
+	computeConditions := condition.Conditions{}
+	computeConditions.Set(condition.TrueCondition(GetServiceReadyCondition("configure-network"), "ready"))
+	instance := &dataplanev1.OpenStackDataPlaneDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "current",
+			Namespace: "openstack",
+		},
+		Status: dataplanev1.OpenStackDataPlaneDeploymentStatus{
+			Plan: &dataplanev1.DeploymentPlan{
+				NodeSets: []dataplanev1.NodeSetPlan{
+					{
+						Name:     "edpm-compute",
+						Stage:    0,
+						Services: []dataplanev1.ServicePlan{{Name: "configure-network"}, {Name: "install-os"}},
+					},
+					{
+						Name:     "edpm-networker",
+						Stage:    1,
+						Services: []dataplanev1.ServicePlan{{Name: "configure-network"}},
+					},
+				},
+			},
+			NodeSetConditions: map[string]condition.Conditions{
+				"edpm-compute": computeConditions,
+			},
+			NodeSetAttempts: map[string]map[string][]dataplanev1.AnsibleExecutionAttempt{
+				"edpm-compute": {
+					"install-os": {{StartTime: metav1.NewTime(now.Add(-5 * time.Minute))}},
+				},
+			},
+		},
+	}
+
+	h := newTestHelper(t, instance, previous[0], previous[1], check)
+	if err := UpdateDeploymentProgress(context.Background(), h, instance); err != nil {
+		t.Fatal(err)
+	}
+	after := time.Now()
+
+	if instance.Status.Progress != "1/3" {
+		t.Errorf("got progress %s, want 1/3", instance.Status.Progress)
+	}
+	compute := instance.Status.NodeSetProgress["edpm-compute"]
+	if compute.CompletedServices != 1 || compute.TotalServices != 2 || compute.CurrentService != "install-os" {
+		t.Errorf("got compute progress %+v, want install-os after 1 of 2 services", compute)
+	}
+	networker := instance.Status.NodeSetProgress["edpm-networker"]
+	if networker.CompletedServices != 0 || networker.TotalServices != 1 || networker.CurrentService != "configure-network" {
+		t.Errorf("got networker progress %+v, want configure-network after 0 of 1 services", networker)
+	}
+
+	// install-os takes 20 minutes on average, 15 minutes remain on the
+	// compute NodeSet, then 10 minutes of configure-network on the networker
+	// NodeSet
+	expectEstimate := func(name string, estimate *metav1.Time, remaining time.Duration) {
+		t.Helper()
+		if estimate == nil {
+			t.Errorf("%s has no estimated finish time", name)
+			return
+		}
+		if estimate.Time.Before(now.Add(remaining)) || estimate.Time.After(after.Add(remaining)) {
+			t.Errorf("%s estimated to finish at %s, want %s from now", name, estimate.Time, remaining)
+		}
+	}
+	expectEstimate("edpm-compute", compute.EstimatedFinishTime, 15*time.Minute)
+	expectEstimate("edpm-networker", networker.EstimatedFinishTime, 25*time.Minute)
+	expectEstimate("deployment", instance.Status.EstimatedFinishTime, 25*time.Minute)
+
+	// Without any duration for a remaining service, nothing is estimated
+	delete(instance.Status.NodeSetAttempts, "edpm-compute")
+	instance.Status.Plan.NodeSets[1].Services = append(instance.Status.Plan.NodeSets[1].Services,
+		dataplanev1.ServicePlan{Name: "run-os"})
+	if err := UpdateDeploymentProgress(context.Background(), h, instance); err != nil {
+		t.Fatal(err)
+	}
+	if instance.Status.Progress != "1/4" {
+		t.Errorf("got progress %s, want 1/4", instance.Status.Progress)
+	}
+	if instance.Status.EstimatedFinishTime != nil || instance.Status.NodeSetProgress["edpm-compute"].EstimatedFinishTime != nil {
+		t.Errorf("got estimated finish time %v, want none", instance.Status.EstimatedFinishTime)
+	}
+
+	// A finished Deployment has no estimated finish time
+	instance.Status.Plan.NodeSets[1].Services = instance.Status.Plan.NodeSets[1].Services[:1]
+	instance.Status.Deployed = true
+	for _, nodeSet := range []string{"edpm-compute", "edpm-networker"} {
+		nsConditions := instance.Status.NodeSetConditions[nodeSet]
+		nsConditions.Set(condition.TrueCondition(dataplanev1.NodeSetDeploymentReadyCondition, "ready"))
+		instance.Status.NodeSetConditions[nodeSet] = nsConditions
+	}
+	if err := UpdateDeploymentProgress(context.Background(), h, instance); err != nil {
+		t.Fatal(err)
+	}
+	if instance.Status.Progress != "3/3" || instance.Status.EstimatedFinishTime != nil {
+		t.Errorf("got progress %s, estimated finish time %v, want 3/3 and none",
+			instance.Status.Progress, instance.Status.EstimatedFinishTime)
+	}
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	dataplaneutil "github.com/openstack-k8s-operators/dataplane-operator/pkg/util"
+	ansibleeev1 "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
+)
+
+const (
+	// QueuedRequeueInterval is the delay before checking again whether a
+	// queued ansible execution can be started
+	QueuedRequeueInterval = 10 * time.Second
+
+	// queuedExecutionExpiry is the time after which a queued execution which
+	// was not checked again is removed from the queue, e.g. because its
+	// Deployment was deleted
+	queuedExecutionExpiry = 6 * QueuedRequeueInterval
+
+	// startedExecutionExpiry is the time a started execution is counted as
+	// running while it is not listed yet by the cached client
+	startedExecutionExpiry = time.Minute
+)
+
+// ExecutionQueue limits the number of OpenStackAnsibleEE executions of the
+// Deployments running at the same time. Once a limit is reached, the
+// executions are queued and started as the running ones finish. The queue is
+// fair between Deployments: the first queued execution of every Deployment is
+// started before the second one of any Deployment, and so on.
+type ExecutionQueue struct {
+	lock sync.Mutex
+	// maxExecutions is the maximum number of executions running at the same
+	// time, 0 for no limit
+	maxExecutions int
+	// maxExecutionsPerNamespace is the maximum number of executions running
+	// at the same time in a namespace, 0 for no limit
+	maxExecutionsPerNamespace int
+	// queued are the executions waiting to be started, by namespace and name
+	queued map[string]*queuedExecution
+	// started are the executions started recently, by namespace and name
+	started map[string]startedExecution
+}
+
+// queuedExecution is an execution waiting in the ExecutionQueue
+type queuedExecution struct {
+	key        string
+	namespace  string
+	deployment string
+	queuedAt   time.Time
+	lastSeen   time.Time
+}
+
+// startedExecution is an execution recently started by the ExecutionQueue
+type startedExecution struct {
+	namespace string
+	startedAt time.Time
+}
+
+// NewExecutionQueue - returns an ExecutionQueue with the given limits, 0 for
+// no limit
+func NewExecutionQueue(maxExecutions int, maxExecutionsPerNamespace int) *ExecutionQueue {
+	return &ExecutionQueue{
+		maxExecutions:             maxExecutions,
+		maxExecutionsPerNamespace: maxExecutionsPerNamespace,
+		queued:                    map[string]*queuedExecution{},
+		started:                   map[string]startedExecution{},
+	}
+}
+
+// SetLimits - updates the limits of the ExecutionQueue, 0 for no limit
+func (q *ExecutionQueue) SetLimits(maxExecutions int, maxExecutionsPerNamespace int) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.maxExecutions = maxExecutions
+	q.maxExecutionsPerNamespace = maxExecutionsPerNamespace
+}
+
+// Admit - returns true when the named execution of the Deployment may be
+// started. Otherwise the execution is queued, and its position in the queue
+// is returned. Admit must be called again, at least every
+// QueuedRequeueInterval, until the execution is admitted.
+func (q *ExecutionQueue) Admit(
+	ctx context.Context,
+	c client.Client,
+	deployment *dataplanev1.OpenStackDataPlaneDeployment,
+	name string,
+) (bool, int, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.maxExecutions <= 0 && q.maxExecutionsPerNamespace <= 0 {
+		return true, 0, nil
+	}
+
+	// Count the running executions of all Deployments
+	ansibleEEs := &ansibleeev1.OpenStackAnsibleEEList{}
+	err := c.List(ctx, ansibleEEs, client.HasLabels{"openstackdataplanedeployment"})
+	if err != nil {
+		return false, 0, err
+	}
+	now := time.Now()
+	key := client.ObjectKey{Namespace: deployment.Namespace, Name: name}.String()
+	running := 0
+	runningPerNamespace := map[string]int{}
+	listed := make(map[string]bool, len(ansibleEEs.Items))
+	for idx := range ansibleEEs.Items {
+		ansibleEE := &ansibleEEs.Items[idx]
+		listed[client.ObjectKeyFromObject(ansibleEE).String()] = true
+		if ansibleEE.DeletionTimestamp != nil ||
+			ansibleEE.Status.JobStatus == ansibleeev1.JobStatusSucceeded ||
+			ansibleEE.Status.JobStatus == ansibleeev1.JobStatusFailed {
+			continue
+		}
+		running++
+		runningPerNamespace[ansibleEE.Namespace]++
+	}
+	if listed[key] {
+		delete(q.queued, key)
+		return true, 0, nil
+	}
+	// The executions started recently might not be listed yet
+	for startedKey, execution := range q.started {
+		if listed[startedKey] || now.Sub(execution.startedAt) > startedExecutionExpiry {
+			delete(q.started, startedKey)
+			continue
+		}
+		running++
+		runningPerNamespace[execution.namespace]++
+	}
+
+	// Queue the execution, or refresh it, and forget the executions which
+	// were not checked again
+	if execution, ok := q.queued[key]; ok {
+		execution.lastSeen = now
+	} else {
+		q.queued[key] = &queuedExecution{
+			key:        key,
+			namespace:  deployment.Namespace,
+			deployment: deployment.Name,
+			queuedAt:   now,
+			lastSeen:   now,
+		}
+	}
+	for queuedKey, execution := range q.queued {
+		if now.Sub(execution.lastSeen) > queuedExecutionExpiry {
+			delete(q.queued, queuedKey)
+		}
+	}
+
+	// Hand the free slots over to the queued executions in a fair order
+	position := 0
+	for _, execution := range q.fairOrder() {
+		free := q.maxExecutions <= 0 || running < q.maxExecutions
+		freeInNamespace := q.maxExecutionsPerNamespace <= 0 ||
+			runningPerNamespace[execution.namespace] < q.maxExecutionsPerNamespace
+		if free && freeInNamespace {
+			if execution.key == key {
+				delete(q.queued, key)
+				q.started[key] = startedExecution{namespace: execution.namespace, startedAt: now}
+				return true, 0, nil
+			}
+			// The slot is kept for the execution ahead in the queue
+			running++
+			runningPerNamespace[execution.namespace]++
+			continue
+		}
+		position++
+		if execution.key == key {
+			break
+		}
+	}
+
+	return false, position, nil
+}
+
+// fairOrder returns the queued executions in the order they are given the
+// free slots: by their rank in the queue of their Deployment, then by the
+// time they were queued
+func (q *ExecutionQueue) fairOrder() []*queuedExecution {
+	byDeployment := map[string][]*queuedExecution{}
+	for _, execution := range q.queued {
+		deploymentKey := client.ObjectKey{Namespace: execution.namespace, Name: execution.deployment}.String()
+		byDeployment[deploymentKey] = append(byDeployment[deploymentKey], execution)
+	}
+
+	rank := make(map[string]int, len(q.queued))
+	ordered := make([]*queuedExecution, 0, len(q.queued))
+	for _, executions := range byDeployment {
+		sortQueuedExecutions(executions)
+		for idx, execution := range executions {
+			rank[execution.key] = idx
+		}
+		ordered = append(ordered, executions...)
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if rank[ordered[i].key] != rank[ordered[j].key] {
+			return rank[ordered[i].key] < rank[ordered[j].key]
+		}
+		return ordered[i].queuedAt.Before(ordered[j].queuedAt) ||
+			(ordered[i].queuedAt.Equal(ordered[j].queuedAt) && ordered[i].key < ordered[j].key)
+	})
+	return ordered
+}
+
+// sortQueuedExecutions sorts the executions by the time they were queued
+func sortQueuedExecutions(executions []*queuedExecution) {
+	sort.Slice(executions, func(i, j int) bool {
+		if executions[i].queuedAt.Equal(executions[j].queuedAt) {
+			return executions[i].key < executions[j].key
+		}
+		return executions[i].queuedAt.Before(executions[j].queuedAt)
+	})
+}
+
+// queueExecution returns true, with its position in the queue, when the given
+// attempt of the execution of the service can not be started yet because of
+// the limits of the ExecutionQueue
+func (d *Deployer) queueExecution(service dataplanev1.OpenStackDataPlaneService, attempt int) (bool, int, error) {
+	if d.ExecutionQueue == nil {
+		return false, 0, nil
+	}
+	name, labels := dataplaneutil.GetAnsibleExecutionDeploymentNameAndLabels(&service, d.Deployment, d.NodeSet.Name, d.Batch)
+	if attempt > 1 {
+		name, _ = dataplaneutil.GetAnsibleExecutionAttemptNameAndLabels(name, labels, attempt)
+	}
+	admitted, position, err := d.ExecutionQueue.Admit(d.Ctx, d.Helper.GetClient(), d.Deployment, name)
+	if err != nil || admitted {
+		return false, 0, err
+	}
+	d.setRequeueAfter(QueuedRequeueInterval)
+	return true, position, nil
+}
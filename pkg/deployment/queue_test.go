@@ -0,0 +1,194 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	ansibleeev1 "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
+)
+
+func newQueueDeployment(namespace string, name string) *dataplanev1.OpenStackDataPlaneDeployment {
+	return &dataplanev1.OpenStackDataPlaneDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+	}
+}
+
+func newQueueAnsibleEE(namespace string, name string, jobStatus string) *ansibleeev1.OpenStackAnsibleEE {
+	return &ansibleeev1.OpenStackAnsibleEE{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"openstackdataplanedeployment": "edpm-deployment"},
+		},
+		Status: ansibleeev1.OpenStackAnsibleEEStatus{JobStatus: jobStatus},
+	}
+}
+
+// expectAdmit checks the result of admitting the named execution
+func expectAdmit(
+	t *testing.T,
+	q *ExecutionQueue,
+	c client.Client,
+	deployment *dataplanev1.OpenStackDataPlaneDeployment,
+	name string,
+	wantAdmitted bool,
+	wantPosition int,
+) {
+	t.Helper()
+	admitted, position, err := q.Admit(context.Background(), c, deployment, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if admitted != wantAdmitted || position != wantPosition {
+		t.Errorf("Admit(%s) = %t, %d, want %t, %d", name, admitted, position, wantAdmitted, wantPosition)
+	}
+}
+
+func TestExecutionQueueNoLimit(t *testing.T) {
+	deployment := newQueueDeployment("openstack", "edpm-deployment")
+	c := newTestHelper(t, deployment,
+		newQueueAnsibleEE("openstack", "running", ansibleeev1.JobStatusRunning)).GetClient()
+	q := NewExecutionQueue(0, 0)
+
+	for _, name := range []string{"first", "second", "third"} {
+		expectAdmit(t, q, c, deployment, name, true, 0)
+	}
+}
+
+func TestExecutionQueueGlobalLimit(t *testing.T) {
+	first := newQueueDeployment("openstack", "first-deployment")
+	second := newQueueDeployment("other", "second-deployment")
+	running := newQueueAnsibleEE("openstack", "running", ansibleeev1.JobStatusRunning)
+	c := newTestHelper(t, first, second, running,
+		newQueueAnsibleEE("openstack", "succeeded", ansibleeev1.JobStatusSucceeded),
+		newQueueAnsibleEE("other", "failed", ansibleeev1.JobStatusFailed)).GetClient()
+	q := NewExecutionQueue(2, 0)
+
+	// The finished executions are not counted
+	expectAdmit(t, q, c, first, "first-1", true, 0)
+	// The execution just admitted is counted even though it is not listed
+	expectAdmit(t, q, c, first, "first-2", false, 1)
+	expectAdmit(t, q, c, second, "second-1", false, 2)
+	// An execution which already exists is not limited
+	expectAdmit(t, q, c, first, "running", true, 0)
+
+	// The slot freed is kept for the execution queued first
+	if err := c.Delete(context.Background(), running); err != nil {
+		t.Fatal(err)
+	}
+	expectAdmit(t, q, c, second, "second-1", false, 1)
+	expectAdmit(t, q, c, first, "first-2", true, 0)
+	expectAdmit(t, q, c, second, "second-1", false, 1)
+
+	// Without limit, the queued executions are admitted
+	q.SetLimits(0, 0)
+	expectAdmit(t, q, c, second, "second-1", true, 0)
+}
+
+func TestExecutionQueueNamespaceLimit(t *testing.T) {
+	first := newQueueDeployment("openstack", "first-deployment")
+	second := newQueueDeployment("other", "second-deployment")
+	c := newTestHelper(t, first, second,
+		newQueueAnsibleEE("openstack", "running", ansibleeev1.JobStatusRunning)).GetClient()
+	q := NewExecutionQueue(0, 1)
+
+	expectAdmit(t, q, c, first, "first-1", false, 1)
+	// The executions of another namespace do not wait
+	expectAdmit(t, q, c, second, "second-1", true, 0)
+	// The position counts the executions waiting in all the namespaces
+	expectAdmit(t, q, c, second, "second-2", false, 2)
+	expectAdmit(t, q, c, first, "first-1", false, 1)
+
+	// The global limit applies to all the namespaces
+	q.SetLimits(2, 2)
+	expectAdmit(t, q, c, first, "first-1", false, 1)
+	q.SetLimits(3, 2)
+	expectAdmit(t, q, c, first, "first-1", true, 0)
+}
+
+func TestExecutionQueueFairOrder(t *testing.T) {
+	queuedAt := time.Now()
+	q := NewExecutionQueue(1, 0)
+	for idx, execution := range []struct {
+		key        string
+		namespace  string
+		deployment string
+	}{
+		{"openstack/first-1", "openstack", "first-deployment"},
+		{"openstack/first-2", "openstack", "first-deployment"},
+		{"openstack/first-3", "openstack", "first-deployment"},
+		{"openstack/second-1", "openstack", "second-deployment"},
+		{"other/third-1", "other", "third-deployment"},
+		{"openstack/second-2", "openstack", "second-deployment"},
+	} {
+		q.queued[execution.key] = &queuedExecution{
+			key:        execution.key,
+			namespace:  execution.namespace,
+			deployment: execution.deployment,
+			queuedAt:   queuedAt.Add(time.Duration(idx) * time.Second),
+			lastSeen:   queuedAt,
+		}
+	}
+
+	want := []string{
+		"openstack/first-1", "openstack/second-1", "other/third-1",
+		"openstack/first-2", "openstack/second-2",
+		"openstack/first-3",
+	}
+	ordered := q.fairOrder()
+	if len(ordered) != len(want) {
+		t.Fatalf("got %d executions, want %d", len(ordered), len(want))
+	}
+	for idx, execution := range ordered {
+		if execution.key != want[idx] {
+			t.Errorf("got %s at position %d, want %s", execution.key, idx, want[idx])
+		}
+	}
+}
+
+func TestExecutionQueueFairness(t *testing.T) {
+	first := newQueueDeployment("openstack", "first-deployment")
+	second := newQueueDeployment("openstack", "second-deployment")
+	running := newQueueAnsibleEE("openstack", "running", ansibleeev1.JobStatusRunning)
+	c := newTestHelper(t, first, second, running).GetClient()
+	q := NewExecutionQueue(1, 0)
+
+	// The second Deployment queues its first execution after all the ones
+	// of the first Deployment, and is still started second
+	expectAdmit(t, q, c, first, "first-1", false, 1)
+	expectAdmit(t, q, c, first, "first-2", false, 2)
+	expectAdmit(t, q, c, first, "first-3", false, 3)
+	expectAdmit(t, q, c, second, "second-1", false, 2)
+	expectAdmit(t, q, c, first, "first-3", false, 4)
+
+	if err := c.Delete(context.Background(), running); err != nil {
+		t.Fatal(err)
+	}
+	expectAdmit(t, q, c, first, "first-1", true, 0)
+	// first-1 is counted as running while it is not listed, and first-2 is
+	// now the first execution of its Deployment, queued before second-1
+	expectAdmit(t, q, c, first, "first-2", false, 1)
+	expectAdmit(t, q, c, second, "second-1", false, 2)
+	expectAdmit(t, q, c, first, "first-3", false, 3)
+}
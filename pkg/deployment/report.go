@@ -0,0 +1,297 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	dataplaneutil "github.com/openstack-k8s-operators/dataplane-operator/pkg/util"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	ansibleeev1 "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
+)
+
+// ansibleOutputTailLines is the number of lines read from the end of the
+// output of an ansible execution, which holds the PLAY RECAP
+const ansibleOutputTailLines int64 = 5000
+
+var (
+	recapHostRegex  = regexp.MustCompile(`^\s*(\S+)\s+:\s+(.*\w+=\d+.*)$`)
+	recapCountRegex = regexp.MustCompile(`(\w+)=(\d+)`)
+	taskRegex       = regexp.MustCompile(`^(?:TASK|RUNNING HANDLER) \[(.*)\] \*+`)
+	failedHostRegex = regexp.MustCompile(`^(?:fatal|failed): \[([^\]]+)\]`)
+)
+
+// AnsibleHostRecap - task counts of the PLAY RECAP of an ansible run for a host
+type AnsibleHostRecap struct {
+	Ok          int `json:"ok"`
+	Changed     int `json:"changed"`
+	Unreachable int `json:"unreachable"`
+	Failed      int `json:"failed"`
+	Skipped     int `json:"skipped"`
+	Rescued     int `json:"rescued"`
+	Ignored     int `json:"ignored"`
+}
+
+// add sums the counts of another recap of the same host
+func (r *AnsibleHostRecap) add(other AnsibleHostRecap) {
+	r.Ok += other.Ok
+	r.Changed += other.Changed
+	r.Unreachable += other.Unreachable
+	r.Failed += other.Failed
+	r.Skipped += other.Skipped
+	r.Rescued += other.Rescued
+	r.Ignored += other.Ignored
+}
+
+// ParseAnsibleRecap - returns the PLAY RECAP task counts by host found in the
+// output of an ansible run. The counts of several recaps are summed.
+func ParseAnsibleRecap(output string) map[string]AnsibleHostRecap {
+	recaps := map[string]AnsibleHostRecap{}
+	inRecap := false
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, "PLAY RECAP") {
+			inRecap = true
+			continue
+		}
+		if !inRecap {
+			continue
+		}
+		match := recapHostRegex.FindStringSubmatch(line)
+		if match == nil {
+			inRecap = false
+			continue
+		}
+
+		recap := AnsibleHostRecap{}
+		for _, count := range recapCountRegex.FindAllStringSubmatch(match[2], -1) {
+			value, _ := strconv.Atoi(count[2])
+			switch count[1] {
+			case "ok":
+				recap.Ok = value
+			case "changed":
+				recap.Changed = value
+			case "unreachable":
+				recap.Unreachable = value
+			case "failed":
+				recap.Failed = value
+			case "skipped":
+				recap.Skipped = value
+			case "rescued":
+				recap.Rescued = value
+			case "ignored":
+				recap.Ignored = value
+			}
+		}
+		hostRecap := recaps[match[1]]
+		hostRecap.add(recap)
+		recaps[match[1]] = hostRecap
+	}
+
+	return recaps
+}
+
+// ParseAnsibleFailedTasks - returns the name of the last task which failed on
+// each host in the output of an ansible run. Failures which were ignored are
+// not returned.
+func ParseAnsibleFailedTasks(output string) map[string]string {
+	failedTasks := map[string]string{}
+	task := ""
+	lastHost := ""
+	lastHostTask, lastHostFailed := "", false
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := taskRegex.FindStringSubmatch(line); match != nil {
+			task = match[1]
+			continue
+		}
+		if match := failedHostRegex.FindStringSubmatch(line); match != nil {
+			// Delegated tasks are reported as "host -> delegate"
+			host, _, _ := strings.Cut(match[1], " -> ")
+			lastHost = host
+			lastHostTask, lastHostFailed = failedTasks[host]
+			failedTasks[host] = task
+			continue
+		}
+		if strings.HasPrefix(line, "...ignoring") && lastHost != "" {
+			// Restore the failure recorded before the ignored one
+			if lastHostFailed {
+				failedTasks[lastHost] = lastHostTask
+			} else {
+				delete(failedTasks, lastHost)
+			}
+			lastHost = ""
+		}
+	}
+
+	return failedTasks
+}
+
+// getAnsibleExecutionPod returns the latest pod of the OpenStackAnsibleEE
+func getAnsibleExecutionPod(
+	ctx context.Context,
+	helper *helper.Helper,
+	ansibleEE *ansibleeev1.OpenStackAnsibleEE,
+) (*corev1.Pod, error) {
+	pods, err := helper.GetKClient().CoreV1().Pods(ansibleEE.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", ansibleEE.Name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no pod found for execution %s", ansibleEE.Name)
+	}
+
+	latest := pods.Items[0]
+	for _, pod := range pods.Items[1:] {
+		if latest.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			latest = pod
+		}
+	}
+	return &latest, nil
+}
+
+// getPodOutput returns the last lines of the output of the pod
+func getPodOutput(
+	ctx context.Context,
+	helper *helper.Helper,
+	pod *corev1.Pod,
+	tailLines int64,
+) (string, error) {
+	output, err := helper.GetKClient().CoreV1().Pods(pod.Namespace).GetLogs(
+		pod.Name, &corev1.PodLogOptions{TailLines: &tailLines}).DoRaw(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// GetAnsibleExecutionOutput - returns the end of the output of the latest
+// pod of the OpenStackAnsibleEE
+func GetAnsibleExecutionOutput(
+	ctx context.Context,
+	helper *helper.Helper,
+	ansibleEE *ansibleeev1.OpenStackAnsibleEE,
+) (string, error) {
+	pod, err := getAnsibleExecutionPod(ctx, helper, ansibleEE)
+	if err != nil {
+		return "", err
+	}
+	return getPodOutput(ctx, helper, pod, ansibleOutputTailLines)
+}
+
+// GetCheckReportName - returns the name of the ConfigMap holding the report
+// of a Check mode Deployment
+func GetCheckReportName(instance *dataplanev1.OpenStackDataPlaneDeployment) string {
+	if instance.Status.Run > 0 {
+		return fmt.Sprintf("%s-run-%d-check-report", instance.Name, instance.Status.Run)
+	}
+	return fmt.Sprintf("%s-check-report", instance.Name)
+}
+
+// GenerateCheckReport - collects the ansible recap of each host from the
+// succeeded executions of a Check mode Deployment into a ConfigMap. The
+// ConfigMap holds a key by NodeSet, with the recap of each host by service.
+func GenerateCheckReport(
+	ctx context.Context,
+	helper *helper.Helper,
+	instance *dataplanev1.OpenStackDataPlaneDeployment,
+) error {
+	log := helper.GetLogger()
+
+	ansibleEEs := &ansibleeev1.OpenStackAnsibleEEList{}
+	err := helper.GetClient().List(ctx, ansibleEEs,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels(dataplaneutil.GetDeploymentExecutionLabels(instance)))
+	if err != nil {
+		return err
+	}
+
+	report := map[string]map[string]map[string]AnsibleHostRecap{}
+	for _, nodeSet := range instance.Spec.NodeSets {
+		report[nodeSet] = map[string]map[string]AnsibleHostRecap{}
+	}
+	for idx := range ansibleEEs.Items {
+		ansibleEE := &ansibleEEs.Items[idx]
+		if ansibleEE.Status.JobStatus != ansibleeev1.JobStatusSucceeded {
+			continue
+		}
+		nodeSet := ansibleEE.Labels["openstackdataplanenodeset"]
+		service := ansibleEE.Labels["openstackdataplaneservice"]
+		if _, ok := report[nodeSet]; !ok {
+			continue
+		}
+		if report[nodeSet][service] == nil {
+			report[nodeSet][service] = map[string]AnsibleHostRecap{}
+		}
+
+		output, err := GetAnsibleExecutionOutput(ctx, helper, ansibleEE)
+		if err != nil {
+			log.Info("Unable to read the output of execution for the check report", "execution", ansibleEE.Name, "error", err.Error())
+			continue
+		}
+		for host, recap := range ParseAnsibleRecap(output) {
+			hostRecap := report[nodeSet][service][host]
+			hostRecap.add(recap)
+			report[nodeSet][service][host] = hostRecap
+		}
+	}
+
+	data := map[string]string{}
+	for nodeSet, services := range report {
+		nodeSetReport, err := json.MarshalIndent(services, "", "  ")
+		if err != nil {
+			return err
+		}
+		data[nodeSet] = string(nodeSetReport)
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetCheckReportName(instance),
+			Namespace: instance.Namespace,
+		},
+	}
+	_, err = controllerutil.CreateOrPatch(ctx, helper.GetClient(), configMap, func() error {
+		configMap.Labels = map[string]string{"openstackdataplanedeployment": instance.Name}
+		configMap.Data = data
+		return controllerutil.SetControllerReference(instance, configMap, helper.GetScheme())
+	})
+	if err != nil {
+		return err
+	}
+
+	instance.Status.CheckReport = configMap.Name
+	return nil
+}
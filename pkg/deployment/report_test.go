@@ -0,0 +1,183 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	// Output of an ansible run with a failed and an unreachable host
+	ansibleOutputFailed = `PLAY [osp.edpm.edpm_bootstrap] *************************************************
+
+TASK [Gathering Facts] *********************************************************
+ok: [edpm-compute-0]
+fatal: [edpm-compute-1]: UNREACHABLE! => {"changed": false, "msg": "Failed to connect to the host via ssh: ssh: connect to host 192.168.122.101 port 22: No route to host", "unreachable": true}
+
+TASK [osp.edpm.edpm_bootstrap : Install packages] ******************************
+fatal: [edpm-compute-0]: FAILED! => {"changed": false, "failures": ["No package foo available."], "msg": "Failed to install some of the specified packages", "rc": 1, "results": []}
+
+PLAY RECAP *********************************************************************
+edpm-compute-0             : ok=1    changed=0    unreachable=0    failed=1    skipped=0    rescued=0    ignored=0
+edpm-compute-1             : ok=0    changed=0    unreachable=1    failed=0    skipped=0    rescued=0    ignored=0
+
+`
+
+	// Output of two playbooks, the second one ignoring a failure
+	ansibleOutputTwoPlays = `PLAY [osp.edpm.edpm_bootstrap] *************************************************
+
+TASK [osp.edpm.edpm_bootstrap : Set hostname] **********************************
+changed: [edpm-compute-0]
+
+PLAY RECAP *********************************************************************
+edpm-compute-0             : ok=3    changed=1    unreachable=0    failed=0    skipped=2    rescued=0    ignored=0
+
+PLAY [osp.edpm.edpm_kernel] ****************************************************
+
+TASK [osp.edpm.edpm_kernel : Check for reboot] *********************************
+fatal: [edpm-compute-0]: FAILED! => {"changed": false, "cmd": ["needs-restarting", "-r"], "msg": "non-zero return code", "rc": 1}
+...ignoring
+
+TASK [osp.edpm.edpm_kernel : Configure kernel args] ****************************
+ok: [edpm-compute-0]
+
+PLAY RECAP *********************************************************************
+edpm-compute-0             : ok=2    changed=0    unreachable=0    failed=0    skipped=1    rescued=0    ignored=1
+
+`
+)
+
+func TestParseAnsibleRecap(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   map[string]AnsibleHostRecap
+	}{
+		{
+			name:   "no recap",
+			output: "PLAY [osp.edpm.edpm_bootstrap] ****\n\nTASK [Gathering Facts] ****\nok: [edpm-compute-0]\n",
+			want:   map[string]AnsibleHostRecap{},
+		},
+		{
+			name:   "failed and unreachable hosts",
+			output: ansibleOutputFailed,
+			want: map[string]AnsibleHostRecap{
+				"edpm-compute-0": {Ok: 1, Failed: 1},
+				"edpm-compute-1": {Unreachable: 1},
+			},
+		},
+		{
+			name:   "recaps summed",
+			output: ansibleOutputTwoPlays,
+			want: map[string]AnsibleHostRecap{
+				"edpm-compute-0": {Ok: 5, Changed: 1, Skipped: 3, Ignored: 1},
+			},
+		},
+		{
+			name: "lines after the recap",
+			output: `PLAY RECAP *********************************************************************
+edpm-compute-0             : ok=4    changed=2    unreachable=0    failed=0    skipped=0    rescued=1    ignored=0
+localhost                  : ok=1    changed=0    unreachable=0    failed=0    skipped=0    rescued=0    ignored=0
+
+Thursday 15 February 2024  10:07:30 +0000 (0:00:01.234)       0:02:03.456 *****
+===============================================================================
+osp.edpm.edpm_bootstrap : Install packages ---------------------------- 60.12s
+`,
+			want: map[string]AnsibleHostRecap{
+				"edpm-compute-0": {Ok: 4, Changed: 2, Rescued: 1},
+				"localhost":      {Ok: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseAnsibleRecap(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAnsibleRecap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAnsibleFailedTasks(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   map[string]string
+	}{
+		{
+			name:   "no failure",
+			output: "TASK [Gathering Facts] ****\nok: [edpm-compute-0]\n",
+			want:   map[string]string{},
+		},
+		{
+			name:   "failed and unreachable hosts",
+			output: ansibleOutputFailed,
+			want: map[string]string{
+				"edpm-compute-0": "osp.edpm.edpm_bootstrap : Install packages",
+				"edpm-compute-1": "Gathering Facts",
+			},
+		},
+		{
+			name:   "ignored failure",
+			output: ansibleOutputTwoPlays,
+			want:   map[string]string{},
+		},
+		{
+			name: "delegated task",
+			output: `TASK [osp.edpm.edpm_nova : Check the compute service] **************************
+fatal: [edpm-compute-0 -> localhost]: FAILED! => {"changed": false, "msg": "compute service not found"}
+`,
+			want: map[string]string{"edpm-compute-0": "osp.edpm.edpm_nova : Check the compute service"},
+		},
+		{
+			name: "failed handler",
+			output: `RUNNING HANDLER [osp.edpm.edpm_network_config : Restart network] **************
+fatal: [edpm-compute-0]: FAILED! => {"changed": false, "msg": "Unable to restart service network"}
+`,
+			want: map[string]string{"edpm-compute-0": "osp.edpm.edpm_network_config : Restart network"},
+		},
+		{
+			name: "ignored failure after a failure",
+			output: `TASK [osp.edpm.edpm_users : Create users] **************************************
+failed: [edpm-compute-0] (item=nova) => {"ansible_loop_var": "item", "changed": false, "item": "nova", "msg": "useradd failed"}
+
+TASK [osp.edpm.edpm_users : Check users] ***************************************
+fatal: [edpm-compute-0]: FAILED! => {"changed": false, "msg": "user nova not found"}
+...ignoring
+`,
+			want: map[string]string{"edpm-compute-0": "osp.edpm.edpm_users : Create users"},
+		},
+		{
+			name: "last failed task",
+			output: `TASK [osp.edpm.edpm_podman : Pull images] **************************************
+fatal: [edpm-compute-0]: FAILED! => {"changed": false, "msg": "pull failed"}
+
+RUNNING HANDLER [osp.edpm.edpm_podman : Restart podman] ************************
+fatal: [edpm-compute-0]: FAILED! => {"changed": false, "msg": "restart failed"}
+`,
+			want: map[string]string{"edpm-compute-0": "osp.edpm.edpm_podman : Restart podman"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseAnsibleFailedTasks(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAnsibleFailedTasks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+)
+
+// StartDeploymentRerun - starts a new run of the finished Deployment when its
+// rerun annotation carries a new token. The current run is recorded in the
+// PreviousRuns, and the status is reset so the Deployment creates new ansible
+// executions. A rerun requested while the Deployment is running starts once
+// it finished, and none is started while the cancel annotation is set.
+// Returns true when a new run was started.
+func StartDeploymentRerun(instance *dataplanev1.OpenStackDataPlaneDeployment) bool {
+	if !instance.IsRerunRequested() || !instance.DeletionTimestamp.IsZero() {
+		return false
+	}
+	token := instance.Annotations[dataplanev1.DeploymentRerunAnnotation]
+
+	// A Deployment created with the annotation already runs for its token
+	if instance.Status.Conditions == nil && instance.Status.Run == 0 {
+		instance.Status.RerunToken = token
+		return false
+	}
+
+	outcome := GetDeploymentOutcome(instance)
+	if outcome == dataplanev1.DeploymentOutcomeRunning || instance.IsCancelRequested() {
+		return false
+	}
+
+	finishTime := metav1.NewTime(GetDeploymentFinishTime(instance))
+	previousRuns := append(instance.Status.PreviousRuns, dataplanev1.DeploymentRun{
+		Run:         instance.Status.Run,
+		RerunToken:  instance.Status.RerunToken,
+		StartTime:   instance.GetRunStartTime(),
+		FinishTime:  &finishTime,
+		Outcome:     outcome,
+		Results:     instance.Status.Results,
+		CheckReport: instance.Status.CheckReport,
+	})
+	if extra := len(previousRuns) - dataplanev1.DeploymentRunHistoryLimit; extra > 0 {
+		previousRuns = previousRuns[extra:]
+	}
+
+	now := metav1.Now()
+	instance.Status = dataplanev1.OpenStackDataPlaneDeploymentStatus{
+		Run:                instance.Status.Run + 1,
+		RerunToken:         token,
+		RunStartTime:       &now,
+		PreviousRuns:       previousRuns,
+		ObservedGeneration: instance.Status.ObservedGeneration,
+	}
+	return true
+}
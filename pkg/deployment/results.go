@@ -0,0 +1,225 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	dataplaneutil "github.com/openstack-k8s-operators/dataplane-operator/pkg/util"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	ansibleeev1 "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
+)
+
+// AnsibleHostResult - ansible results of an execution on a host
+type AnsibleHostResult struct {
+	AnsibleHostRecap
+	// FailedTask - name of the task which failed on the host, if any
+	FailedTask string `json:"failedTask,omitempty"`
+}
+
+// AnsibleExecutionResult - ansible results of an execution, by host. Hosts is
+// empty when the output of the execution could not be read.
+type AnsibleExecutionResult struct {
+	JobStatus string                       `json:"jobStatus"`
+	Hosts     map[string]AnsibleHostResult `json:"hosts,omitempty"`
+}
+
+// GetResultsName - returns the name of the ConfigMap holding the ansible
+// results of a Deployment
+func GetResultsName(instance *dataplanev1.OpenStackDataPlaneDeployment) string {
+	if instance.Status.Run > 0 {
+		return fmt.Sprintf("%s-run-%d-results", instance.Name, instance.Status.Run)
+	}
+	return fmt.Sprintf("%s-results", instance.Name)
+}
+
+// UpdateDeploymentResults - records the ansible results of each host from the
+// finished executions of the Deployment in a ConfigMap, and their summary in
+// the Deployment status. Only the last attempt of each execution is recorded,
+// and the output of an execution already recorded is not read again. The
+// ConfigMap holds a key by NodeSet, with the results of each host by service
+// and execution.
+func UpdateDeploymentResults(
+	ctx context.Context,
+	helper *helper.Helper,
+	instance *dataplanev1.OpenStackDataPlaneDeployment,
+) error {
+	log := helper.GetLogger()
+
+	ansibleEEs := &ansibleeev1.OpenStackAnsibleEEList{}
+	err := helper.GetClient().List(ctx, ansibleEEs,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels(dataplaneutil.GetDeploymentExecutionLabels(instance)))
+	if err != nil {
+		return err
+	}
+
+	// Keep the last attempt of each batch of each service of each NodeSet
+	latest := map[string]*ansibleeev1.OpenStackAnsibleEE{}
+	for idx := range ansibleEEs.Items {
+		ansibleEE := &ansibleEEs.Items[idx]
+		key := fmt.Sprintf("%s/%s/%s",
+			ansibleEE.Labels["openstackdataplanenodeset"],
+			ansibleEE.Labels["openstackdataplaneservice"],
+			ansibleEE.Labels["openstackdataplanebatch"])
+		current, ok := latest[key]
+		if !ok || dataplaneutil.GetAnsibleExecutionAttempt(current) < dataplaneutil.GetAnsibleExecutionAttempt(ansibleEE) {
+			latest[key] = ansibleEE
+		}
+	}
+
+	// Results already recorded
+	recorded := map[string]map[string]map[string]AnsibleExecutionResult{}
+	existing := &corev1.ConfigMap{}
+	err = helper.GetClient().Get(ctx, types.NamespacedName{
+		Name:      GetResultsName(instance),
+		Namespace: instance.Namespace,
+	}, existing)
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return err
+	}
+	for nodeSet, data := range existing.Data {
+		services := map[string]map[string]AnsibleExecutionResult{}
+		if err := json.Unmarshal([]byte(data), &services); err != nil {
+			log.Info("Ignoring invalid Deployment results", "nodeSet", nodeSet, "error", err.Error())
+			continue
+		}
+		recorded[nodeSet] = services
+	}
+
+	results := map[string]map[string]map[string]AnsibleExecutionResult{}
+	for _, nodeSet := range instance.Spec.NodeSets {
+		results[nodeSet] = map[string]map[string]AnsibleExecutionResult{}
+	}
+	changed := false
+	for _, ansibleEE := range latest {
+		if ansibleEE.Status.JobStatus != ansibleeev1.JobStatusSucceeded &&
+			ansibleEE.Status.JobStatus != ansibleeev1.JobStatusFailed {
+			continue
+		}
+		nodeSet := ansibleEE.Labels["openstackdataplanenodeset"]
+		service := ansibleEE.Labels["openstackdataplaneservice"]
+		if _, ok := results[nodeSet]; !ok {
+			continue
+		}
+		if results[nodeSet][service] == nil {
+			results[nodeSet][service] = map[string]AnsibleExecutionResult{}
+		}
+
+		result, ok := recorded[nodeSet][service][ansibleEE.Name]
+		if ok && result.JobStatus == ansibleEE.Status.JobStatus {
+			results[nodeSet][service][ansibleEE.Name] = result
+			continue
+		}
+		changed = true
+		result = AnsibleExecutionResult{JobStatus: ansibleEE.Status.JobStatus}
+		output, err := GetAnsibleExecutionOutput(ctx, helper, ansibleEE)
+		if err != nil {
+			log.Info("Unable to read the output of execution for the Deployment results", "execution", ansibleEE.Name, "error", err.Error())
+		} else {
+			result.Hosts = map[string]AnsibleHostResult{}
+			failedTasks := ParseAnsibleFailedTasks(output)
+			for host, recap := range ParseAnsibleRecap(output) {
+				hostResult := AnsibleHostResult{AnsibleHostRecap: recap}
+				if recap.Failed > 0 || recap.Unreachable > 0 {
+					hostResult.FailedTask = failedTasks[host]
+				}
+				result.Hosts[host] = hostResult
+			}
+		}
+		results[nodeSet][service][ansibleEE.Name] = result
+	}
+
+	// Nothing finished yet
+	if !changed && len(existing.Data) == 0 {
+		return nil
+	}
+
+	data := map[string]string{}
+	for nodeSet, services := range results {
+		nodeSetResults, err := json.MarshalIndent(services, "", "  ")
+		if err != nil {
+			return err
+		}
+		data[nodeSet] = string(nodeSetResults)
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetResultsName(instance),
+			Namespace: instance.Namespace,
+		},
+	}
+	_, err = controllerutil.CreateOrPatch(ctx, helper.GetClient(), configMap, func() error {
+		configMap.Labels = map[string]string{"openstackdataplanedeployment": instance.Name}
+		configMap.Data = data
+		return controllerutil.SetControllerReference(instance, configMap, helper.GetScheme())
+	})
+	if err != nil {
+		return err
+	}
+
+	instance.Status.Results = configMap.Name
+	instance.Status.NodeSetResults = make(map[string]map[string]dataplanev1.AnsibleServiceResult)
+	for nodeSet, services := range results {
+		instance.Status.NodeSetResults[nodeSet] = make(map[string]dataplanev1.AnsibleServiceResult)
+		for service, executions := range services {
+			instance.Status.NodeSetResults[nodeSet][service] = summarizeServiceResults(executions)
+		}
+	}
+	return nil
+}
+
+// summarizeServiceResults returns the summary of the results of the executions
+// of a service on the hosts of a NodeSet
+func summarizeServiceResults(executions map[string]AnsibleExecutionResult) dataplanev1.AnsibleServiceResult {
+	hosts := map[string]AnsibleHostRecap{}
+	for _, execution := range executions {
+		for host, result := range execution.Hosts {
+			recap := hosts[host]
+			recap.add(result.AnsibleHostRecap)
+			hosts[host] = recap
+		}
+	}
+
+	summary := dataplanev1.AnsibleServiceResult{Hosts: len(hosts)}
+	for host, recap := range hosts {
+		if recap.Changed > 0 {
+			summary.ChangedHosts++
+		}
+		if recap.Failed > 0 {
+			summary.FailedHosts = append(summary.FailedHosts, host)
+		}
+		if recap.Unreachable > 0 {
+			summary.UnreachableHosts = append(summary.UnreachableHosts, host)
+		}
+	}
+	sort.Strings(summary.FailedHosts)
+	sort.Strings(summary.UnreachableHosts)
+	return summary
+}
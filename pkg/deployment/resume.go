@@ -0,0 +1,157 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"fmt"
+
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	dataplaneutil "github.com/openstack-k8s-operators/dataplane-operator/pkg/util"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+)
+
+// GetServiceConfigHash - returns a hash of the NodeSet configuration and of
+// the ConfigMaps and Secrets of the service
+func GetServiceConfigHash(
+	ctx context.Context,
+	helper *helper.Helper,
+	nodeSet *dataplanev1.OpenStackDataPlaneNodeSet,
+	serviceName string,
+) (string, error) {
+	configMapHashes := make(map[string]string)
+	secretHashes := make(map[string]string)
+	err := GetDeploymentHashesForService(
+		ctx,
+		helper,
+		nodeSet.Namespace,
+		serviceName,
+		configMapHashes,
+		secretHashes,
+		dataplanev1.OpenStackDataPlaneNodeSetList{Items: []dataplanev1.OpenStackDataPlaneNodeSet{*nodeSet}})
+	if err != nil {
+		return "", err
+	}
+
+	return util.ObjectHash(struct {
+		NodeSetHash     string
+		ConfigMapHashes map[string]string
+		SecretHashes    map[string]string
+	}{
+		NodeSetHash:     nodeSet.Status.ConfigHash,
+		ConfigMapHashes: configMapHashes,
+		SecretHashes:    secretHashes,
+	})
+}
+
+// recordServiceHash records the configuration hash the service is deployed
+// with for the NodeSet. The hash is only updated when a new execution is
+// started.
+func (d *Deployer) recordServiceHash(serviceName string, update bool) error {
+	if _, ok := d.Status.NodeSetServiceHashes[d.NodeSet.Name][serviceName]; ok && !update {
+		return nil
+	}
+
+	hash, err := GetServiceConfigHash(d.Ctx, d.Helper, d.NodeSet, serviceName)
+	if err != nil {
+		return err
+	}
+	d.setServiceHash(serviceName, hash)
+
+	return nil
+}
+
+// setServiceHash sets the configuration hash of the service for the NodeSet
+func (d *Deployer) setServiceHash(serviceName string, hash string) {
+	if d.Status.NodeSetServiceHashes == nil {
+		d.Status.NodeSetServiceHashes = make(map[string]map[string]string)
+	}
+	if d.Status.NodeSetServiceHashes[d.NodeSet.Name] == nil {
+		d.Status.NodeSetServiceHashes[d.NodeSet.Name] = make(map[string]string)
+	}
+	d.Status.NodeSetServiceHashes[d.NodeSet.Name][serviceName] = hash
+}
+
+// isResumable returns true when the service may be skipped after it succeeded
+// in the resumed Deployment. The service must have been deployed on the whole
+// NodeSet at once, with all of its tasks: not in batches, in Check mode, with
+// a limit, tags or skipped tags, or with options overridden for the service.
+func isResumable(resumeFrom *dataplanev1.OpenStackDataPlaneDeployment, serviceName string) bool {
+	spec := resumeFrom.Spec
+	if spec.RolloutStrategy != nil || spec.Canary != nil || resumeFrom.IsCheckMode() {
+		return false
+	}
+	if spec.AnsibleLimit != "" || spec.AnsibleTags != "" || spec.AnsibleSkipTags != "" {
+		return false
+	}
+	_, overridden := spec.ServiceOverrides[serviceName]
+	return !overridden
+}
+
+// resumeService marks the service as ready when it succeeded for the NodeSet
+// in the Deployment being resumed, and neither the NodeSet nor the service
+// configuration changed since. Services already started by this Deployment
+// are never skipped.
+func (d *Deployer) resumeService(
+	foundService dataplanev1.OpenStackDataPlaneService,
+	readyCondition condition.Type,
+) (bool, error) {
+	resumeFrom := d.ResumeFrom
+	if resumeFrom == nil || d.Batch > 0 || !isResumable(resumeFrom, foundService.Name) {
+		return false, nil
+	}
+
+	previousConditions := resumeFrom.Status.NodeSetConditions[d.NodeSet.Name]
+	if !previousConditions.IsTrue(readyCondition) {
+		return false, nil
+	}
+	previousHash, ok := resumeFrom.Status.NodeSetServiceHashes[d.NodeSet.Name][foundService.Name]
+	if !ok {
+		return false, nil
+	}
+
+	_, labelSelector := dataplaneutil.GetAnsibleExecutionDeploymentNameAndLabels(&foundService, d.Deployment, d.NodeSet.Name, 0)
+	_, err := dataplaneutil.GetAnsibleExecution(d.Ctx, d.Helper, d.Deployment, labelSelector)
+	if err == nil {
+		return false, nil
+	} else if !k8s_errors.IsNotFound(err) {
+		return false, err
+	}
+
+	hash, err := GetServiceConfigHash(d.Ctx, d.Helper, d.NodeSet, foundService.Name)
+	if err != nil {
+		return false, err
+	}
+	if hash != previousHash {
+		d.Helper.GetLogger().Info("Configuration changed since the resumed Deployment, not skipping service",
+			"service", foundService.Name, "resumeFrom", resumeFrom.Name)
+		return false, nil
+	}
+
+	d.setServiceHash(foundService.Name, hash)
+	nsConditions := d.Status.NodeSetConditions[d.NodeSet.Name]
+	nsConditions.Set(condition.TrueCondition(
+		readyCondition,
+		fmt.Sprintf(dataplanev1.NodeSetServiceDeploymentResumedMessage, foundService.Name, resumeFrom.Name)))
+	d.Status.NodeSetConditions[d.NodeSet.Name] = nsConditions
+
+	return true, nil
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	dataplaneutil "github.com/openstack-k8s-operators/dataplane-operator/pkg/util"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	ansibleeev1 "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
+)
+
+func TestResumeService(t *testing.T) {
+	objectMeta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "openstack"}
+	}
+	newService := func() *dataplanev1.OpenStackDataPlaneService {
+		return &dataplanev1.OpenStackDataPlaneService{
+			ObjectMeta: objectMeta("foo-service"),
+			Spec: dataplanev1.OpenStackDataPlaneServiceSpec{
+				ConfigMaps: []string{"foo-config"},
+				Secrets:    []string{"foo-secret"},
+			},
+		}
+	}
+	newConfigMap := func(value string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: objectMeta("foo-config"), Data: map[string]string{"foo": value}}
+	}
+	newSecret := func(value string) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: objectMeta("foo-secret"), Data: map[string][]byte{"foo": []byte(value)}}
+	}
+	newNodeSet := func() *dataplanev1.OpenStackDataPlaneNodeSet {
+		return &dataplanev1.OpenStackDataPlaneNodeSet{
+			ObjectMeta: objectMeta("edpm-compute"),
+			Status:     dataplanev1.OpenStackDataPlaneNodeSetStatus{ConfigHash: "nodeset-hash"},
+		}
+	}
+	newDeployment := func() *dataplanev1.OpenStackDataPlaneDeployment {
+		return &dataplanev1.OpenStackDataPlaneDeployment{
+			ObjectMeta: objectMeta("edpm-deployment-resumed"),
+			Spec: dataplanev1.OpenStackDataPlaneDeploymentSpec{
+				NodeSets:   []string{"edpm-compute"},
+				ResumeFrom: "edpm-deployment",
+			},
+		}
+	}
+	readyCondition := GetServiceReadyCondition("foo-service")
+
+	// The hash foo-service was deployed with by the resumed Deployment
+	nodeSet := newNodeSet()
+	h := newTestHelper(t, newDeployment(), nodeSet, newService(), newConfigMap("bar"), newSecret("bar"))
+	previousHash, err := GetServiceConfigHash(context.Background(), h, nodeSet, "foo-service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newResumeFrom := func() *dataplanev1.OpenStackDataPlaneDeployment {
+		nsConditions := condition.Conditions{}
+		nsConditions.Set(condition.TrueCondition(readyCondition, "ready"))
+		return &dataplanev1.OpenStackDataPlaneDeployment{
+			ObjectMeta: objectMeta("edpm-deployment"),
+			Spec: dataplanev1.OpenStackDataPlaneDeploymentSpec{
+				NodeSets: []string{"edpm-compute"},
+			},
+			Status: dataplanev1.OpenStackDataPlaneDeploymentStatus{
+				NodeSetConditions:    map[string]condition.Conditions{"edpm-compute": nsConditions},
+				NodeSetServiceHashes: map[string]map[string]string{"edpm-compute": {"foo-service": previousHash}},
+			},
+		}
+	}
+	newAnsibleEE := func() *ansibleeev1.OpenStackAnsibleEE {
+		name, labels := dataplaneutil.GetAnsibleExecutionDeploymentNameAndLabels(
+			newService(), newDeployment(), "edpm-compute", 0)
+		ansibleEE := &ansibleeev1.OpenStackAnsibleEE{ObjectMeta: objectMeta(name)}
+		ansibleEE.Labels = labels
+		return ansibleEE
+	}
+
+	tests := []struct {
+		name       string
+		resumeFrom func(*dataplanev1.OpenStackDataPlaneDeployment)
+		batch      int
+		objs       []client.Object
+		want       bool
+	}{
+		{
+			name: "unchanged configuration",
+			want: true,
+		},
+		{
+			name: "ConfigMap changed",
+			objs: []client.Object{newConfigMap("baz")},
+		},
+		{
+			name: "Secret changed",
+			objs: []client.Object{newSecret("baz")},
+		},
+		{
+			name: "NodeSet changed",
+			resumeFrom: func(resumeFrom *dataplanev1.OpenStackDataPlaneDeployment) {
+				resumeFrom.Status.NodeSetServiceHashes["edpm-compute"]["foo-service"] = "old-hash"
+			},
+		},
+		{
+			name: "not ready in the resumed Deployment",
+			resumeFrom: func(resumeFrom *dataplanev1.OpenStackDataPlaneDeployment) {
+				resumeFrom.Status.NodeSetConditions["edpm-compute"] = condition.Conditions{}
+			},
+		},
+		{
+			name: "no hash in the resumed Deployment",
+			resumeFrom: func(resumeFrom *dataplanev1.OpenStackDataPlaneDeployment) {
+				resumeFrom.Status.NodeSetServiceHashes = nil
+			},
+		},
+		{
+			name: "already started",
+			objs: []client.Object{newAnsibleEE()},
+		},
+		{
+			name:  "deployed in batches",
+			batch: 1,
+		},
+		{
+			name: "resumed from a rollout",
+			resumeFrom: func(resumeFrom *dataplanev1.OpenStackDataPlaneDeployment) {
+				resumeFrom.Spec.RolloutStrategy = &dataplanev1.RolloutStrategy{BatchSize: intstr.FromInt(1)}
+			},
+		},
+		{
+			name: "resumed from a canary",
+			resumeFrom: func(resumeFrom *dataplanev1.OpenStackDataPlaneDeployment) {
+				resumeFrom.Spec.Canary = &dataplanev1.Canary{Count: 1}
+			},
+		},
+		{
+			name: "resumed from a limited Deployment",
+			resumeFrom: func(resumeFrom *dataplanev1.OpenStackDataPlaneDeployment) {
+				resumeFrom.Spec.AnsibleLimit = "edpm-compute-0"
+			},
+		},
+		{
+			name: "resumed from a Deployment with tags",
+			resumeFrom: func(resumeFrom *dataplanev1.OpenStackDataPlaneDeployment) {
+				resumeFrom.Spec.AnsibleTags = "packages"
+			},
+		},
+		{
+			name: "resumed from a Deployment with skipped tags",
+			resumeFrom: func(resumeFrom *dataplanev1.OpenStackDataPlaneDeployment) {
+				resumeFrom.Spec.AnsibleSkipTags = "packages"
+			},
+		},
+		{
+			name: "resumed from a Deployment overriding the service",
+			resumeFrom: func(resumeFrom *dataplanev1.OpenStackDataPlaneDeployment) {
+				resumeFrom.Spec.ServiceOverrides = map[string]dataplanev1.ServiceOverride{
+					"foo-service": {AnsibleLimit: "edpm-compute-0"},
+				}
+			},
+		},
+		{
+			name: "resumed from a Deployment overriding another service",
+			resumeFrom: func(resumeFrom *dataplanev1.OpenStackDataPlaneDeployment) {
+				resumeFrom.Spec.ServiceOverrides = map[string]dataplanev1.ServiceOverride{
+					"bar-service": {AnsibleLimit: "edpm-compute-0"},
+				}
+			},
+			want: true,
+		},
+		{
+			name: "resumed from a check",
+			resumeFrom: func(resumeFrom *dataplanev1.OpenStackDataPlaneDeployment) {
+				resumeFrom.Spec.Mode = dataplanev1.DeploymentModeCheck
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resumeFrom := newResumeFrom()
+			if tt.resumeFrom != nil {
+				tt.resumeFrom(resumeFrom)
+			}
+			// The objects of the test replace the default ones
+			objs := map[string]client.Object{}
+			for _, obj := range append([]client.Object{
+				newNodeSet(), newService(), newConfigMap("bar"), newSecret("bar"),
+			}, tt.objs...) {
+				objs[fmt.Sprintf("%T/%s", obj, obj.GetName())] = obj
+			}
+			deployment := newDeployment()
+			d := &Deployer{
+				Ctx:        context.Background(),
+				NodeSet:    newNodeSet(),
+				Deployment: deployment,
+				Status:     &deployment.Status,
+				Batch:      tt.batch,
+				ResumeFrom: resumeFrom,
+			}
+			d.Status.NodeSetConditions = map[string]condition.Conditions{}
+			helperObjs := []client.Object{}
+			for _, obj := range objs {
+				helperObjs = append(helperObjs, obj)
+			}
+			d.Helper = newTestHelper(t, deployment, helperObjs...)
+
+			got, err := d.resumeService(*newService(), readyCondition)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("resumeService() = %t, want %t", got, tt.want)
+			}
+			nsConditions := d.Status.NodeSetConditions["edpm-compute"]
+			ready := nsConditions.Get(readyCondition)
+			if tt.want && (ready == nil || ready.Status != corev1.ConditionTrue) {
+				t.Errorf("got ready condition %v, want True", ready)
+			}
+			if !tt.want && ready != nil {
+				t.Errorf("got ready condition %v for a service which is not skipped", ready)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"golang.org/x/exp/slices"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+)
+
+// SortDeploymentsByReadyTime - sorts the Deployments from oldest to newest by
+// the LastTransitionTime of their DeploymentReadyCondition. Deployments
+// without the condition are sorted last, and ties are ordered by creation.
+func SortDeploymentsByReadyTime(deployments []dataplanev1.OpenStackDataPlaneDeployment) {
+	slices.SortStableFunc(deployments, func(a, b dataplanev1.OpenStackDataPlaneDeployment) int {
+		aReady := a.Status.Conditions.Get(condition.DeploymentReadyCondition)
+		bReady := b.Status.Conditions.Get(condition.DeploymentReadyCondition)
+		switch {
+		case aReady == nil && bReady != nil:
+			return 1
+		case aReady != nil && bReady == nil:
+			return -1
+		case aReady != nil && !aReady.LastTransitionTime.Equal(&bReady.LastTransitionTime):
+			if aReady.LastTransitionTime.Before(&bReady.LastTransitionTime) {
+				return -1
+			}
+			return 1
+		case a.DeploysBefore(b):
+			return -1
+		case b.DeploysBefore(a):
+			return 1
+		}
+		return 0
+	})
+}
+
+// GetDeployedDeployment - returns the Deployment which last deployed the
+// NodeSet, from which the NodeSet gets its DeployedConfigHash, nil if none.
+// The Deployments must be sorted with SortDeploymentsByReadyTime.
+func GetDeployedDeployment(
+	nodeSet string,
+	deployments []dataplanev1.OpenStackDataPlaneDeployment,
+) *dataplanev1.OpenStackDataPlaneDeployment {
+	var deployed *dataplanev1.OpenStackDataPlaneDeployment
+	for idx := range deployments {
+		deployment := &deployments[idx]
+		if deployment.DeletionTimestamp.IsZero() && !deployment.IsCheckMode() &&
+			deployment.Status.Deployed && slices.Contains(deployment.Spec.NodeSets, nodeSet) {
+			deployed = deployment
+		}
+	}
+	return deployed
+}
+
+// GetDeploymentFinishTime - returns the time the Deployment finished, zero if
+// it is still running. Deployments which finished before the FinishTime was
+// recorded use the LastTransitionTime of their ReadyCondition instead.
+func GetDeploymentFinishTime(deployment *dataplanev1.OpenStackDataPlaneDeployment) time.Time {
+	if GetDeploymentOutcome(deployment) == dataplanev1.DeploymentOutcomeRunning {
+		return time.Time{}
+	}
+	if deployment.Status.FinishTime != nil {
+		return deployment.Status.FinishTime.Time
+	}
+	if ready := deployment.Status.Conditions.Get(condition.ReadyCondition); ready != nil {
+		return ready.LastTransitionTime.Time
+	}
+	return deployment.CreationTimestamp.Time
+}
+
+// UpdateDeploymentFinishTime - records the time the Deployment finished in
+// its status, or clears it while the Deployment is running
+func UpdateDeploymentFinishTime(deployment *dataplanev1.OpenStackDataPlaneDeployment) {
+	if GetDeploymentOutcome(deployment) == dataplanev1.DeploymentOutcomeRunning {
+		deployment.Status.FinishTime = nil
+	} else if deployment.Status.FinishTime == nil {
+		now := metav1.Now()
+		deployment.Status.FinishTime = &now
+	}
+}
+
+// GetDeploymentExpiry - returns the time the TTL of the finished Deployment
+// expires at, zero if it has no TTL or is still running
+func GetDeploymentExpiry(deployment *dataplanev1.OpenStackDataPlaneDeployment) time.Time {
+	finishTime := GetDeploymentFinishTime(deployment)
+	if deployment.Spec.TTLSecondsAfterFinished == nil || finishTime.IsZero() {
+		return time.Time{}
+	}
+	return finishTime.Add(time.Duration(*deployment.Spec.TTLSecondsAfterFinished) * time.Second)
+}
+
+// GetProtectedDeployments - returns the names of the Deployments which are
+// never deleted by the garbage collection: the Deployment which last deployed
+// each of the given NodeSets, and the Deployments resumed by running ones
+func GetProtectedDeployments(
+	deployments []dataplanev1.OpenStackDataPlaneDeployment,
+	nodeSets []string,
+) map[string]bool {
+	sorted := append([]dataplanev1.OpenStackDataPlaneDeployment{}, deployments...)
+	SortDeploymentsByReadyTime(sorted)
+
+	protected := map[string]bool{}
+	for _, nodeSet := range nodeSets {
+		if deployed := GetDeployedDeployment(nodeSet, sorted); deployed != nil {
+			protected[deployed.Name] = true
+		}
+	}
+	for _, deployment := range deployments {
+		if len(deployment.Spec.ResumeFrom) > 0 && deployment.DeletionTimestamp.IsZero() &&
+			GetDeploymentOutcome(&deployment) == dataplanev1.DeploymentOutcomeRunning {
+			protected[deployment.Spec.ResumeFrom] = true
+		}
+	}
+	return protected
+}
+
+// getNamespaceDeployments returns the Deployments of the namespace, and the
+// names of the ones which are protected from the garbage collection
+func getNamespaceDeployments(
+	ctx context.Context,
+	helper *helper.Helper,
+	namespace string,
+) ([]dataplanev1.OpenStackDataPlaneDeployment, map[string]bool, error) {
+	deployments := &dataplanev1.OpenStackDataPlaneDeploymentList{}
+	err := helper.GetClient().List(ctx, deployments, client.InNamespace(namespace))
+	if err != nil {
+		return nil, nil, err
+	}
+	nodeSets := &dataplanev1.OpenStackDataPlaneNodeSetList{}
+	err = helper.GetClient().List(ctx, nodeSets, client.InNamespace(namespace))
+	if err != nil {
+		return nil, nil, err
+	}
+	nodeSetNames := make([]string, 0, len(nodeSets.Items))
+	for _, nodeSet := range nodeSets.Items {
+		nodeSetNames = append(nodeSetNames, nodeSet.Name)
+	}
+	return deployments.Items, GetProtectedDeployments(deployments.Items, nodeSetNames), nil
+}
+
+// ExpireDeployment - deletes the finished Deployment, along with its ansible
+// executions, once its TTL expired and unless it is protected. Returns true
+// when the Deployment was deleted, otherwise the time left before its TTL
+// expires, 0 when it does not expire.
+func ExpireDeployment(
+	ctx context.Context,
+	helper *helper.Helper,
+	instance *dataplanev1.OpenStackDataPlaneDeployment,
+) (bool, time.Duration, error) {
+	expiry := GetDeploymentExpiry(instance)
+	if expiry.IsZero() || !instance.DeletionTimestamp.IsZero() {
+		return false, 0, nil
+	}
+	if remaining := time.Until(expiry); remaining > 0 {
+		return false, remaining, nil
+	}
+
+	_, protected, err := getNamespaceDeployments(ctx, helper, instance.Namespace)
+	if err != nil {
+		return false, 0, err
+	}
+	if protected[instance.Name] {
+		// The NodeSet controller deletes it once another Deployment deployed
+		// its NodeSets
+		helper.GetLogger().Info("Keeping expired Deployment which last deployed a NodeSet", "deployment", instance.Name)
+		return false, 0, nil
+	}
+	err = DeleteDeployment(ctx, helper, instance)
+	if err != nil {
+		return false, 0, err
+	}
+	helper.GetLogger().Info("Deleted Deployment after its TTL", "deployment", instance.Name)
+	return true, 0, nil
+}
+
+// PruneNodeSetDeployments - deletes the finished Deployments of the NodeSet
+// beyond its DeploymentRetention, newest kept first, or whose TTL expired,
+// along with their ansible executions. Protected Deployments are never
+// deleted.
+func PruneNodeSetDeployments(
+	ctx context.Context,
+	helper *helper.Helper,
+	instance *dataplanev1.OpenStackDataPlaneNodeSet,
+) error {
+	deployments, protected, err := getNamespaceDeployments(ctx, helper, instance.Namespace)
+	if err != nil {
+		return err
+	}
+
+	finished := []dataplanev1.OpenStackDataPlaneDeployment{}
+	for _, deployment := range deployments {
+		if deployment.DeletionTimestamp.IsZero() &&
+			slices.Contains(deployment.Spec.NodeSets, instance.Name) &&
+			GetDeploymentOutcome(&deployment) != dataplanev1.DeploymentOutcomeRunning {
+			finished = append(finished, deployment)
+		}
+	}
+	sort.SliceStable(finished, func(i, j int) bool {
+		return GetDeploymentFinishTime(&finished[j]).Before(GetDeploymentFinishTime(&finished[i]))
+	})
+
+	var succeededLimit, failedLimit *int
+	if instance.Spec.DeploymentRetention != nil {
+		succeededLimit = instance.Spec.DeploymentRetention.Succeeded
+		failedLimit = instance.Spec.DeploymentRetention.Failed
+	}
+	succeeded, failed := 0, 0
+	now := time.Now()
+	for idx := range finished {
+		deployment := &finished[idx]
+		var beyondRetention bool
+		if GetDeploymentOutcome(deployment) == dataplanev1.DeploymentOutcomeSucceeded {
+			succeeded++
+			beyondRetention = succeededLimit != nil && succeeded > *succeededLimit
+		} else {
+			failed++
+			beyondRetention = failedLimit != nil && failed > *failedLimit
+		}
+		expiry := GetDeploymentExpiry(deployment)
+		expired := !expiry.IsZero() && !expiry.After(now)
+		if protected[deployment.Name] || (!beyondRetention && !expired) {
+			continue
+		}
+
+		err := DeleteDeployment(ctx, helper, deployment)
+		if err != nil {
+			return err
+		}
+		delete(instance.Status.DeploymentStatuses, deployment.Name)
+		helper.GetLogger().Info("Deleted Deployment beyond the NodeSet retention or after its TTL", "deployment", deployment.Name)
+	}
+	return nil
+}
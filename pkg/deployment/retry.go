@@ -0,0 +1,179 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	dataplaneutil "github.com/openstack-k8s-operators/dataplane-operator/pkg/util"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	ansibleeev1 "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
+)
+
+// RetryMaxBackoff is the longest time waited before retrying a failed
+// ansible execution
+const RetryMaxBackoff = time.Hour
+
+// GetRetryPolicy - returns the RetryPolicy of the service, or the one of the
+// Deployment when the service does not set one
+func GetRetryPolicy(
+	deployment *dataplanev1.OpenStackDataPlaneDeployment,
+	service dataplanev1.OpenStackDataPlaneService,
+) *dataplanev1.RetryPolicy {
+	if service.Spec.RetryPolicy != nil {
+		return service.Spec.RetryPolicy
+	}
+	return deployment.Spec.RetryPolicy
+}
+
+// GetRetryBackoff - returns the time to wait before retrying the given failed
+// attempt
+func GetRetryBackoff(retryPolicy *dataplanev1.RetryPolicy, attempt int) time.Duration {
+	// The backoff is compared to the cap before each multiplication, so that
+	// large values do not overflow
+	if retryPolicy.InitialBackoffSeconds >= int(RetryMaxBackoff/time.Second) {
+		return RetryMaxBackoff
+	}
+	backoff := time.Second * time.Duration(retryPolicy.InitialBackoffSeconds)
+	multiplier := time.Duration(retryPolicy.BackoffMultiplier)
+	for i := 1; i < attempt; i++ {
+		if multiplier > 1 && backoff > RetryMaxBackoff/multiplier {
+			return RetryMaxBackoff
+		}
+		backoff *= multiplier
+	}
+	return backoff
+}
+
+// getAnsibleExecutionFinishTime returns the time the OpenStackAnsibleEE was
+// last updated as succeeded or failed, falling back to its creation time
+func getAnsibleExecutionFinishTime(ansibleEE *ansibleeev1.OpenStackAnsibleEE) time.Time {
+	readyCondition := ansibleEE.Status.Conditions.Get(condition.ReadyCondition)
+	if readyCondition != nil && !readyCondition.LastTransitionTime.IsZero() {
+		return readyCondition.LastTransitionTime.Time
+	}
+	return ansibleEE.CreationTimestamp.Time
+}
+
+// setRequeueAfter keeps the shortest delay after which the deployment should
+// be reconciled again
+func (d *Deployer) setRequeueAfter(requeueAfter time.Duration) {
+	if requeueAfter > 0 && (d.requeueAfter == 0 || requeueAfter < d.requeueAfter) {
+		d.requeueAfter = requeueAfter
+	}
+}
+
+// updateAttemptHistory records the attempts of the ansible executions of the
+// service for the NodeSet in the Deployment status
+func (d *Deployer) updateAttemptHistory(service dataplanev1.OpenStackDataPlaneService) {
+	labels := dataplaneutil.GetDeploymentExecutionLabels(d.Deployment)
+	labels["openstackdataplaneservice"] = service.Name
+	labels["openstackdataplanenodeset"] = d.NodeSet.Name
+	ansibleEEs := &ansibleeev1.OpenStackAnsibleEEList{}
+	err := d.Helper.GetClient().List(d.Ctx, ansibleEEs,
+		client.InNamespace(d.Deployment.Namespace),
+		client.MatchingLabels(labels))
+	if err != nil {
+		d.Helper.GetLogger().Error(err, "Unable to list ansible executions", "service", service.Name)
+		return
+	}
+	if len(ansibleEEs.Items) == 0 {
+		return
+	}
+
+	// Attempts stopped because of a timeout are kept, even once their
+	// OpenStackAnsibleEE is deleted, and so are the failures already found
+	timedOut := map[string]dataplanev1.AnsibleExecutionAttempt{}
+	failures := map[string]string{}
+	for _, attempt := range d.Status.NodeSetAttempts[d.NodeSet.Name][service.Name] {
+		if attempt.JobStatus == dataplanev1.AnsibleExecutionTimedOut {
+			timedOut[attempt.Name] = attempt
+		}
+		if attempt.Failure != "" {
+			failures[attempt.Name] = attempt.Failure
+		}
+	}
+
+	attempts := make([]dataplanev1.AnsibleExecutionAttempt, 0, len(ansibleEEs.Items)+len(timedOut))
+	finishTimes := make(map[string]time.Time, len(ansibleEEs.Items))
+	for idx := range ansibleEEs.Items {
+		ansibleEE := &ansibleEEs.Items[idx]
+		if attempt, ok := timedOut[ansibleEE.Name]; ok {
+			attempts = append(attempts, attempt)
+			delete(timedOut, ansibleEE.Name)
+			continue
+		}
+		finishTimes[ansibleEE.Name] = getAnsibleExecutionFinishTime(ansibleEE)
+		batch, _ := strconv.Atoi(ansibleEE.Labels["openstackdataplanebatch"])
+		attempt := dataplanev1.AnsibleExecutionAttempt{
+			Attempt:   dataplaneutil.GetAnsibleExecutionAttempt(ansibleEE),
+			Batch:     batch,
+			Name:      ansibleEE.Name,
+			JobStatus: ansibleEE.Status.JobStatus,
+			StartTime: ansibleEE.CreationTimestamp,
+			Failure:   failures[ansibleEE.Name],
+		}
+		if attempt.JobStatus == ansibleeev1.JobStatusSucceeded || attempt.JobStatus == ansibleeev1.JobStatusFailed {
+			finishTime := metav1.NewTime(finishTimes[ansibleEE.Name])
+			attempt.FinishTime = &finishTime
+		}
+		attempts = append(attempts, attempt)
+	}
+	for _, attempt := range timedOut {
+		attempts = append(attempts, attempt)
+	}
+	sort.Slice(attempts, func(i, j int) bool {
+		if attempts[i].Batch != attempts[j].Batch {
+			return attempts[i].Batch < attempts[j].Batch
+		}
+		return attempts[i].Attempt < attempts[j].Attempt
+	})
+
+	d.recordAttemptChanges(service.Name, d.Status.NodeSetAttempts[d.NodeSet.Name][service.Name], attempts, finishTimes)
+
+	if d.Status.NodeSetAttempts == nil {
+		d.Status.NodeSetAttempts = make(map[string]map[string][]dataplanev1.AnsibleExecutionAttempt)
+	}
+	if d.Status.NodeSetAttempts[d.NodeSet.Name] == nil {
+		d.Status.NodeSetAttempts[d.NodeSet.Name] = make(map[string][]dataplanev1.AnsibleExecutionAttempt)
+	}
+	d.Status.NodeSetAttempts[d.NodeSet.Name][service.Name] = attempts
+}
+
+// GetExecutionsInFlight - returns the number of ansible executions of the
+// Deployment which are pending or running, according to its attempt history
+func GetExecutionsInFlight(status *dataplanev1.OpenStackDataPlaneDeploymentStatus) int {
+	inFlight := 0
+	for _, services := range status.NodeSetAttempts {
+		for _, attempts := range services {
+			for _, attempt := range attempts {
+				switch attempt.JobStatus {
+				case ansibleeev1.JobStatusSucceeded, ansibleeev1.JobStatusFailed, dataplanev1.AnsibleExecutionTimedOut:
+				default:
+					inFlight++
+				}
+			}
+		}
+	}
+	return inFlight
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+)
+
+func TestGetRetryBackoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		initial    int
+		multiplier int
+		attempt    int
+		want       time.Duration
+	}{
+		{"first attempt", 30, 2, 1, 30 * time.Second},
+		{"second attempt", 30, 2, 2, time.Minute},
+		{"fifth attempt", 30, 2, 5, 8 * time.Minute},
+		{"constant backoff", 30, 1, 10, 30 * time.Second},
+		{"no backoff", 0, 2, 10, 0},
+		{"capped", 30, 2, 9, RetryMaxBackoff},
+		{"cap reached exactly", 900, 2, 3, RetryMaxBackoff},
+		{"initial backoff above the cap", 7200, 2, 1, RetryMaxBackoff},
+		{"many attempts", 30, 2, 1000, RetryMaxBackoff},
+		{"large multiplier", 3000, math.MaxInt32, 2, RetryMaxBackoff},
+		{"largest multiplier", 1, math.MaxInt, 3, RetryMaxBackoff},
+		{"largest initial backoff", math.MaxInt, 2, 2, RetryMaxBackoff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retryPolicy := &dataplanev1.RetryPolicy{
+				InitialBackoffSeconds: tt.initial,
+				BackoffMultiplier:     tt.multiplier,
+			}
+			if got := GetRetryBackoff(retryPolicy, tt.attempt); got != tt.want {
+				t.Errorf("GetRetryBackoff() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
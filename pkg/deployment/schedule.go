@@ -0,0 +1,177 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/exp/slices"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	ansibleeev1 "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
+)
+
+// GetScheduleTimes - returns the most recent scheduled time which is due and
+// was not run yet, zero if none, and the next scheduled time after now. Only
+// the most recent of several missed runs is returned.
+func GetScheduleTimes(
+	schedule *dataplanev1.CronSchedule,
+	instance *dataplanev1.OpenStackDataPlaneDeploymentSchedule,
+	now time.Time,
+) (time.Time, time.Time) {
+	earliest := instance.CreationTimestamp.Time
+	if instance.Status.LastScheduleTime != nil {
+		earliest = instance.Status.LastScheduleTime.Time
+	}
+
+	var scheduled time.Time
+	next := schedule.Next(earliest.UTC())
+	for !next.IsZero() && !next.After(now) {
+		scheduled = next
+		next = schedule.Next(next)
+	}
+	return scheduled, next
+}
+
+// GetScheduleDeploymentName - returns the name of the Deployment created by
+// the schedule for the given scheduled time
+func GetScheduleDeploymentName(
+	instance *dataplanev1.OpenStackDataPlaneDeploymentSchedule,
+	scheduled time.Time,
+) string {
+	suffix := fmt.Sprintf("-%d", scheduled.Unix()/60)
+	name := instance.Name
+	// The Deployment name is used in labels
+	if len(name)+len(suffix) > 63 {
+		name = name[:63-len(suffix)]
+	}
+	return name + suffix
+}
+
+// GetScheduleDeployments - returns the Deployments created by the schedule
+func GetScheduleDeployments(
+	ctx context.Context,
+	helper *helper.Helper,
+	instance *dataplanev1.OpenStackDataPlaneDeploymentSchedule,
+) ([]dataplanev1.OpenStackDataPlaneDeployment, error) {
+	deployments := &dataplanev1.OpenStackDataPlaneDeploymentList{}
+	err := helper.GetClient().List(ctx, deployments,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels{dataplanev1.DeploymentScheduleLabel: instance.Name})
+	if err != nil {
+		return nil, err
+	}
+	return deployments.Items, nil
+}
+
+// GetRunningDeployments - returns the Deployments of the namespace which are
+// still running on any of the given NodeSets
+func GetRunningDeployments(
+	ctx context.Context,
+	helper *helper.Helper,
+	namespace string,
+	nodeSets []string,
+) ([]dataplanev1.OpenStackDataPlaneDeployment, error) {
+	deployments := &dataplanev1.OpenStackDataPlaneDeploymentList{}
+	err := helper.GetClient().List(ctx, deployments, client.InNamespace(namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	running := []dataplanev1.OpenStackDataPlaneDeployment{}
+	for _, deployment := range deployments.Items {
+		if !deployment.DeletionTimestamp.IsZero() ||
+			GetDeploymentOutcome(&deployment) != dataplanev1.DeploymentOutcomeRunning {
+			continue
+		}
+		for _, nodeSet := range deployment.Spec.NodeSets {
+			if slices.Contains(nodeSets, nodeSet) {
+				running = append(running, deployment)
+				break
+			}
+		}
+	}
+	return running, nil
+}
+
+// PruneScheduleDeployments - deletes the finished Deployments of the schedule
+// beyond its HistoryLimit, oldest first, along with their ansible executions.
+// Protected Deployments are never deleted.
+func PruneScheduleDeployments(
+	ctx context.Context,
+	helper *helper.Helper,
+	instance *dataplanev1.OpenStackDataPlaneDeploymentSchedule,
+	deployments []dataplanev1.OpenStackDataPlaneDeployment,
+) error {
+	finished := []dataplanev1.OpenStackDataPlaneDeployment{}
+	for _, deployment := range deployments {
+		if deployment.DeletionTimestamp.IsZero() &&
+			GetDeploymentOutcome(&deployment) != dataplanev1.DeploymentOutcomeRunning {
+			finished = append(finished, deployment)
+		}
+	}
+	if len(finished) <= instance.Spec.HistoryLimit {
+		return nil
+	}
+
+	_, protected, err := getNamespaceDeployments(ctx, helper, instance.Namespace)
+	if err != nil {
+		return err
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[j].CreationTimestamp.Before(&finished[i].CreationTimestamp)
+	})
+	for idx := instance.Spec.HistoryLimit; idx < len(finished); idx++ {
+		if protected[finished[idx].Name] {
+			continue
+		}
+		err := DeleteDeployment(ctx, helper, &finished[idx])
+		if err != nil {
+			return err
+		}
+		helper.GetLogger().Info("Deleted Deployment beyond the schedule history limit", "deployment", finished[idx].Name)
+	}
+	return nil
+}
+
+// DeleteDeployment - deletes the Deployment and its ansible executions
+func DeleteDeployment(
+	ctx context.Context,
+	helper *helper.Helper,
+	deployment *dataplanev1.OpenStackDataPlaneDeployment,
+) error {
+	err := helper.GetClient().DeleteAllOf(ctx, &ansibleeev1.OpenStackAnsibleEE{},
+		client.InNamespace(deployment.Namespace),
+		client.MatchingLabels{"openstackdataplanedeployment": deployment.Name},
+		client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil {
+		return err
+	}
+	err = helper.GetClient().Delete(ctx, deployment,
+		client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -44,7 +44,19 @@ type ServiceYAML struct {
 
 // DeployService service deployment
 func (d *Deployer) DeployService(foundService dataplanev1.OpenStackDataPlaneService) error {
-	err := dataplaneutil.AnsibleExecution(
+	return d.deployServiceAttempt(foundService, 0)
+}
+
+// deployServiceAttempt service deployment for the given attempt, an attempt
+// of 0 updates the latest attempt
+func (d *Deployer) deployServiceAttempt(foundService dataplanev1.OpenStackDataPlaneService, attempt int) error {
+	err := d.recordServiceHash(foundService.Name, attempt > 0)
+	if err != nil {
+		d.Helper.GetLogger().Error(err, fmt.Sprintf("Unable to compute the configuration hash for %s", foundService.Name))
+		return err
+	}
+
+	err = dataplaneutil.AnsibleExecution(
 		d.Ctx,
 		d.Helper,
 		d.Deployment,
@@ -52,7 +64,9 @@ func (d *Deployer) DeployService(foundService dataplanev1.OpenStackDataPlaneServ
 		d.AnsibleSSHPrivateKeySecrets,
 		d.InventorySecrets,
 		d.AeeSpec,
-		d.NodeSet)
+		d.NodeSet,
+		d.Batch,
+		attempt)
 
 	if err != nil {
 		d.Helper.GetLogger().Error(err, fmt.Sprintf("Unable to execute Ansible for %s", foundService.Name))
@@ -89,6 +103,7 @@ func EnsureServices(ctx context.Context, helper *helper.Helper, instance *datapl
 		return err
 	}
 
+	serviceSpecs := map[string]*dataplanev1.OpenStackDataPlaneServiceSpec{}
 	for _, service := range services {
 
 		servicePath := path.Join(servicesPath, service.Name())
@@ -122,17 +137,6 @@ func EnsureServices(ctx context.Context, helper *helper.Helper, instance *datapl
 			helper.GetLogger().Info("service name must follow RFC1123")
 			return err
 		}
-		roleContainsService := false
-		for _, roleServiceName := range instance.Spec.Services {
-			if roleServiceName == serviceObjMeta.Name {
-				roleContainsService = true
-				break
-			}
-		}
-		if !roleContainsService {
-			helper.GetLogger().Info("Skipping ensure service since it is not a service on this role", "service", serviceObjMeta.Name)
-			continue
-		}
 
 		serviceObjSpec := &dataplanev1.OpenStackDataPlaneServiceSpec{}
 		err = serviceObj.Spec.Decode(serviceObjSpec)
@@ -140,10 +144,36 @@ func EnsureServices(ctx context.Context, helper *helper.Helper, instance *datapl
 			helper.GetLogger().Info("Service Spec decode error")
 			return err
 		}
+		serviceSpecs[serviceObjMeta.Name] = serviceObjSpec
+	}
+
+	// Services on the role and the services they depend on need to exist
+	requiredServices := map[string]bool{}
+	var requireService func(name string)
+	requireService = func(name string) {
+		if requiredServices[name] {
+			return
+		}
+		requiredServices[name] = true
+		if serviceSpec, ok := serviceSpecs[name]; ok {
+			for _, dep := range serviceSpec.DependsOn {
+				requireService(dep)
+			}
+		}
+	}
+	for _, roleServiceName := range instance.Spec.Services {
+		requireService(roleServiceName)
+	}
+
+	for serviceName, serviceObjSpec := range serviceSpecs {
+		if !requiredServices[serviceName] {
+			helper.GetLogger().Info("Skipping ensure service since it is not a service on this role", "service", serviceName)
+			continue
+		}
 
 		ensureService := &dataplanev1.OpenStackDataPlaneService{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      serviceObjMeta.Name,
+				Name:      serviceName,
 				Namespace: instance.Namespace,
 			},
 		}
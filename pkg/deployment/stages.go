@@ -0,0 +1,68 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"golang.org/x/exp/slices"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+)
+
+// GetNodeSetStages - groups the NodeSets in the ordered stages defined by the
+// nodeSetStrategy of the Deployment. All NodeSets of a stage are deployed at
+// the same time, and a stage is only started once the previous one is
+// deployed.
+func GetNodeSetStages(
+	instance *dataplanev1.OpenStackDataPlaneDeployment,
+	nodeSets []dataplanev1.OpenStackDataPlaneNodeSet,
+) [][]dataplanev1.OpenStackDataPlaneNodeSet {
+	strategy := instance.Spec.NodeSetStrategy
+	if strategy == nil || strategy.Type == "" || strategy.Type == dataplanev1.NodeSetStrategyParallel {
+		return [][]dataplanev1.OpenStackDataPlaneNodeSet{nodeSets}
+	}
+
+	stages := [][]dataplanev1.OpenStackDataPlaneNodeSet{}
+	if strategy.Type == dataplanev1.NodeSetStrategySerial {
+		for _, nodeSet := range nodeSets {
+			stages = append(stages, []dataplanev1.OpenStackDataPlaneNodeSet{nodeSet})
+		}
+		return stages
+	}
+
+	for _, wave := range strategy.Waves {
+		stage := []dataplanev1.OpenStackDataPlaneNodeSet{}
+		for _, nodeSet := range nodeSets {
+			if slices.Contains(wave, nodeSet.Name) {
+				stage = append(stage, nodeSet)
+			}
+		}
+		if len(stage) > 0 {
+			stages = append(stages, stage)
+		}
+	}
+
+	return stages
+}
+
+// GetNodeSetNames - returns the names of the NodeSets
+func GetNodeSetNames(nodeSets []dataplanev1.OpenStackDataPlaneNodeSet) []string {
+	names := make([]string, 0, len(nodeSets))
+	for _, nodeSet := range nodeSets {
+		names = append(names, nodeSet.Name)
+	}
+	return names
+}
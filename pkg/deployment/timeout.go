@@ -0,0 +1,127 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/dataplane-operator/pkg/metrics"
+	dataplaneutil "github.com/openstack-k8s-operators/dataplane-operator/pkg/util"
+	ansibleeev1 "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
+)
+
+// GetDeploymentTimeout - returns the timeout of the Deployment, 0 when not set
+func GetDeploymentTimeout(deployment *dataplanev1.OpenStackDataPlaneDeployment) time.Duration {
+	return time.Second * time.Duration(deployment.Spec.Timeout)
+}
+
+// GetDeploymentDeadline - returns the time the Deployment times out at, the
+// zero time when the Deployment has no timeout
+func GetDeploymentDeadline(deployment *dataplanev1.OpenStackDataPlaneDeployment) time.Time {
+	if deployment.Spec.Timeout <= 0 {
+		return time.Time{}
+	}
+	return deployment.GetRunStartTime().Add(GetDeploymentTimeout(deployment))
+}
+
+// DeploymentTimedOut - returns true when the Deployment exceeded its timeout
+func DeploymentTimedOut(deployment *dataplanev1.OpenStackDataPlaneDeployment) bool {
+	deadline := GetDeploymentDeadline(deployment)
+	return !deadline.IsZero() && !time.Now().Before(deadline)
+}
+
+// getExecutionDeadline returns the time the ansible execution of the service
+// times out at, and the timeout it was computed from. The earliest of the
+// service and Deployment timeouts applies.
+func (d *Deployer) getExecutionDeadline(
+	ansibleEE *ansibleeev1.OpenStackAnsibleEE,
+	service dataplanev1.OpenStackDataPlaneService,
+) (time.Time, time.Duration) {
+	deadline := GetDeploymentDeadline(d.Deployment)
+	timeout := GetDeploymentTimeout(d.Deployment)
+	if service.Spec.Timeout > 0 {
+		serviceTimeout := time.Second * time.Duration(service.Spec.Timeout)
+		serviceDeadline := ansibleEE.CreationTimestamp.Add(serviceTimeout)
+		if deadline.IsZero() || serviceDeadline.Before(deadline) {
+			deadline = serviceDeadline
+			timeout = serviceTimeout
+		}
+	}
+	return deadline, timeout
+}
+
+// getTimedOutAttempt returns the attempt of the service for the current batch
+// which was stopped because of a timeout, nil when there is none
+func (d *Deployer) getTimedOutAttempt(serviceName string) *dataplanev1.AnsibleExecutionAttempt {
+	attempts := d.Status.NodeSetAttempts[d.NodeSet.Name][serviceName]
+	for idx := range attempts {
+		if attempts[idx].Batch == d.Batch && attempts[idx].JobStatus == dataplanev1.AnsibleExecutionTimedOut {
+			return &attempts[idx]
+		}
+	}
+	return nil
+}
+
+// stopExecution deletes an ansible execution which exceeded its timeout, and
+// records it as timed out in the attempt history so it is not started again
+func (d *Deployer) stopExecution(
+	ansibleEE *ansibleeev1.OpenStackAnsibleEE,
+	service dataplanev1.OpenStackDataPlaneService,
+) error {
+	d.Helper.GetLogger().Info("Stopping ansible execution which exceeded its timeout", "execution", ansibleEE.Name)
+	err := d.Helper.GetClient().Delete(d.Ctx, ansibleEE, client.PropagationPolicy("Background"))
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return err
+	}
+	metrics.ObserveServiceExecution(d.Deployment.Namespace, d.NodeSet.Name, service.Name,
+		metrics.ResultTimedOut, time.Since(ansibleEE.CreationTimestamp.Time))
+	d.Recorder.Eventf(d.Deployment, corev1.EventTypeWarning, ServiceExecutionTimedOutReason,
+		"Service %s timed out on NodeSet %s, execution %s",
+		service.Name, d.NodeSet.Name, ansibleEE.Name)
+
+	finishTime := metav1.Now()
+	timedOut := dataplanev1.AnsibleExecutionAttempt{
+		Attempt:    dataplaneutil.GetAnsibleExecutionAttempt(ansibleEE),
+		Batch:      d.Batch,
+		Name:       ansibleEE.Name,
+		JobStatus:  dataplanev1.AnsibleExecutionTimedOut,
+		StartTime:  ansibleEE.CreationTimestamp,
+		FinishTime: &finishTime,
+	}
+	if d.Status.NodeSetAttempts == nil {
+		d.Status.NodeSetAttempts = make(map[string]map[string][]dataplanev1.AnsibleExecutionAttempt)
+	}
+	if d.Status.NodeSetAttempts[d.NodeSet.Name] == nil {
+		d.Status.NodeSetAttempts[d.NodeSet.Name] = make(map[string][]dataplanev1.AnsibleExecutionAttempt)
+	}
+	attempts := d.Status.NodeSetAttempts[d.NodeSet.Name][service.Name]
+	for idx := range attempts {
+		if attempts[idx].Name == ansibleEE.Name {
+			attempts[idx] = timedOut
+			return nil
+		}
+	}
+	d.Status.NodeSetAttempts[d.NodeSet.Name][service.Name] = append(attempts, timedOut)
+
+	return nil
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	ansibleeev1 "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
+)
+
+func TestDeploymentTimedOut(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name         string
+		timeout      int
+		created      time.Time
+		runStartTime time.Time
+		want         bool
+	}{
+		{"no timeout", 0, now.Add(-time.Hour), time.Time{}, false},
+		{"before the deadline", 600, now.Add(-time.Minute), time.Time{}, false},
+		{"after the deadline", 60, now.Add(-time.Hour), time.Time{}, true},
+		{"rerun after the deadline of the first run", 600, now.Add(-time.Hour), now.Add(-time.Minute), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployment := &dataplanev1.OpenStackDataPlaneDeployment{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(tt.created)},
+				Spec:       dataplanev1.OpenStackDataPlaneDeploymentSpec{Timeout: tt.timeout},
+			}
+			if !tt.runStartTime.IsZero() {
+				runStartTime := metav1.NewTime(tt.runStartTime)
+				deployment.Status.RunStartTime = &runStartTime
+			}
+			if got := DeploymentTimedOut(deployment); got != tt.want {
+				t.Errorf("DeploymentTimedOut() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetExecutionDeadline(t *testing.T) {
+	created := time.Now().Add(-time.Hour)
+	started := created.Add(10 * time.Minute)
+	tests := []struct {
+		name              string
+		deploymentTimeout int
+		serviceTimeout    int
+		wantDeadline      time.Time
+		wantTimeout       time.Duration
+	}{
+		{"no timeout", 0, 0, time.Time{}, 0},
+		{"Deployment timeout", 3600, 0, created.Add(time.Hour), time.Hour},
+		{"service timeout", 0, 600, started.Add(10 * time.Minute), 10 * time.Minute},
+		{"service timeout first", 3600, 600, started.Add(10 * time.Minute), 10 * time.Minute},
+		{"Deployment timeout first", 1200, 1800, created.Add(20 * time.Minute), 20 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Deployer{
+				Deployment: &dataplanev1.OpenStackDataPlaneDeployment{
+					ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)},
+					Spec:       dataplanev1.OpenStackDataPlaneDeploymentSpec{Timeout: tt.deploymentTimeout},
+				},
+			}
+			ansibleEE := &ansibleeev1.OpenStackAnsibleEE{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(started)},
+			}
+			service := dataplanev1.OpenStackDataPlaneService{
+				Spec: dataplanev1.OpenStackDataPlaneServiceSpec{Timeout: tt.serviceTimeout},
+			}
+			deadline, timeout := d.getExecutionDeadline(ansibleEE, service)
+			if !deadline.Equal(tt.wantDeadline) || timeout != tt.wantTimeout {
+				t.Errorf("getExecutionDeadline() = %s, %s, want %s, %s",
+					deadline, timeout, tt.wantDeadline, tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestStopExecution(t *testing.T) {
+	nodeSet := &dataplanev1.OpenStackDataPlaneNodeSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "edpm-compute", Namespace: "openstack"},
+	}
+	deployment := &dataplanev1.OpenStackDataPlaneDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "edpm-deployment", Namespace: "openstack"},
+	}
+	// Times are stored with a precision of a second
+	started := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	ansibleEE := &ansibleeev1.OpenStackAnsibleEE{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "install-os-edpm-deployment-edpm-compute",
+			Namespace:         "openstack",
+			CreationTimestamp: started,
+		},
+		Status: ansibleeev1.OpenStackAnsibleEEStatus{JobStatus: ansibleeev1.JobStatusRunning},
+	}
+	service := dataplanev1.OpenStackDataPlaneService{ObjectMeta: metav1.ObjectMeta{Name: "install-os"}}
+	d := &Deployer{
+		Ctx:        context.Background(),
+		Helper:     newTestHelper(t, deployment, nodeSet, ansibleEE),
+		NodeSet:    nodeSet,
+		Deployment: deployment,
+		Status:     &deployment.Status,
+		Recorder:   record.NewFakeRecorder(10),
+	}
+	// The running attempt is replaced by the timed out one
+	d.Status.NodeSetAttempts = map[string]map[string][]dataplanev1.AnsibleExecutionAttempt{
+		"edpm-compute": {
+			"install-os": {{Attempt: 1, Name: ansibleEE.Name, JobStatus: ansibleeev1.JobStatusRunning, StartTime: started}},
+		},
+	}
+
+	if d.getTimedOutAttempt("install-os") != nil {
+		t.Fatal("running execution reported as timed out")
+	}
+	if err := d.stopExecution(ansibleEE, service); err != nil {
+		t.Fatal(err)
+	}
+
+	err := d.Helper.GetClient().Get(d.Ctx, client.ObjectKeyFromObject(ansibleEE), &ansibleeev1.OpenStackAnsibleEE{})
+	if !k8s_errors.IsNotFound(err) {
+		t.Errorf("got error %v getting the stopped execution, want not found", err)
+	}
+	attempts := d.Status.NodeSetAttempts["edpm-compute"]["install-os"]
+	if len(attempts) != 1 {
+		t.Fatalf("got %d attempts, want 1", len(attempts))
+	}
+	if attempts[0].JobStatus != dataplanev1.AnsibleExecutionTimedOut || attempts[0].FinishTime == nil ||
+		!attempts[0].StartTime.Equal(&started) {
+		t.Errorf("got attempt %+v, want timed out since %s", attempts[0], started)
+	}
+	if timedOut := d.getTimedOutAttempt("install-os"); timedOut == nil || timedOut.Name != ansibleEE.Name {
+		t.Errorf("got timed out attempt %v, want %s", timedOut, ansibleEE.Name)
+	}
+	wantEvent := "Warning " + ServiceExecutionTimedOutReason +
+		" Service install-os timed out on NodeSet edpm-compute, execution " + ansibleEE.Name
+	if event := <-d.Recorder.(*record.FakeRecorder).Events; event != wantEvent {
+		t.Errorf("got event %q, want %q", event, wantEvent)
+	}
+
+	// Stopping an execution already deleted records it too
+	d.Status.NodeSetAttempts = nil
+	if err := d.stopExecution(ansibleEE, service); err != nil {
+		t.Fatal(err)
+	}
+	if timedOut := d.getTimedOutAttempt("install-os"); timedOut == nil {
+		t.Error("execution not recorded as timed out")
+	}
+}
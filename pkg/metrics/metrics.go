@@ -0,0 +1,162 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics defines the Prometheus metrics of the dataplane deployments,
+// registered with the controller-runtime metrics registry
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+)
+
+const (
+	// ResultSucceeded - result of a succeeded ansible execution
+	ResultSucceeded = "succeeded"
+	// ResultFailed - result of a failed ansible execution
+	ResultFailed = "failed"
+	// ResultTimedOut - result of an ansible execution stopped after its
+	// timeout
+	ResultTimedOut = "timedout"
+)
+
+var (
+	serviceExecutionDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "dataplane_service_execution_duration_seconds",
+			Help: "Duration of the ansible executions of the services",
+			// From 30 seconds to about 4 hours
+			Buckets: prometheus.ExponentialBuckets(30, 2, 10),
+		},
+		[]string{"namespace", "nodeset", "service", "result"},
+	)
+
+	serviceExecutionFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dataplane_service_execution_failures_total",
+			Help: "Number of failed ansible executions of the services, including the ones which timed out",
+		},
+		[]string{"namespace", "nodeset", "service"},
+	)
+
+	serviceExecutionRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dataplane_service_execution_retries_total",
+			Help: "Number of ansible executions of the services retried after a failure",
+		},
+		[]string{"namespace", "nodeset", "service"},
+	)
+
+	nodeSetNodes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "dataplane_nodeset_nodes",
+			Help: "Number of nodes of the NodeSet",
+		},
+		[]string{"namespace", "nodeset"},
+	)
+
+	nodeSetConfigDrift = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "dataplane_nodeset_config_drift",
+			Help: "1 when the configuration of the NodeSet differs from the deployed one, 0 otherwise",
+		},
+		[]string{"namespace", "nodeset"},
+	)
+
+	executionsInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "dataplane_ansibleee_executions_in_flight",
+			Help: "Number of ansible executions of the Deployment which are pending or running",
+		},
+		[]string{"namespace", "deployment"},
+	)
+
+	nodeCertificateExpiry = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "dataplane_node_certificate_expiry_timestamp_seconds",
+			Help: "Time the TLS certificate of a service on a node expires at, in seconds since the epoch",
+		},
+		[]string{"namespace", "nodeset", "service", "hostname"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		serviceExecutionDuration,
+		serviceExecutionFailures,
+		serviceExecutionRetries,
+		nodeSetNodes,
+		nodeSetConfigDrift,
+		executionsInFlight,
+		nodeCertificateExpiry,
+	)
+}
+
+// ObserveServiceExecution - records the duration and the result of a finished
+// ansible execution of a service. Failures are also counted.
+func ObserveServiceExecution(namespace, nodeSet, service, result string, duration time.Duration) {
+	serviceExecutionDuration.WithLabelValues(namespace, nodeSet, service, result).Observe(duration.Seconds())
+	if result != ResultSucceeded {
+		serviceExecutionFailures.WithLabelValues(namespace, nodeSet, service).Inc()
+	}
+}
+
+// RecordServiceExecutionRetry - counts a retry of the ansible execution of a
+// service
+func RecordServiceExecutionRetry(namespace, nodeSet, service string) {
+	serviceExecutionRetries.WithLabelValues(namespace, nodeSet, service).Inc()
+}
+
+// SetNodeSetMetrics - records the number of nodes of the NodeSet, and whether
+// its configuration differs from the deployed one
+func SetNodeSetMetrics(instance *dataplanev1.OpenStackDataPlaneNodeSet) {
+	nodeSetNodes.WithLabelValues(instance.Namespace, instance.Name).Set(float64(len(instance.Spec.Nodes)))
+	drift := 0.0
+	if instance.Status.ConfigHash != instance.Status.DeployedConfigHash {
+		drift = 1.0
+	}
+	nodeSetConfigDrift.WithLabelValues(instance.Namespace, instance.Name).Set(drift)
+}
+
+// DeleteNodeSetMetrics - removes the metrics of a deleted NodeSet
+func DeleteNodeSetMetrics(namespace, nodeSet string) {
+	labels := prometheus.Labels{"namespace": namespace, "nodeset": nodeSet}
+	nodeSetNodes.Delete(labels)
+	nodeSetConfigDrift.Delete(labels)
+	nodeCertificateExpiry.DeletePartialMatch(labels)
+}
+
+// SetExecutionsInFlight - records the number of pending or running ansible
+// executions of the Deployment
+func SetExecutionsInFlight(namespace, deployment string, count int) {
+	executionsInFlight.WithLabelValues(namespace, deployment).Set(float64(count))
+}
+
+// DeleteDeploymentMetrics - removes the metrics of a finished or deleted
+// Deployment
+func DeleteDeploymentMetrics(namespace, deployment string) {
+	executionsInFlight.Delete(prometheus.Labels{"namespace": namespace, "deployment": deployment})
+}
+
+// SetNodeCertificateExpiry - records the time the TLS certificate of a
+// service on a node expires at
+func SetNodeCertificateExpiry(namespace, nodeSet, service, hostname string, expiry time.Time) {
+	nodeCertificateExpiry.WithLabelValues(namespace, nodeSet, service, hostname).Set(float64(expiry.Unix()))
+}
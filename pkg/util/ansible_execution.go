@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -47,6 +48,8 @@ func AnsibleExecution(
 	inventorySecrets map[string]string,
 	aeeSpec *dataplanev1.AnsibleEESpec,
 	nodeSet client.Object,
+	batch int,
+	attempt int,
 ) error {
 	var err error
 	var cmdLineArguments strings.Builder
@@ -59,12 +62,18 @@ func AnsibleExecution(
 
 	ansibleEEMounts := storage.VolMounts{}
 
-	executionName, labels := GetAnsibleExecutionNameAndLabels(service, deployment.GetName(), nodeSet.GetName())
+	executionName, labels := GetAnsibleExecutionDeploymentNameAndLabels(service, deployment, nodeSet.GetName(), batch)
 	ansibleEE, err := GetAnsibleExecution(ctx, helper, deployment, labels)
 	if err != nil && !k8serrors.IsNotFound(err) {
 		return err
 	}
-	if ansibleEE == nil {
+	// An attempt of 0 updates the latest attempt, a higher attempt than the
+	// latest one creates a new execution
+	if ansibleEE == nil || GetAnsibleExecutionAttempt(ansibleEE) < attempt {
+		if attempt < 1 {
+			attempt = 1
+		}
+		executionName, labels = GetAnsibleExecutionAttemptNameAndLabels(executionName, labels, attempt)
 		ansibleEE = &ansibleeev1.OpenStackAnsibleEE{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      executionName,
@@ -94,6 +103,9 @@ func AnsibleExecution(
 		if len(aeeSpec.AnsibleSkipTags) > 0 {
 			fmt.Fprintf(&cmdLineArguments, "--skip-tags %s ", aeeSpec.AnsibleSkipTags)
 		}
+		if deployment.IsCheckMode() {
+			fmt.Fprintf(&cmdLineArguments, "--check --diff ")
+		}
 		if len(aeeSpec.ServiceAccountName) > 0 {
 			ansibleEE.Spec.ServiceAccountName = aeeSpec.ServiceAccountName
 		}
@@ -229,7 +241,8 @@ func AnsibleExecution(
 // "openstackdataplaneservice":    <serviceName>,
 // "openstackdataplanedeployment": <deploymentName>,
 // "openstackdataplanenodeset":    <nodeSetName>,
-// If none or more than one is found, return nil and error
+// When several attempts are found, the latest attempt is returned.
+// If none or more than one latest attempt is found, return nil and error
 func GetAnsibleExecution(ctx context.Context,
 	helper *helper.Helper, obj client.Object, labelSelector map[string]string) (*ansibleeev1.OpenStackAnsibleEE, error) {
 	var err error
@@ -247,18 +260,39 @@ func GetAnsibleExecution(ctx context.Context,
 		return nil, err
 	}
 
-	var ansibleEE *ansibleeev1.OpenStackAnsibleEE
 	if len(ansibleEEs.Items) == 0 {
 		return nil, k8serrors.NewNotFound(appsv1.Resource("OpenStackAnsibleEE"), fmt.Sprintf("with label %s", labelSelector))
-	} else if len(ansibleEEs.Items) == 1 {
-		ansibleEE = &ansibleEEs.Items[0]
-	} else {
+	}
+
+	var ansibleEE *ansibleeev1.OpenStackAnsibleEE
+	latestAttempt := 0
+	for idx := range ansibleEEs.Items {
+		attempt := GetAnsibleExecutionAttempt(&ansibleEEs.Items[idx])
+		if attempt == latestAttempt {
+			ansibleEE = nil
+		}
+		if attempt > latestAttempt {
+			latestAttempt = attempt
+			ansibleEE = &ansibleEEs.Items[idx]
+		}
+	}
+	if ansibleEE == nil {
 		return nil, fmt.Errorf("multiple OpenStackAnsibleEE's found with label %s", labelSelector)
 	}
 
 	return ansibleEE, nil
 }
 
+// GetAnsibleExecutionAttempt returns the attempt number of an OpenStackAnsibleEE.
+// Executions created without an attempt label are the first attempt.
+func GetAnsibleExecutionAttempt(ansibleEE *ansibleeev1.OpenStackAnsibleEE) int {
+	attempt, err := strconv.Atoi(ansibleEE.Labels[AnsibleExecutionAttemptLabel])
+	if err != nil || attempt < 1 {
+		return 1
+	}
+	return attempt
+}
+
 // getAnsibleExecutionNamePrefix compute the name of the AnsibleEE
 func getAnsibleExecutionNamePrefix(serviceName string) string {
 	var executionNamePrefix string
@@ -289,3 +323,84 @@ func GetAnsibleExecutionNameAndLabels(service *dataplanev1.OpenStackDataPlaneSer
 	}
 	return executionName, labels
 }
+
+// GetAnsibleExecutionBatchNameAndLabels Name and Labels of the AnsibleEE
+// deploying a batch of the nodes of a NodeSet. A batch of 0 means the whole
+// NodeSet is deployed at once.
+func GetAnsibleExecutionBatchNameAndLabels(service *dataplanev1.OpenStackDataPlaneService,
+	deploymentName string,
+	nodeSetName string,
+	batch int) (string, map[string]string) {
+	executionName, labels := GetAnsibleExecutionNameAndLabels(service, deploymentName, nodeSetName)
+	if batch == 0 {
+		return executionName, labels
+	}
+
+	executionName = appendAnsibleExecutionNameSuffix(executionName, fmt.Sprintf("-batch-%d", batch))
+	labels["openstackdataplanebatch"] = strconv.Itoa(batch)
+
+	return executionName, labels
+}
+
+// GetAnsibleExecutionRunNameAndLabels Name and Labels of an AnsibleEE for the
+// given run of its Deployment. The first run, 0, keeps the name of the
+// execution.
+func GetAnsibleExecutionRunNameAndLabels(executionName string,
+	labels map[string]string,
+	run int) (string, map[string]string) {
+	if run == 0 {
+		return executionName, labels
+	}
+
+	executionName = appendAnsibleExecutionNameSuffix(executionName, fmt.Sprintf("-run-%d", run))
+	labels[AnsibleExecutionRunLabel] = strconv.Itoa(run)
+
+	return executionName, labels
+}
+
+// GetAnsibleExecutionDeploymentNameAndLabels Name and Labels of the AnsibleEE
+// deploying a batch of the nodes of a NodeSet for the current run of the
+// Deployment
+func GetAnsibleExecutionDeploymentNameAndLabels(service *dataplanev1.OpenStackDataPlaneService,
+	deployment *dataplanev1.OpenStackDataPlaneDeployment,
+	nodeSetName string,
+	batch int) (string, map[string]string) {
+	executionName, labels := GetAnsibleExecutionBatchNameAndLabels(service, deployment.Name, nodeSetName, batch)
+	return GetAnsibleExecutionRunNameAndLabels(executionName, labels, deployment.Status.Run)
+}
+
+// GetDeploymentExecutionLabels Labels of the AnsibleEEs of the current run of
+// the Deployment
+func GetDeploymentExecutionLabels(deployment *dataplanev1.OpenStackDataPlaneDeployment) map[string]string {
+	labels := map[string]string{"openstackdataplanedeployment": deployment.Name}
+	if deployment.Status.Run > 0 {
+		labels[AnsibleExecutionRunLabel] = strconv.Itoa(deployment.Status.Run)
+	}
+	return labels
+}
+
+// GetAnsibleExecutionAttemptNameAndLabels Name and Labels of the given
+// attempt of an AnsibleEE. The first attempt keeps the name of the execution.
+func GetAnsibleExecutionAttemptNameAndLabels(executionName string,
+	labels map[string]string,
+	attempt int) (string, map[string]string) {
+	attemptLabels := make(map[string]string, len(labels)+1)
+	for key, value := range labels {
+		attemptLabels[key] = value
+	}
+	attemptLabels[AnsibleExecutionAttemptLabel] = strconv.Itoa(attempt)
+	if attempt > 1 {
+		executionName = appendAnsibleExecutionNameSuffix(executionName, fmt.Sprintf("-attempt-%d", attempt))
+	}
+
+	return executionName, attemptLabels
+}
+
+// appendAnsibleExecutionNameSuffix appends the suffix to the execution name,
+// truncating the name so the suffix is kept within the max length
+func appendAnsibleExecutionNameSuffix(executionName string, suffix string) string {
+	if len(executionName)+len(suffix) > AnsibleExcecutionNameLabelLen {
+		executionName = executionName[:AnsibleExcecutionNameLabelLen-len(suffix)]
+	}
+	return fmt.Sprintf("%s%s", executionName, suffix)
+}
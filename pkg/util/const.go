@@ -21,4 +21,9 @@ const (
 	AnsibleExecutionServiceNameLen = 53
 	// AnsibleExcecutionNameLabelLen max length for the ansibleEE execution name
 	AnsibleExcecutionNameLabelLen = 63
+	// AnsibleExecutionAttemptLabel label holding the attempt number of an ansibleEE execution
+	AnsibleExecutionAttemptLabel = "openstackdataplaneattempt"
+	// AnsibleExecutionRunLabel label holding the run of the Deployment an
+	// ansibleEE execution was created for, only set for reruns
+	AnsibleExecutionRunLabel = "openstackdataplanerun"
 )
@@ -3,17 +3,22 @@ package functional
 import (
 	"fmt"
 
-	. "github.com/onsi/gomega" //revive:disable:dot-imports
+	. "github.com/onsi/ginkgo/v2" //revive:disable:dot-imports
+	. "github.com/onsi/gomega"    //revive:disable:dot-imports
 	"gopkg.in/yaml.v3"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+	dataplaneutil "github.com/openstack-k8s-operators/dataplane-operator/pkg/util"
 	infrav1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	"github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
+	baremetalv1 "github.com/openstack-k8s-operators/openstack-baremetal-operator/api/v1beta1"
 )
 
 var DefaultEdpmServiceAnsibleVarList = []string{
@@ -40,6 +45,12 @@ func CreateDataplaneDeployment(name types.NamespacedName, spec map[string]interf
 	return th.CreateUnstructured(instance)
 }
 
+// Create OpenStackDataPlaneDeploymentSchedule in k8s and test that no errors occur
+func CreateDataplaneDeploymentSchedule(name types.NamespacedName, spec map[string]interface{}) *unstructured.Unstructured {
+	instance := DefaultDataplaneDeploymentScheduleTemplate(name, spec)
+	return th.CreateUnstructured(instance)
+}
+
 // Create an OpenStackDataPlaneService with a given NamespacedName, assert on success
 func CreateDataplaneService(name types.NamespacedName, globalService bool) *unstructured.Unstructured {
 	var raw map[string]interface{}
@@ -51,6 +62,13 @@ func CreateDataplaneService(name types.NamespacedName, globalService bool) *unst
 	return th.CreateUnstructured(raw)
 }
 
+// Create an OpenStackDataPlaneService with a given NamespacedName and spec, assert on success
+func CreateDataplaneServiceWithSpec(name types.NamespacedName, spec map[string]interface{}) *unstructured.Unstructured {
+	raw := DefaultDataplaneService(name)
+	raw["spec"] = spec
+	return th.CreateUnstructured(raw)
+}
+
 // Build CustomServiceImageSpec struct with empty `Nodes` list
 func CustomServiceImageSpec() map[string]interface{} {
 
@@ -200,6 +218,52 @@ func SimulateIPSetComplete(name types.NamespacedName) {
 	th.Logger.Info("Simulated DB completed", "on", name)
 }
 
+// SimulateBaremetalSetReady - Simulates the provisioning of the
+// OpenStackBaremetalSet, which has the same name as its NodeSet
+func SimulateBaremetalSetReady(name types.NamespacedName) {
+	Eventually(func(g Gomega) {
+		baremetal := &baremetalv1.OpenStackBaremetalSet{}
+		g.Expect(th.K8sClient.Get(th.Ctx, name, baremetal)).To(Succeed())
+		baremetal.Status.Conditions.MarkTrue(
+			condition.ReadyCondition,
+			condition.ReadyMessage)
+		// This can return conflict so we have the gomega.Eventually block to retry
+		g.Expect(th.K8sClient.Status().Update(th.Ctx, baremetal)).To(Succeed())
+	}, th.Timeout, th.Interval).Should(Succeed())
+}
+
+// CreateServiceSecrets - Creates the Secrets the default services of the
+// NodeSets mount, they are deleted when the test finishes
+func CreateServiceSecrets(namespace string) {
+	for _, name := range []string{
+		"neutron-ovn-metadata-agent-neutron-config",
+		"nova-metadata-neutron-config",
+		"nova-cell1-compute-config",
+		"ceilometer-compute-config-data",
+	} {
+		DeferCleanup(th.DeleteInstance, th.CreateSecret(
+			types.NamespacedName{Namespace: namespace, Name: name},
+			map[string][]byte{"fake_keys": []byte("blih")}))
+	}
+	DeferCleanup(th.DeleteInstance, th.CreateSecret(
+		types.NamespacedName{Namespace: namespace, Name: "nova-migration-ssh-key"},
+		map[string][]byte{
+			"ssh-privatekey": []byte("fake-ssh-private-key"),
+			"ssh-publickey":  []byte("fake-ssh-public-key"),
+		}))
+}
+
+// CreateOVNControllerConfigMap - Creates the ConfigMap of the OVN service
+func CreateOVNControllerConfigMap(namespace string) {
+	ovnConfigMapName := types.NamespacedName{
+		Namespace: namespace,
+		Name:      "ovncontroller-config",
+	}
+	th.CreateConfigMap(ovnConfigMapName, map[string]interface{}{
+		"ovsdb-config": "test-ovn-config",
+	})
+}
+
 // Build OpenStackDataPlaneNodeSet struct and fill it with preset values
 func DefaultDataplaneNodeSetTemplate(name types.NamespacedName, spec map[string]interface{}) map[string]interface{} {
 	return map[string]interface{}{
@@ -229,6 +293,21 @@ func DefaultDataplaneDeploymentTemplate(name types.NamespacedName, spec map[stri
 	}
 }
 
+// Build OpenStackDataPlaneDeploymentSchedule struct and fill it with preset values
+func DefaultDataplaneDeploymentScheduleTemplate(name types.NamespacedName, spec map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+
+		"apiVersion": "dataplane.openstack.org/v1beta1",
+		"kind":       "OpenStackDataPlaneDeploymentSchedule",
+
+		"metadata": map[string]interface{}{
+			"name":      name.Name,
+			"namespace": name.Namespace,
+		},
+		"spec": spec,
+	}
+}
+
 func DefaultNetConfig(name types.NamespacedName, spec map[string]interface{}) map[string]interface{} {
 	return map[string]interface{}{
 		"apiVersion": "network.openstack.org/v1beta1",
@@ -285,6 +364,16 @@ func GetDataplaneDeployment(name types.NamespacedName) *dataplanev1.OpenStackDat
 	return instance
 }
 
+// Retrieve OpenStackDataPlaneDeploymentSchedule and check for errors
+func GetDataplaneDeploymentSchedule(name types.NamespacedName) *dataplanev1.OpenStackDataPlaneDeploymentSchedule {
+	instance := &dataplanev1.OpenStackDataPlaneDeploymentSchedule{}
+	Eventually(func(g Gomega) error {
+		g.Expect(k8sClient.Get(ctx, name, instance)).Should(Succeed())
+		return nil
+	}, timeout, interval).Should(Succeed())
+	return instance
+}
+
 // Retrieve OpenStackDataPlaneDeployment and check for errors
 func GetDataplaneNodeSet(name types.NamespacedName) *dataplanev1.OpenStackDataPlaneNodeSet {
 	instance := &dataplanev1.OpenStackDataPlaneNodeSet{}
@@ -317,6 +406,11 @@ func DataplaneDeploymentConditionGetter(name types.NamespacedName) condition.Con
 	return instance.Status.Conditions
 }
 
+func DataplaneDeploymentScheduleConditionGetter(name types.NamespacedName) condition.Conditions {
+	instance := GetDataplaneDeploymentSchedule(name)
+	return instance.Status.Conditions
+}
+
 func GetAnsibleee(name types.NamespacedName) *v1beta1.OpenStackAnsibleEE {
 	instance := &v1beta1.OpenStackAnsibleEE{}
 	Eventually(func(g Gomega) {
@@ -325,6 +419,65 @@ func GetAnsibleee(name types.NamespacedName) *v1beta1.OpenStackAnsibleEE {
 	return instance
 }
 
+// Set the JobStatus of the OpenStackAnsibleEE deploying the service of the
+// Deployment on the given batch of the NodeSet, 0 being the whole NodeSet
+func SetAnsibleeeJobStatus(deploymentName types.NamespacedName, nodeSetName string, serviceName string, batch int, jobStatus string) {
+	service := GetService(types.NamespacedName{Name: serviceName, Namespace: deploymentName.Namespace})
+	aeeName, _ := dataplaneutil.GetAnsibleExecutionBatchNameAndLabels(service, deploymentName.Name, nodeSetName, batch)
+	Eventually(func(g Gomega) {
+		ansibleEE := &v1beta1.OpenStackAnsibleEE{}
+		g.Expect(k8sClient.Get(ctx, types.NamespacedName{Name: aeeName, Namespace: deploymentName.Namespace}, ansibleEE)).Should(Succeed())
+		ansibleEE.Status.JobStatus = jobStatus
+		g.Expect(k8sClient.Status().Update(ctx, ansibleEE)).Should(Succeed())
+	}, timeout, interval).Should(Succeed())
+}
+
+// Get the reasons of the events recorded on the object with the given name
+func GetEventReasons(name types.NamespacedName) []string {
+	events := &corev1.EventList{}
+	Expect(k8sClient.List(ctx, events, client.InNamespace(name.Namespace))).Should(Succeed())
+	reasons := []string{}
+	for _, event := range events.Items {
+		if event.InvolvedObject.Name == name.Name {
+			reasons = append(reasons, event.Reason)
+		}
+	}
+	return reasons
+}
+
+// Get the value of the metric with the given name and labels from the
+// controller-runtime metrics registry. The sample count is returned for a
+// histogram.
+func GetMetricValue(name string, labels map[string]string) (float64, bool) {
+	families, err := metrics.Registry.Gather()
+	Expect(err).ShouldNot(HaveOccurred())
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			matches := 0
+			for _, label := range metric.GetLabel() {
+				if labels[label.GetName()] == label.GetValue() {
+					matches++
+				}
+			}
+			if matches != len(labels) {
+				continue
+			}
+			switch {
+			case metric.GetCounter() != nil:
+				return metric.GetCounter().GetValue(), true
+			case metric.GetHistogram() != nil:
+				return float64(metric.GetHistogram().GetSampleCount()), true
+			default:
+				return metric.GetGauge().GetValue(), true
+			}
+		}
+	}
+	return 0, false
+}
+
 // Delete resources
 
 // Delete namespace from k8s, check for errors
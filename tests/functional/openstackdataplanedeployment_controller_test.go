@@ -15,9 +15,11 @@ import (
 	ansibleeev1 "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
 	baremetalv1 "github.com/openstack-k8s-operators/openstack-baremetal-operator/api/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var _ = Describe("Dataplane Deployment Test", func() {
@@ -664,4 +666,1887 @@ var _ = Describe("Dataplane Deployment Test", func() {
 			)
 		})
 	})
+
+	When("A dataplaneDeployment is created with a rolloutStrategy", func() {
+		BeforeEach(func() {
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			CreateDataplaneService(dataplaneServiceName, false)
+			CreateDataplaneService(dataplaneGlobalServiceName, true)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteService, dataplaneGlobalServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)))
+			deploymentSpec := DefaultDataPlaneDeploymentSpec()
+			deploymentSpec["rolloutStrategy"] = map[string]interface{}{
+				"batchSize": 1,
+			}
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, deploymentSpec))
+		})
+
+		It("should deploy each batch with a limit", func() {
+
+			CreateOVNControllerConfigMap(namespace)
+
+			nodeSet := *GetDataplaneNodeSet(dataplaneNodeSetName)
+
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			for _, serviceName := range nodeSet.Spec.Services {
+				dataplaneServiceName := types.NamespacedName{
+					Name:      serviceName,
+					Namespace: namespace,
+				}
+				service := GetService(dataplaneServiceName)
+				deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+				//Retrieve the AnsibleEE of the first batch and set JobStatus to Successful
+				aeeName, _ := dataplaneutil.GetAnsibleExecutionBatchNameAndLabels(
+					service, deployment.GetName(), nodeSet.GetName(), 1)
+				Eventually(func(g Gomega) {
+					ansibleeeName := types.NamespacedName{
+						Name:      aeeName,
+						Namespace: dataplaneDeploymentName.Namespace,
+					}
+					ansibleEE := &ansibleeev1.OpenStackAnsibleEE{}
+					g.Expect(th.K8sClient.Get(th.Ctx, ansibleeeName, ansibleEE)).To(Succeed())
+					g.Expect(ansibleEE.Labels).To(HaveKeyWithValue("openstackdataplanebatch", "1"))
+					g.Expect(ansibleEE.Spec.CmdLine).To(Equal("--limit edpm-bm-compute-1"))
+					ansibleEE.Status.JobStatus = ansibleeev1.JobStatusSucceeded
+
+					g.Expect(th.K8sClient.Status().Update(th.Ctx, ansibleEE)).To(Succeed())
+				}, th.Timeout, th.Interval).Should(Succeed())
+			}
+
+			Eventually(func(g Gomega) {
+				deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+				nsConditions := deployment.Status.NodeSetConditions[dataplaneNodeSetName.Name]
+				g.Expect(nsConditions.IsTrue(condition.Type("Batch1DeploymentReady"))).To(BeTrue())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			th.ExpectCondition(
+				dataplaneDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+	})
+
+	When("A dataplaneDeployment is created with two NodeSets and a Serial nodeSetStrategy", func() {
+		var alphaNodeSetName types.NamespacedName
+		var betaNodeSetName types.NamespacedName
+
+		BeforeEach(func() {
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+
+			alphaNodeSetName = types.NamespacedName{
+				Name:      "alpha-nodeset",
+				Namespace: namespace,
+			}
+			betaNodeSetName = types.NamespacedName{
+				Name:      "beta-nodeset",
+				Namespace: namespace,
+			}
+
+			CreateDataplaneService(dataplaneServiceName, false)
+			CreateDataplaneService(dataplaneGlobalServiceName, true)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteService, dataplaneGlobalServiceName)
+
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+
+			betaNodeSetSpec := DefaultDataPlaneNodeSetSpec(betaNodeSetName.Name)
+			betaNodeSetSpec["services"] = []string{
+				"foo-service",
+			}
+			betaNodeSetSpec["nodes"] = map[string]interface{}{
+				fmt.Sprintf("%s-node-1", betaNodeSetName.Name): map[string]interface{}{
+					"hostname": "edpm-bm-compute-2",
+					"networks": []map[string]interface{}{{
+						"name":       "CtlPlane",
+						"fixedIP":    "172.20.12.77",
+						"subnetName": "ctlplane_subnet",
+					},
+					},
+				},
+			}
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(alphaNodeSetName, DefaultDataPlaneNodeSetSpec(alphaNodeSetName.Name)))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(betaNodeSetName, betaNodeSetSpec))
+
+			deploymentSpec := map[string]interface{}{
+				"nodeSets": []string{
+					"alpha-nodeset",
+					"beta-nodeset",
+				},
+				"nodeSetStrategy": map[string]interface{}{
+					"type": "Serial",
+				},
+			}
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneMultiNodesetDeploymentName, deploymentSpec))
+		})
+
+		It("Should deploy the NodeSets one after the other", func() {
+			CreateOVNControllerConfigMap(namespace)
+
+			nodeSetAlpha := *GetDataplaneNodeSet(alphaNodeSetName)
+			nodeSetBeta := *GetDataplaneNodeSet(betaNodeSetName)
+
+			SimulateBaremetalSetReady(alphaNodeSetName)
+			SimulateBaremetalSetReady(betaNodeSetName)
+
+			service := GetService(dataplaneServiceName)
+			betaAeeName, _ := dataplaneutil.GetAnsibleExecutionNameAndLabels(
+				service, dataplaneMultiNodesetDeploymentName.Name, nodeSetBeta.GetName())
+			betaAeeNamespacedName := types.NamespacedName{
+				Name:      betaAeeName,
+				Namespace: dataplaneMultiNodesetDeploymentName.Namespace,
+			}
+
+			// The beta NodeSet waits for the alpha NodeSet
+			Eventually(func(g Gomega) {
+				deployment := GetDataplaneDeployment(dataplaneMultiNodesetDeploymentName)
+				nsConditions := deployment.Status.NodeSetConditions[betaNodeSetName.Name]
+				g.Expect(nsConditions.IsFalse(dataplanev1.NodeSetDeploymentReadyCondition)).To(BeTrue())
+				g.Expect(nsConditions.Get(dataplanev1.NodeSetDeploymentReadyCondition).Message).To(
+					Equal(fmt.Sprintf(dataplanev1.NodeSetDeploymentWaitingForStageMessage, alphaNodeSetName.Name)))
+			}, th.Timeout, th.Interval).Should(Succeed())
+			Expect(th.K8sClient.Get(th.Ctx, betaAeeNamespacedName, &ansibleeev1.OpenStackAnsibleEE{})).ShouldNot(Succeed())
+
+			for _, serviceName := range nodeSetAlpha.Spec.Services {
+				service := GetService(types.NamespacedName{
+					Name:      serviceName,
+					Namespace: namespace,
+				})
+				aeeName, _ := dataplaneutil.GetAnsibleExecutionNameAndLabels(
+					service, dataplaneMultiNodesetDeploymentName.Name, nodeSetAlpha.GetName())
+				Eventually(func(g Gomega) {
+					ansibleEE := GetAnsibleee(types.NamespacedName{
+						Name:      aeeName,
+						Namespace: dataplaneMultiNodesetDeploymentName.Namespace,
+					})
+					ansibleEE.Status.JobStatus = ansibleeev1.JobStatusSucceeded
+					g.Expect(th.K8sClient.Status().Update(th.Ctx, ansibleEE)).To(Succeed())
+				}, th.Timeout, th.Interval).Should(Succeed())
+			}
+
+			// The beta NodeSet is started once the alpha NodeSet is deployed
+			Eventually(func(g Gomega) {
+				ansibleEE := GetAnsibleee(betaAeeNamespacedName)
+				ansibleEE.Status.JobStatus = ansibleeev1.JobStatusSucceeded
+				g.Expect(th.K8sClient.Status().Update(th.Ctx, ansibleEE)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			th.ExpectCondition(
+				dataplaneMultiNodesetDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+	})
+
+	When("A dataplaneDeployment is created with three NodeSets and a Waves nodeSetStrategy", func() {
+		var nodeSetNames []types.NamespacedName
+
+		BeforeEach(func() {
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			CreateDataplaneService(dataplaneServiceName, false)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+
+			nodeSetNames = []types.NamespacedName{}
+			for idx, name := range []string{"alpha-nodeset", "beta-nodeset", "gamma-nodeset"} {
+				nodeSetName := types.NamespacedName{Name: name, Namespace: namespace}
+				nodeSetNames = append(nodeSetNames, nodeSetName)
+				nodeSetSpec := DefaultDataPlaneNodeSetSpec(name)
+				nodeSetSpec["services"] = []string{"foo-service"}
+				nodeSetSpec["nodes"] = map[string]interface{}{
+					fmt.Sprintf("%s-node-1", name): map[string]interface{}{
+						"hostname": fmt.Sprintf("edpm-bm-compute-%d", idx+1),
+						"networks": []map[string]interface{}{{
+							"name":       "CtlPlane",
+							"fixedIP":    fmt.Sprintf("172.20.12.%d", 76+idx),
+							"subnetName": "ctlplane_subnet",
+						},
+						},
+					},
+				}
+				DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(nodeSetName, nodeSetSpec))
+			}
+
+			deploymentSpec := map[string]interface{}{
+				"nodeSets": []string{
+					"alpha-nodeset",
+					"beta-nodeset",
+					"gamma-nodeset",
+				},
+				"nodeSetStrategy": map[string]interface{}{
+					"type": "Waves",
+					"waves": [][]string{
+						{"alpha-nodeset", "beta-nodeset"},
+						{"gamma-nodeset"},
+					},
+				},
+			}
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneMultiNodesetDeploymentName, deploymentSpec))
+		})
+
+		It("should deploy the NodeSets of a wave in parallel, once the previous wave is deployed", func() {
+			for _, nodeSetName := range nodeSetNames {
+				SimulateBaremetalSetReady(nodeSetName)
+			}
+
+			service := GetService(dataplaneServiceName)
+			aeeNames := map[string]types.NamespacedName{}
+			for _, nodeSetName := range nodeSetNames {
+				aeeName, _ := dataplaneutil.GetAnsibleExecutionNameAndLabels(
+					service, dataplaneMultiNodesetDeploymentName.Name, nodeSetName.Name)
+				aeeNames[nodeSetName.Name] = types.NamespacedName{Name: aeeName, Namespace: namespace}
+			}
+			exists := func(nodeSet string) bool {
+				return th.K8sClient.Get(th.Ctx, aeeNames[nodeSet], &ansibleeev1.OpenStackAnsibleEE{}) == nil
+			}
+
+			// Both NodeSets of the first wave run at the same time
+			Eventually(func(g Gomega) {
+				g.Expect(exists("alpha-nodeset")).To(BeTrue())
+				g.Expect(exists("beta-nodeset")).To(BeTrue())
+			}, th.Timeout, th.Interval).Should(Succeed())
+			Eventually(func(g Gomega) {
+				deployment := GetDataplaneDeployment(dataplaneMultiNodesetDeploymentName)
+				nsConditions := deployment.Status.NodeSetConditions["gamma-nodeset"]
+				g.Expect(nsConditions.IsFalse(dataplanev1.NodeSetDeploymentReadyCondition)).To(BeTrue())
+				g.Expect(nsConditions.Get(dataplanev1.NodeSetDeploymentReadyCondition).Message).To(
+					Equal(fmt.Sprintf(dataplanev1.NodeSetDeploymentWaitingForStageMessage, "alpha-nodeset,beta-nodeset")))
+			}, th.Timeout, th.Interval).Should(Succeed())
+			Expect(exists("gamma-nodeset")).To(BeFalse())
+
+			// The second wave waits for all the NodeSets of the first one
+			SetAnsibleeeJobStatus(dataplaneMultiNodesetDeploymentName, "alpha-nodeset",
+				dataplaneServiceName.Name, 0, ansibleeev1.JobStatusSucceeded)
+			Consistently(func() bool { return exists("gamma-nodeset") }, th.Timeout/8, th.Interval).Should(BeFalse())
+			SetAnsibleeeJobStatus(dataplaneMultiNodesetDeploymentName, "beta-nodeset",
+				dataplaneServiceName.Name, 0, ansibleeev1.JobStatusSucceeded)
+			Eventually(func() bool { return exists("gamma-nodeset") }, th.Timeout, th.Interval).Should(BeTrue())
+			SetAnsibleeeJobStatus(dataplaneMultiNodesetDeploymentName, "gamma-nodeset",
+				dataplaneServiceName.Name, 0, ansibleeev1.JobStatusSucceeded)
+
+			th.ExpectCondition(
+				dataplaneMultiNodesetDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+	})
+
+	When("A dataplaneDeployment is created with services depending on each other", func() {
+		var serviceNames []types.NamespacedName
+
+		BeforeEach(func() {
+			serviceNames = []types.NamespacedName{
+				{Namespace: namespace, Name: "base-service"},
+				{Namespace: namespace, Name: "left-service"},
+				{Namespace: namespace, Name: "right-service"},
+				{Namespace: namespace, Name: "final-service"},
+			}
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			// left-service and right-service both only need base-service,
+			// final-service needs both of them
+			CreateDataplaneService(serviceNames[0], false)
+			CreateDataplaneServiceWithSpec(serviceNames[1], map[string]interface{}{
+				"dependsOn": []string{"base-service"},
+			})
+			CreateDataplaneServiceWithSpec(serviceNames[2], map[string]interface{}{
+				"dependsOn": []string{"base-service"},
+			})
+			CreateDataplaneServiceWithSpec(serviceNames[3], map[string]interface{}{
+				"dependsOn": []string{"left-service", "right-service"},
+			})
+			for _, serviceName := range serviceNames {
+				DeferCleanup(th.DeleteService, serviceName)
+			}
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			nodeSetSpec := DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)
+			nodeSetSpec["services"] = []string{"base-service", "left-service", "right-service", "final-service"}
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, nodeSetSpec))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, DefaultDataPlaneDeploymentSpec()))
+		})
+
+		It("should start the independent services in parallel once their dependencies are deployed", func() {
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			aeeNames := map[string]types.NamespacedName{}
+			for _, serviceName := range serviceNames {
+				aeeName, _ := dataplaneutil.GetAnsibleExecutionNameAndLabels(
+					GetService(serviceName), dataplaneDeploymentName.Name, dataplaneNodeSetName.Name)
+				aeeNames[serviceName.Name] = types.NamespacedName{Name: aeeName, Namespace: namespace}
+			}
+			succeed := func(service string) {
+				Eventually(func(g Gomega) {
+					ansibleEE := GetAnsibleee(aeeNames[service])
+					ansibleEE.Status.JobStatus = ansibleeev1.JobStatusSucceeded
+					g.Expect(th.K8sClient.Status().Update(th.Ctx, ansibleEE)).To(Succeed())
+				}, th.Timeout, th.Interval).Should(Succeed())
+			}
+			exists := func(service string) bool {
+				return th.K8sClient.Get(th.Ctx, aeeNames[service], &ansibleeev1.OpenStackAnsibleEE{}) == nil
+			}
+
+			// Only base-service starts, the others wait for it
+			Eventually(func() bool { return exists("base-service") }, th.Timeout, th.Interval).Should(BeTrue())
+			Consistently(func(g Gomega) {
+				g.Expect(exists("left-service")).To(BeFalse())
+				g.Expect(exists("right-service")).To(BeFalse())
+				g.Expect(exists("final-service")).To(BeFalse())
+			}, th.Timeout/8, th.Interval).Should(Succeed())
+
+			// left-service and right-service run at the same time
+			succeed("base-service")
+			Eventually(func(g Gomega) {
+				g.Expect(exists("left-service")).To(BeTrue())
+				g.Expect(exists("right-service")).To(BeTrue())
+			}, th.Timeout, th.Interval).Should(Succeed())
+			Expect(exists("final-service")).To(BeFalse())
+
+			// final-service waits for both of them
+			succeed("left-service")
+			Consistently(func() bool { return exists("final-service") }, th.Timeout/8, th.Interval).Should(BeFalse())
+			succeed("right-service")
+			succeed("final-service")
+
+			th.ExpectCondition(
+				dataplaneDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+	})
+
+	When("A dataplaneDeployment is created with services depending on each other in a cycle", func() {
+		BeforeEach(func() {
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			firstServiceName := types.NamespacedName{Namespace: namespace, Name: "first-service"}
+			secondServiceName := types.NamespacedName{Namespace: namespace, Name: "second-service"}
+			CreateDataplaneServiceWithSpec(firstServiceName, map[string]interface{}{
+				"dependsOn": []string{"second-service"},
+			})
+			CreateDataplaneServiceWithSpec(secondServiceName, map[string]interface{}{
+				"dependsOn": []string{"first-service"},
+			})
+			DeferCleanup(th.DeleteService, firstServiceName)
+			DeferCleanup(th.DeleteService, secondServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			nodeSetSpec := DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)
+			nodeSetSpec["services"] = []string{"first-service", "second-service"}
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, nodeSetSpec))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, DefaultDataPlaneDeploymentSpec()))
+		})
+
+		It("should fail without starting any service", func() {
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			Eventually(func(g Gomega) {
+				deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+				nsConditions := deployment.Status.NodeSetConditions[dataplaneNodeSetName.Name]
+				g.Expect(nsConditions.IsFalse(dataplanev1.NodeSetDeploymentReadyCondition)).To(BeTrue())
+				g.Expect(nsConditions.Get(dataplanev1.NodeSetDeploymentReadyCondition).Message).To(
+					ContainSubstring("service dependency cycle detected: first-service -> second-service -> first-service"))
+			}, th.Timeout, th.Interval).Should(Succeed())
+			th.ExpectCondition(
+				dataplaneDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionFalse,
+			)
+			ansibleEEs := &ansibleeev1.OpenStackAnsibleEEList{}
+			Expect(th.K8sClient.List(th.Ctx, ansibleEEs,
+				client.InNamespace(namespace),
+				client.MatchingLabels{"openstackdataplanedeployment": dataplaneDeploymentName.Name})).To(Succeed())
+			Expect(ansibleEEs.Items).To(BeEmpty())
+		})
+	})
+
+	When("A dataplaneDeployment is created with a service depending on a missing service", func() {
+		BeforeEach(func() {
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			CreateDataplaneServiceWithSpec(dataplaneServiceName, map[string]interface{}{
+				"dependsOn": []string{"missing-service"},
+			})
+			CreateDataplaneService(dataplaneGlobalServiceName, true)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteService, dataplaneGlobalServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, DefaultDataPlaneDeploymentSpec()))
+		})
+
+		It("should fail reporting the missing service", func() {
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			Eventually(func(g Gomega) {
+				deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+				nsConditions := deployment.Status.NodeSetConditions[dataplaneNodeSetName.Name]
+				g.Expect(nsConditions.IsFalse(dataplanev1.NodeSetDeploymentReadyCondition)).To(BeTrue())
+				g.Expect(nsConditions.Get(dataplanev1.NodeSetDeploymentReadyCondition).Message).To(
+					ContainSubstring("service foo-service depends on missing service missing-service"))
+			}, th.Timeout, th.Interval).Should(Succeed())
+			th.ExpectCondition(
+				dataplaneDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionFalse,
+			)
+		})
+	})
+
+	When("A dataplaneDeployment is created with a retryPolicy", func() {
+		BeforeEach(func() {
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			CreateDataplaneService(dataplaneServiceName, false)
+			CreateDataplaneService(dataplaneGlobalServiceName, true)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteService, dataplaneGlobalServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)))
+			deploymentSpec := DefaultDataPlaneDeploymentSpec()
+			deploymentSpec["retryPolicy"] = map[string]interface{}{
+				"maxRetries":            1,
+				"initialBackoffSeconds": 0,
+			}
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, deploymentSpec))
+		})
+
+		It("should retry a failed execution", func() {
+
+			CreateOVNControllerConfigMap(namespace)
+
+			nodeSet := *GetDataplaneNodeSet(dataplaneNodeSetName)
+
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			service := GetService(dataplaneServiceName)
+			aeeName, aeeLabels := dataplaneutil.GetAnsibleExecutionNameAndLabels(
+				service, dataplaneDeploymentName.Name, nodeSet.GetName())
+			retryAeeName, _ := dataplaneutil.GetAnsibleExecutionAttemptNameAndLabels(aeeName, aeeLabels, 2)
+
+			// Fail the first attempt
+			Eventually(func(g Gomega) {
+				ansibleEE := GetAnsibleee(types.NamespacedName{
+					Name:      aeeName,
+					Namespace: dataplaneDeploymentName.Namespace,
+				})
+				ansibleEE.Status.JobStatus = ansibleeev1.JobStatusFailed
+				g.Expect(th.K8sClient.Status().Update(th.Ctx, ansibleEE)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			// The second attempt is created and succeeds
+			Eventually(func(g Gomega) {
+				ansibleEE := GetAnsibleee(types.NamespacedName{
+					Name:      retryAeeName,
+					Namespace: dataplaneDeploymentName.Namespace,
+				})
+				g.Expect(ansibleEE.Labels).To(HaveKeyWithValue(dataplaneutil.AnsibleExecutionAttemptLabel, "2"))
+				ansibleEE.Status.JobStatus = ansibleeev1.JobStatusSucceeded
+				g.Expect(th.K8sClient.Status().Update(th.Ctx, ansibleEE)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+				attempts := deployment.Status.NodeSetAttempts[dataplaneNodeSetName.Name][service.Name]
+				g.Expect(attempts).To(HaveLen(2))
+				g.Expect(attempts[0].JobStatus).To(Equal(ansibleeev1.JobStatusFailed))
+				g.Expect(attempts[1].JobStatus).To(Equal(ansibleeev1.JobStatusSucceeded))
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			// The retry and both results are counted
+			labels := map[string]string{
+				"namespace": namespace,
+				"nodeset":   dataplaneNodeSetName.Name,
+				"service":   service.Name,
+			}
+			resultLabels := func(result string) map[string]string {
+				return map[string]string{
+					"namespace": namespace,
+					"nodeset":   dataplaneNodeSetName.Name,
+					"service":   service.Name,
+					"result":    result,
+				}
+			}
+			Eventually(func(g Gomega) {
+				retries, found := GetMetricValue("dataplane_service_execution_retries_total", labels)
+				g.Expect(found).To(BeTrue())
+				g.Expect(retries).To(Equal(1.0))
+				failures, found := GetMetricValue("dataplane_service_execution_failures_total", labels)
+				g.Expect(found).To(BeTrue())
+				g.Expect(failures).To(Equal(1.0))
+				failed, found := GetMetricValue("dataplane_service_execution_duration_seconds", resultLabels("failed"))
+				g.Expect(found).To(BeTrue())
+				g.Expect(failed).To(Equal(1.0))
+				succeeded, found := GetMetricValue("dataplane_service_execution_duration_seconds", resultLabels("succeeded"))
+				g.Expect(found).To(BeTrue())
+				g.Expect(succeeded).To(Equal(1.0))
+			}, th.Timeout, th.Interval).Should(Succeed())
+		})
+	})
+
+	When("A dataplaneDeployment with a timeout is created with a missing nodeset", func() {
+		BeforeEach(func() {
+			deploymentSpec := DefaultDataPlaneDeploymentSpec()
+			deploymentSpec["timeout"] = 1
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, deploymentSpec))
+		})
+
+		It("should stop waiting for the nodeset once timed out", func() {
+			th.ExpectConditionWithDetails(
+				dataplaneDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.InputReadyCondition,
+				corev1.ConditionFalse,
+				dataplanev1.TimedOutReason,
+				"Deployment timed out after 1s",
+			)
+		})
+	})
+
+	When("A dataplaneDeployment runs a service with a timeout", func() {
+		BeforeEach(func() {
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			CreateDataplaneServiceWithSpec(dataplaneServiceName, map[string]interface{}{
+				"timeout": 10,
+			})
+			CreateDataplaneService(dataplaneGlobalServiceName, true)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteService, dataplaneGlobalServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, DefaultDataPlaneDeploymentSpec()))
+		})
+
+		It("should stop the execution once timed out and fail", func() {
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			aeeName, _ := dataplaneutil.GetAnsibleExecutionNameAndLabels(
+				GetService(dataplaneServiceName), dataplaneDeploymentName.Name, dataplaneNodeSetName.Name)
+			SetAnsibleeeJobStatus(dataplaneDeploymentName, dataplaneNodeSetName.Name,
+				dataplaneServiceName.Name, 0, ansibleeev1.JobStatusRunning)
+
+			Eventually(func(g Gomega) {
+				deploymentReady := GetDataplaneDeployment(dataplaneDeploymentName).Status.Conditions.Get(condition.DeploymentReadyCondition)
+				g.Expect(deploymentReady).NotTo(BeNil())
+				g.Expect(deploymentReady.Reason).To(Equal(condition.ErrorReason))
+				g.Expect(deploymentReady.Message).To(ContainSubstring(
+					fmt.Sprintf(dataplanev1.NodeSetServiceDeploymentTimedOutMessage, dataplaneServiceName.Name, aeeName)))
+			}, th.Timeout, th.Interval).Should(Succeed())
+			Eventually(func(g Gomega) {
+				err := th.K8sClient.Get(th.Ctx, types.NamespacedName{Name: aeeName, Namespace: namespace},
+					&ansibleeev1.OpenStackAnsibleEE{})
+				g.Expect(k8s_errors.IsNotFound(err)).To(BeTrue())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			instance := GetDataplaneDeployment(dataplaneDeploymentName)
+			nsConditions := instance.Status.NodeSetConditions[dataplaneNodeSetName.Name]
+			fooReady := nsConditions.Get(condition.Type("ServiceFooServiceDeploymentReady"))
+			Expect(fooReady).NotTo(BeNil())
+			Expect(fooReady.Reason).To(Equal(dataplanev1.TimedOutReason))
+			attempts := instance.Status.NodeSetAttempts[dataplaneNodeSetName.Name][dataplaneServiceName.Name]
+			Expect(attempts).To(HaveLen(1))
+			Expect(attempts[0].JobStatus).To(Equal(dataplanev1.AnsibleExecutionTimedOut))
+			Expect(attempts[0].FinishTime).NotTo(BeNil())
+
+			// The following services are not started
+			ansibleEEs := &ansibleeev1.OpenStackAnsibleEEList{}
+			Expect(th.K8sClient.List(th.Ctx, ansibleEEs,
+				client.InNamespace(namespace),
+				client.MatchingLabels{"openstackdataplanedeployment": dataplaneDeploymentName.Name})).To(Succeed())
+			Expect(ansibleEEs.Items).To(BeEmpty())
+			Eventually(func(g Gomega) {
+				g.Expect(GetEventReasons(dataplaneDeploymentName)).To(ContainElement("ServiceExecutionTimedOut"))
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			// The timed out execution is counted as a failure
+			timedOut, found := GetMetricValue("dataplane_service_execution_duration_seconds", map[string]string{
+				"namespace": namespace,
+				"nodeset":   dataplaneNodeSetName.Name,
+				"service":   dataplaneServiceName.Name,
+				"result":    "timedout",
+			})
+			Expect(found).To(BeTrue())
+			Expect(timedOut).To(Equal(1.0))
+			failures, found := GetMetricValue("dataplane_service_execution_failures_total", map[string]string{
+				"namespace": namespace,
+				"nodeset":   dataplaneNodeSetName.Name,
+				"service":   dataplaneServiceName.Name,
+			})
+			Expect(found).To(BeTrue())
+			Expect(failures).To(Equal(1.0))
+		})
+	})
+
+	When("A dataplaneDeployment times out while an execution is running", func() {
+		BeforeEach(func() {
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			CreateDataplaneService(dataplaneServiceName, false)
+			CreateDataplaneService(dataplaneGlobalServiceName, true)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteService, dataplaneGlobalServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)))
+			deploymentSpec := DefaultDataPlaneDeploymentSpec()
+			deploymentSpec["timeout"] = 10
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, deploymentSpec))
+		})
+
+		It("should stop the running execution and time out", func() {
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			aeeName, _ := dataplaneutil.GetAnsibleExecutionNameAndLabels(
+				GetService(dataplaneServiceName), dataplaneDeploymentName.Name, dataplaneNodeSetName.Name)
+			SetAnsibleeeJobStatus(dataplaneDeploymentName, dataplaneNodeSetName.Name,
+				dataplaneServiceName.Name, 0, ansibleeev1.JobStatusRunning)
+
+			th.ExpectConditionWithDetails(
+				dataplaneDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionFalse,
+				dataplanev1.TimedOutReason,
+				"Deployment timed out after 10s",
+			)
+			Eventually(func(g Gomega) {
+				err := th.K8sClient.Get(th.Ctx, types.NamespacedName{Name: aeeName, Namespace: namespace},
+					&ansibleeev1.OpenStackAnsibleEE{})
+				g.Expect(k8s_errors.IsNotFound(err)).To(BeTrue())
+				instance := GetDataplaneDeployment(dataplaneDeploymentName)
+				g.Expect(instance.Status.FinishTime).NotTo(BeNil())
+				attempts := instance.Status.NodeSetAttempts[dataplaneNodeSetName.Name][dataplaneServiceName.Name]
+				g.Expect(attempts).To(HaveLen(1))
+				g.Expect(attempts[0].JobStatus).To(Equal(dataplanev1.AnsibleExecutionTimedOut))
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			// global-service is never started
+			globalAEEName, _ := dataplaneutil.GetAnsibleExecutionNameAndLabels(
+				GetService(dataplaneGlobalServiceName), dataplaneDeploymentName.Name, dataplaneNodeSetName.Name)
+			Consistently(func() bool {
+				return k8s_errors.IsNotFound(th.K8sClient.Get(th.Ctx,
+					types.NamespacedName{Name: globalAEEName, Namespace: namespace}, &ansibleeev1.OpenStackAnsibleEE{}))
+			}, th.Timeout/4, th.Interval).Should(BeTrue())
+		})
+	})
+
+	When("A running dataplaneDeployment is paused", func() {
+		BeforeEach(func() {
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			CreateDataplaneService(dataplaneServiceName, false)
+			CreateDataplaneService(dataplaneGlobalServiceName, true)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteService, dataplaneGlobalServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, DefaultDataPlaneDeploymentSpec()))
+		})
+
+		It("should not start any new ansible execution until it is resumed", func() {
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			globalAEEName, _ := dataplaneutil.GetAnsibleExecutionNameAndLabels(
+				GetService(dataplaneGlobalServiceName), dataplaneDeploymentName.Name, dataplaneNodeSetName.Name)
+			globalAEEExists := func() bool {
+				return th.K8sClient.Get(th.Ctx, types.NamespacedName{Name: globalAEEName, Namespace: namespace},
+					&ansibleeev1.OpenStackAnsibleEE{}) == nil
+			}
+
+			// foo-service is running when the Deployment is paused, it is
+			// allowed to finish
+			fooAEEName, _ := dataplaneutil.GetAnsibleExecutionNameAndLabels(
+				GetService(dataplaneServiceName), dataplaneDeploymentName.Name, dataplaneNodeSetName.Name)
+			GetAnsibleee(types.NamespacedName{Name: fooAEEName, Namespace: namespace})
+			Eventually(func(g Gomega) {
+				instance := GetDataplaneDeployment(dataplaneDeploymentName)
+				if instance.Annotations == nil {
+					instance.Annotations = map[string]string{}
+				}
+				instance.Annotations[dataplanev1.DeploymentPauseAnnotation] = "true"
+				g.Expect(th.K8sClient.Update(th.Ctx, instance)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+			SetAnsibleeeJobStatus(dataplaneDeploymentName, dataplaneNodeSetName.Name,
+				dataplaneServiceName.Name, 0, ansibleeev1.JobStatusSucceeded)
+
+			th.ExpectConditionWithDetails(
+				dataplaneDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.DeploymentReadyCondition,
+				corev1.ConditionFalse,
+				dataplanev1.PausedReason,
+				dataplanev1.DeploymentPausedMessage,
+			)
+			Eventually(func(g Gomega) {
+				nsConditions := GetDataplaneDeployment(dataplaneDeploymentName).Status.NodeSetConditions[dataplaneNodeSetName.Name]
+				g.Expect(nsConditions.IsTrue(condition.Type("ServiceFooServiceDeploymentReady"))).To(BeTrue())
+			}, th.Timeout, th.Interval).Should(Succeed())
+			Consistently(globalAEEExists, th.Timeout/4, th.Interval).Should(BeFalse())
+			ansibleEEs := &ansibleeev1.OpenStackAnsibleEEList{}
+			Expect(th.K8sClient.List(th.Ctx, ansibleEEs,
+				client.InNamespace(namespace),
+				client.MatchingLabels{"openstackdataplanedeployment": dataplaneDeploymentName.Name})).To(Succeed())
+			Expect(ansibleEEs.Items).To(HaveLen(1))
+
+			// Removing the annotation resumes the Deployment
+			Eventually(func(g Gomega) {
+				instance := GetDataplaneDeployment(dataplaneDeploymentName)
+				delete(instance.Annotations, dataplanev1.DeploymentPauseAnnotation)
+				g.Expect(th.K8sClient.Update(th.Ctx, instance)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+			Eventually(globalAEEExists, th.Timeout, th.Interval).Should(BeTrue())
+			SetAnsibleeeJobStatus(dataplaneDeploymentName, dataplaneNodeSetName.Name,
+				dataplaneGlobalServiceName.Name, 0, ansibleeev1.JobStatusSucceeded)
+
+			th.ExpectCondition(
+				dataplaneDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+	})
+
+	When("A dataplaneDeployment is cancelled", func() {
+		BeforeEach(func() {
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, DefaultDataPlaneDeploymentSpec()))
+		})
+
+		It("should reach the Cancelled terminal state", func() {
+			Eventually(func(g Gomega) {
+				instance := GetDataplaneDeployment(dataplaneDeploymentName)
+				if instance.Annotations == nil {
+					instance.Annotations = map[string]string{}
+				}
+				instance.Annotations[dataplanev1.DeploymentCancelAnnotation] = "true"
+				g.Expect(th.K8sClient.Update(th.Ctx, instance)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			th.ExpectConditionWithDetails(
+				dataplaneDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.DeploymentReadyCondition,
+				corev1.ConditionFalse,
+				dataplanev1.CancelledReason,
+				dataplanev1.DeploymentCancelledMessage,
+			)
+			Eventually(func(g Gomega) {
+				instance := GetDataplaneDeployment(dataplaneDeploymentName)
+				g.Expect(instance.Status.Cancelled).To(BeTrue())
+				g.Expect(instance.Status.Deployed).To(BeFalse())
+			}, th.Timeout, th.Interval).Should(Succeed())
+		})
+	})
+
+	When("A cancelled dataplaneDeployment is rerun", func() {
+		BeforeEach(func() {
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, DefaultDataPlaneDeploymentSpec()))
+		})
+
+		It("should record the previous run and start a new one", func() {
+			Eventually(func(g Gomega) {
+				instance := GetDataplaneDeployment(dataplaneDeploymentName)
+				if instance.Annotations == nil {
+					instance.Annotations = map[string]string{}
+				}
+				instance.Annotations[dataplanev1.DeploymentCancelAnnotation] = "true"
+				g.Expect(th.K8sClient.Update(th.Ctx, instance)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+			Eventually(func(g Gomega) {
+				g.Expect(GetDataplaneDeployment(dataplaneDeploymentName).Status.Cancelled).To(BeTrue())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				instance := GetDataplaneDeployment(dataplaneDeploymentName)
+				delete(instance.Annotations, dataplanev1.DeploymentCancelAnnotation)
+				instance.Annotations[dataplanev1.DeploymentRerunAnnotation] = "first-rerun"
+				g.Expect(th.K8sClient.Update(th.Ctx, instance)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				instance := GetDataplaneDeployment(dataplaneDeploymentName)
+				g.Expect(instance.Status.Run).To(Equal(1))
+				g.Expect(instance.Status.RerunToken).To(Equal("first-rerun"))
+				g.Expect(instance.Status.RunStartTime).NotTo(BeNil())
+				g.Expect(instance.Status.Cancelled).To(BeFalse())
+				g.Expect(instance.Status.PreviousRuns).To(HaveLen(1))
+				g.Expect(instance.Status.PreviousRuns[0].Run).To(Equal(0))
+				g.Expect(instance.Status.PreviousRuns[0].Outcome).To(Equal(dataplanev1.DeploymentOutcomeCancelled))
+				g.Expect(instance.Status.PreviousRuns[0].FinishTime).NotTo(BeNil())
+			}, th.Timeout, th.Interval).Should(Succeed())
+		})
+	})
+
+	When("A dataplaneDeployment with a TTL is cancelled", func() {
+		BeforeEach(func() {
+			deploymentSpec := DefaultDataPlaneDeploymentSpec()
+			deploymentSpec["ttlSecondsAfterFinished"] = 0
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, deploymentSpec))
+		})
+
+		It("should be deleted once finished", func() {
+			Eventually(func(g Gomega) {
+				instance := GetDataplaneDeployment(dataplaneDeploymentName)
+				if instance.Annotations == nil {
+					instance.Annotations = map[string]string{}
+				}
+				instance.Annotations[dataplanev1.DeploymentCancelAnnotation] = "true"
+				g.Expect(th.K8sClient.Update(th.Ctx, instance)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				err := th.K8sClient.Get(th.Ctx, dataplaneDeploymentName, &dataplanev1.OpenStackDataPlaneDeployment{})
+				g.Expect(k8s_errors.IsNotFound(err)).To(BeTrue())
+			}, th.Timeout, th.Interval).Should(Succeed())
+		})
+	})
+
+	When("Two dataplaneDeployments are created for the same NodeSet", func() {
+		var secondDeploymentName types.NamespacedName
+
+		BeforeEach(func() {
+			secondDeploymentName = types.NamespacedName{
+				Name:      "edpm-deployment-second",
+				Namespace: namespace,
+			}
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			CreateDataplaneService(dataplaneServiceName, false)
+			CreateDataplaneService(dataplaneGlobalServiceName, true)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteService, dataplaneGlobalServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, DefaultDataPlaneDeploymentSpec()))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(secondDeploymentName, DefaultDataPlaneDeploymentSpec()))
+		})
+
+		It("should only start the second one once the first one finished", func() {
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			th.ExpectConditionWithDetails(
+				secondDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.InputReadyCondition,
+				corev1.ConditionFalse,
+				dataplanev1.WaitingForDeploymentReason,
+				fmt.Sprintf(dataplanev1.DeploymentWaitingForDeploymentsMessage, dataplaneDeploymentName.Name),
+			)
+			ansibleEEs := &ansibleeev1.OpenStackAnsibleEEList{}
+			Expect(th.K8sClient.List(th.Ctx, ansibleEEs,
+				client.InNamespace(namespace),
+				client.MatchingLabels{"openstackdataplanedeployment": secondDeploymentName.Name})).To(Succeed())
+			Expect(ansibleEEs.Items).To(BeEmpty())
+
+			// Cancelling the first Deployment starts the second one
+			Eventually(func(g Gomega) {
+				instance := GetDataplaneDeployment(dataplaneDeploymentName)
+				if instance.Annotations == nil {
+					instance.Annotations = map[string]string{}
+				}
+				instance.Annotations[dataplanev1.DeploymentCancelAnnotation] = "true"
+				g.Expect(th.K8sClient.Update(th.Ctx, instance)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			th.ExpectCondition(
+				secondDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.InputReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+
+		It("should keep the second one waiting while the first one is paused", func() {
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			Eventually(func(g Gomega) {
+				instance := GetDataplaneDeployment(dataplaneDeploymentName)
+				if instance.Annotations == nil {
+					instance.Annotations = map[string]string{}
+				}
+				instance.Annotations[dataplanev1.DeploymentPauseAnnotation] = "true"
+				g.Expect(th.K8sClient.Update(th.Ctx, instance)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			Expect(GetDataplaneDeployment(dataplaneDeploymentName).IsInProgress()).To(BeTrue())
+			Consistently(func(g Gomega) {
+				inputReady := GetDataplaneDeployment(secondDeploymentName).Status.Conditions.Get(condition.InputReadyCondition)
+				g.Expect(inputReady).NotTo(BeNil())
+				g.Expect(inputReady.Status).NotTo(Equal(corev1.ConditionTrue))
+			}, th.Timeout/4, th.Interval).Should(Succeed())
+			th.ExpectConditionWithDetails(
+				secondDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.InputReadyCondition,
+				corev1.ConditionFalse,
+				dataplanev1.WaitingForDeploymentReason,
+				fmt.Sprintf(dataplanev1.DeploymentWaitingForDeploymentsMessage, dataplaneDeploymentName.Name),
+			)
+		})
+	})
+
+	When("A dataplaneDeployment waiting for approval is created before a second one of the same NodeSet", func() {
+		var secondDeploymentName types.NamespacedName
+
+		BeforeEach(func() {
+			secondDeploymentName = types.NamespacedName{
+				Name:      "edpm-deployment-second",
+				Namespace: namespace,
+			}
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			CreateDataplaneService(dataplaneServiceName, false)
+			CreateDataplaneService(dataplaneGlobalServiceName, true)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteService, dataplaneGlobalServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)))
+			deploymentSpec := DefaultDataPlaneDeploymentSpec()
+			deploymentSpec["requireApproval"] = true
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, deploymentSpec))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(secondDeploymentName, DefaultDataPlaneDeploymentSpec()))
+		})
+
+		It("should start the second one, and approving the first one makes it wait", func() {
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			th.ExpectCondition(
+				secondDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.InputReadyCondition,
+				corev1.ConditionTrue,
+			)
+			Expect(GetDataplaneDeployment(dataplaneDeploymentName).IsInProgress()).To(BeFalse())
+
+			Eventually(func(g Gomega) {
+				instance := GetDataplaneDeployment(dataplaneDeploymentName)
+				g.Expect(instance.Status.PlanHash).ToNot(BeEmpty())
+				if instance.Annotations == nil {
+					instance.Annotations = map[string]string{}
+				}
+				instance.Annotations[dataplanev1.DeploymentApprovedAnnotation] = instance.Status.PlanHash
+				g.Expect(th.K8sClient.Update(th.Ctx, instance)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			// The second Deployment started executing first, it keeps the NodeSet
+			th.ExpectConditionWithDetails(
+				dataplaneDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.InputReadyCondition,
+				corev1.ConditionFalse,
+				dataplanev1.WaitingForDeploymentReason,
+				fmt.Sprintf(dataplanev1.DeploymentWaitingForDeploymentsMessage, secondDeploymentName.Name),
+			)
+			ansibleEEs := &ansibleeev1.OpenStackAnsibleEEList{}
+			Expect(th.K8sClient.List(th.Ctx, ansibleEEs,
+				client.InNamespace(namespace),
+				client.MatchingLabels{"openstackdataplanedeployment": dataplaneDeploymentName.Name})).To(Succeed())
+			Expect(ansibleEEs.Items).To(BeEmpty())
+		})
+	})
+
+	When("A dataplaneDeployment times out before a second one of the same NodeSet", func() {
+		var secondDeploymentName types.NamespacedName
+
+		BeforeEach(func() {
+			secondDeploymentName = types.NamespacedName{
+				Name:      "edpm-deployment-second",
+				Namespace: namespace,
+			}
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			CreateDataplaneService(dataplaneServiceName, false)
+			CreateDataplaneService(dataplaneGlobalServiceName, true)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteService, dataplaneGlobalServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)))
+			deploymentSpec := DefaultDataPlaneDeploymentSpec()
+			deploymentSpec["timeout"] = 10
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, deploymentSpec))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(secondDeploymentName, DefaultDataPlaneDeploymentSpec()))
+		})
+
+		It("should finish the timed out one and start the second one", func() {
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			// The ansible execution of the first Deployment never finishes
+			th.ExpectConditionWithDetails(
+				dataplaneDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionFalse,
+				dataplanev1.TimedOutReason,
+				"Deployment timed out after 10s",
+			)
+			Eventually(func(g Gomega) {
+				instance := GetDataplaneDeployment(dataplaneDeploymentName)
+				g.Expect(instance.Status.FinishTime).NotTo(BeNil())
+				g.Expect(instance.IsFailed()).To(BeTrue())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			th.ExpectCondition(
+				secondDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.InputReadyCondition,
+				corev1.ConditionTrue,
+			)
+			Eventually(func(g Gomega) {
+				nodeSet := GetDataplaneNodeSet(dataplaneNodeSetName)
+				g.Expect(nodeSet.Status.DeploymentHistory).NotTo(BeEmpty())
+				entry := nodeSet.Status.DeploymentHistory[0]
+				g.Expect(entry.Deployment).To(Equal(dataplaneDeploymentName.Name))
+				g.Expect(entry.Outcome).To(Equal(dataplanev1.DeploymentOutcomeFailed))
+				g.Expect(entry.FinishTime).NotTo(BeNil())
+			}, th.Timeout, th.Interval).Should(Succeed())
+		})
+	})
+
+	When("A dataplaneDeployment is created with a canary", func() {
+		BeforeEach(func() {
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			CreateDataplaneService(dataplaneServiceName, false)
+			CreateDataplaneService(dataplaneGlobalServiceName, true)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteService, dataplaneGlobalServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)))
+			deploymentSpec := DefaultDataPlaneDeploymentSpec()
+			deploymentSpec["canary"] = map[string]interface{}{
+				"count": 1,
+			}
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, deploymentSpec))
+		})
+
+		It("should deploy the canary hosts first", func() {
+
+			CreateOVNControllerConfigMap(namespace)
+
+			nodeSet := *GetDataplaneNodeSet(dataplaneNodeSetName)
+
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			for _, serviceName := range nodeSet.Spec.Services {
+				dataplaneServiceName := types.NamespacedName{
+					Name:      serviceName,
+					Namespace: namespace,
+				}
+				service := GetService(dataplaneServiceName)
+				deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+				//Retrieve the AnsibleEE of the canary batch and set JobStatus to Successful
+				aeeName, _ := dataplaneutil.GetAnsibleExecutionBatchNameAndLabels(
+					service, deployment.GetName(), nodeSet.GetName(), 1)
+				Eventually(func(g Gomega) {
+					ansibleeeName := types.NamespacedName{
+						Name:      aeeName,
+						Namespace: dataplaneDeploymentName.Namespace,
+					}
+					ansibleEE := &ansibleeev1.OpenStackAnsibleEE{}
+					g.Expect(th.K8sClient.Get(th.Ctx, ansibleeeName, ansibleEE)).To(Succeed())
+					g.Expect(ansibleEE.Labels).To(HaveKeyWithValue("openstackdataplanebatch", "1"))
+					g.Expect(ansibleEE.Spec.CmdLine).To(Equal("--limit edpm-bm-compute-1"))
+					ansibleEE.Status.JobStatus = ansibleeev1.JobStatusSucceeded
+
+					g.Expect(th.K8sClient.Status().Update(th.Ctx, ansibleEE)).To(Succeed())
+				}, th.Timeout, th.Interval).Should(Succeed())
+			}
+
+			Eventually(func(g Gomega) {
+				deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+				nsConditions := deployment.Status.NodeSetConditions[dataplaneNodeSetName.Name]
+				g.Expect(nsConditions.IsTrue(dataplanev1.NodeSetCanaryDeploymentReadyCondition)).To(BeTrue())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			th.ExpectCondition(
+				dataplaneDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+	})
+
+	When("A dataplaneDeployment with a canary fails on the canary hosts", func() {
+		BeforeEach(func() {
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			CreateDataplaneService(dataplaneServiceName, false)
+			CreateDataplaneService(dataplaneGlobalServiceName, true)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteService, dataplaneGlobalServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			nodeSetSpec := DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)
+			nodes := map[string]interface{}{}
+			for idx := 0; idx < 2; idx++ {
+				nodes[fmt.Sprintf("%s-node-%d", dataplaneNodeSetName.Name, idx+1)] = map[string]interface{}{
+					"hostname": fmt.Sprintf("edpm-bm-compute-%d", idx+1),
+					"networks": []map[string]interface{}{{
+						"name":       "CtlPlane",
+						"fixedIP":    fmt.Sprintf("172.20.12.%d", 76+idx),
+						"subnetName": "ctlplane_subnet",
+					},
+					},
+				}
+			}
+			nodeSetSpec["nodes"] = nodes
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, nodeSetSpec))
+			deploymentSpec := DefaultDataPlaneDeploymentSpec()
+			deploymentSpec["canary"] = map[string]interface{}{
+				"count": 1,
+			}
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, deploymentSpec))
+		})
+
+		It("should not deploy the other hosts", func() {
+
+			CreateOVNControllerConfigMap(namespace)
+
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			SetAnsibleeeJobStatus(dataplaneDeploymentName, dataplaneNodeSetName.Name,
+				dataplaneServiceName.Name, 1, ansibleeev1.JobStatusFailed)
+
+			th.ExpectCondition(
+				dataplaneDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.DeploymentReadyCondition,
+				corev1.ConditionFalse,
+			)
+			Eventually(func(g Gomega) {
+				deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+				nsConditions := deployment.Status.NodeSetConditions[dataplaneNodeSetName.Name]
+				canaryCondition := nsConditions.Get(dataplanev1.NodeSetCanaryDeploymentReadyCondition)
+				g.Expect(canaryCondition).NotTo(BeNil())
+				g.Expect(canaryCondition.Status).To(Equal(corev1.ConditionFalse))
+				g.Expect(canaryCondition.Reason).To(Equal(condition.ErrorReason))
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			// Approving the canary does not deploy the other hosts either
+			Eventually(func(g Gomega) {
+				deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+				annotations := deployment.GetAnnotations()
+				if annotations == nil {
+					annotations = map[string]string{}
+				}
+				annotations[dataplanev1.DeploymentCanaryApprovedAnnotation] = "true"
+				deployment.SetAnnotations(annotations)
+				g.Expect(th.K8sClient.Update(th.Ctx, deployment)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			service := GetService(dataplaneServiceName)
+			aeeName, _ := dataplaneutil.GetAnsibleExecutionBatchNameAndLabels(
+				service, dataplaneDeploymentName.Name, dataplaneNodeSetName.Name, 2)
+			Consistently(func(g Gomega) {
+				ansibleEE := &ansibleeev1.OpenStackAnsibleEE{}
+				err := th.K8sClient.Get(th.Ctx, types.NamespacedName{
+					Name:      aeeName,
+					Namespace: dataplaneDeploymentName.Namespace,
+				}, ansibleEE)
+				g.Expect(k8s_errors.IsNotFound(err)).To(BeTrue())
+			}, th.Timeout/8, th.Interval).Should(Succeed())
+		})
+	})
+
+	When("A dataplaneDeployment is created outside of the NodeSet maintenance windows", func() {
+		BeforeEach(func() {
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			CreateDataplaneService(dataplaneServiceName, false)
+			CreateDataplaneService(dataplaneGlobalServiceName, true)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteService, dataplaneGlobalServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			nodeSetSpec := DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)
+			// The window is only open for the first minute of the year
+			nodeSetSpec["maintenanceWindows"] = []map[string]interface{}{
+				{
+					"schedule":        "0 0 1 1 *",
+					"durationMinutes": 1,
+				},
+			}
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, nodeSetSpec))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, DefaultDataPlaneDeploymentSpec()))
+		})
+
+		It("should not start any ansible execution", func() {
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			Eventually(func(g Gomega) {
+				deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+				nsConditions := deployment.Status.NodeSetConditions[dataplaneNodeSetName.Name]
+				readyCondition := nsConditions.Get(dataplanev1.NodeSetDeploymentReadyCondition)
+				g.Expect(readyCondition).ToNot(BeNil())
+				g.Expect(readyCondition.Reason).To(Equal(dataplanev1.WaitingForMaintenanceWindowReason))
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			ansibleEEs := &ansibleeev1.OpenStackAnsibleEEList{}
+			Expect(th.K8sClient.List(th.Ctx, ansibleEEs,
+				client.InNamespace(namespace),
+				client.MatchingLabels{"openstackdataplanedeployment": dataplaneDeploymentName.Name})).To(Succeed())
+			Expect(ansibleEEs.Items).To(BeEmpty())
+		})
+	})
+
+	When("A dataplaneDeployment requiring approval is created", func() {
+		BeforeEach(func() {
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			CreateDataplaneService(dataplaneServiceName, false)
+			CreateDataplaneService(dataplaneGlobalServiceName, true)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteService, dataplaneGlobalServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)))
+			deploymentSpec := DefaultDataPlaneDeploymentSpec()
+			deploymentSpec["requireApproval"] = true
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, deploymentSpec))
+		})
+
+		It("should publish its plan and wait for the approval", func() {
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			Eventually(func(g Gomega) {
+				deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+				inputReady := deployment.Status.Conditions.Get(condition.InputReadyCondition)
+				g.Expect(inputReady).ToNot(BeNil())
+				g.Expect(inputReady.Reason).To(Equal(dataplanev1.WaitingForApprovalReason))
+				g.Expect(deployment.Status.Plan).ToNot(BeNil())
+				g.Expect(deployment.Status.Plan.NodeSets).To(HaveLen(1))
+				nodeSetPlan := deployment.Status.Plan.NodeSets[0]
+				g.Expect(nodeSetPlan.Name).To(Equal(dataplaneNodeSetName.Name))
+				g.Expect(nodeSetPlan.Services).To(Equal([]dataplanev1.ServicePlan{
+					{Name: dataplaneServiceName.Name},
+					{Name: dataplaneGlobalServiceName.Name, Global: true},
+				}))
+				g.Expect(deployment.Status.Progress).To(Equal("0/2"))
+				nodeSetProgress := deployment.Status.NodeSetProgress[dataplaneNodeSetName.Name]
+				g.Expect(nodeSetProgress.TotalServices).To(Equal(2))
+				g.Expect(nodeSetProgress.CurrentService).To(Equal(dataplaneServiceName.Name))
+				// No previous Deployment to estimate the durations from
+				g.Expect(deployment.Status.EstimatedFinishTime).To(BeNil())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			ansibleEEs := &ansibleeev1.OpenStackAnsibleEEList{}
+			Expect(th.K8sClient.List(th.Ctx, ansibleEEs,
+				client.InNamespace(namespace),
+				client.MatchingLabels{"openstackdataplanedeployment": dataplaneDeploymentName.Name})).To(Succeed())
+			Expect(ansibleEEs.Items).To(BeEmpty())
+
+			Eventually(func(g Gomega) {
+				instance := GetDataplaneDeployment(dataplaneDeploymentName)
+				g.Expect(instance.Status.PlanHash).ToNot(BeEmpty())
+				if instance.Annotations == nil {
+					instance.Annotations = map[string]string{}
+				}
+				instance.Annotations[dataplanev1.DeploymentApprovedAnnotation] = instance.Status.PlanHash
+				g.Expect(th.K8sClient.Update(th.Ctx, instance)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			th.ExpectCondition(
+				dataplaneDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.InputReadyCondition,
+				corev1.ConditionTrue,
+			)
+			Expect(GetDataplaneDeployment(dataplaneDeploymentName).Status.StartTime).ToNot(BeNil())
+		})
+
+		It("should wait for a new approval when its plan changed since it was approved", func() {
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			var approvedHash string
+			Eventually(func(g Gomega) {
+				approvedHash = GetDataplaneDeployment(dataplaneDeploymentName).Status.PlanHash
+				g.Expect(approvedHash).ToNot(BeEmpty())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			// The services of the NodeSet change before the approval lands
+			Eventually(func(g Gomega) {
+				nodeSet := GetDataplaneNodeSet(dataplaneNodeSetName)
+				nodeSet.Spec.Services = []string{dataplaneServiceName.Name}
+				g.Expect(th.K8sClient.Update(th.Ctx, nodeSet)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			var planHash string
+			Eventually(func(g Gomega) {
+				deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+				g.Expect(deployment.Status.PlanHash).ToNot(Equal(approvedHash))
+				planHash = deployment.Status.PlanHash
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				instance := GetDataplaneDeployment(dataplaneDeploymentName)
+				if instance.Annotations == nil {
+					instance.Annotations = map[string]string{}
+				}
+				instance.Annotations[dataplanev1.DeploymentApprovedAnnotation] = approvedHash
+				g.Expect(th.K8sClient.Update(th.Ctx, instance)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			th.ExpectConditionWithDetails(
+				dataplaneDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.InputReadyCondition,
+				corev1.ConditionFalse,
+				dataplanev1.WaitingForApprovalReason,
+				fmt.Sprintf(dataplanev1.DeploymentPlanChangedMessage, approvedHash, planHash),
+			)
+			ansibleEEs := &ansibleeev1.OpenStackAnsibleEEList{}
+			Expect(th.K8sClient.List(th.Ctx, ansibleEEs,
+				client.InNamespace(namespace),
+				client.MatchingLabels{"openstackdataplanedeployment": dataplaneDeploymentName.Name})).To(Succeed())
+			Expect(ansibleEEs.Items).To(BeEmpty())
+			Expect(GetDataplaneDeployment(dataplaneDeploymentName).Status.StartTime).To(BeNil())
+		})
+	})
+
+	When("A dataplaneDeployment is created while the ansible executions are limited", func() {
+		BeforeEach(func() {
+			executionQueue.SetLimits(0, 1)
+			DeferCleanup(executionQueue.SetLimits, 0, 0)
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			CreateDataplaneService(dataplaneServiceName, false)
+			CreateDataplaneService(dataplaneGlobalServiceName, true)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteService, dataplaneGlobalServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, DefaultDataPlaneDeploymentSpec()))
+		})
+
+		It("should queue the executions above the limit", func() {
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			// Only one of the two services is started, the other one is queued
+			var queuedCondition *condition.Condition
+			Eventually(func(g Gomega) {
+				deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+				queuedCondition = nil
+				for idx, cond := range deployment.Status.NodeSetConditions[dataplaneNodeSetName.Name] {
+					if cond.Reason == dataplanev1.QueuedReason {
+						queuedCondition = &deployment.Status.NodeSetConditions[dataplaneNodeSetName.Name][idx]
+					}
+				}
+				g.Expect(queuedCondition).ToNot(BeNil())
+				g.Expect(queuedCondition.Message).To(ContainSubstring("queued at position 1"))
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			ansibleEEs := &ansibleeev1.OpenStackAnsibleEEList{}
+			Expect(th.K8sClient.List(th.Ctx, ansibleEEs,
+				client.InNamespace(namespace),
+				client.MatchingLabels{"openstackdataplanedeployment": dataplaneDeploymentName.Name})).To(Succeed())
+			Expect(ansibleEEs.Items).To(HaveLen(1))
+			Expect(queuedCondition.Message).ToNot(HavePrefix(ansibleEEs.Items[0].Labels["openstackdataplaneservice"]))
+
+			// The queued service is started once the running one finished
+			ansibleEE := &ansibleEEs.Items[0]
+			ansibleEE.Status.JobStatus = ansibleeev1.JobStatusSucceeded
+			Expect(th.K8sClient.Status().Update(th.Ctx, ansibleEE)).To(Succeed())
+			Eventually(func(g Gomega) {
+				g.Expect(th.K8sClient.List(th.Ctx, ansibleEEs,
+					client.InNamespace(namespace),
+					client.MatchingLabels{"openstackdataplanedeployment": dataplaneDeploymentName.Name})).To(Succeed())
+				g.Expect(ansibleEEs.Items).To(HaveLen(2))
+			}, th.Timeout, th.Interval).Should(Succeed())
+		})
+	})
+
+	When("A dataplaneDeployment is created with serviceOverrides", func() {
+		BeforeEach(func() {
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			CreateDataplaneService(dataplaneServiceName, false)
+			CreateDataplaneService(dataplaneGlobalServiceName, true)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteService, dataplaneGlobalServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)))
+			deploymentSpec := DefaultDataPlaneDeploymentSpec()
+			deploymentSpec["ansibleTags"] = "common"
+			deploymentSpec["serviceOverrides"] = map[string]interface{}{
+				dataplaneServiceName.Name: map[string]interface{}{
+					"ansibleTags": "foo",
+					"ansibleExtraVars": map[string]interface{}{
+						"foo_var": "bar",
+					},
+				},
+			}
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, deploymentSpec))
+		})
+
+		It("should only apply the overrides to their service", func() {
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			nodeSet := *GetDataplaneNodeSet(dataplaneNodeSetName)
+			for _, serviceName := range nodeSet.Spec.Services {
+				service := GetService(types.NamespacedName{
+					Name:      serviceName,
+					Namespace: namespace,
+				})
+				aeeName, _ := dataplaneutil.GetAnsibleExecutionNameAndLabels(
+					service, dataplaneDeploymentName.Name, nodeSet.GetName())
+				Eventually(func(g Gomega) {
+					ansibleEE := &ansibleeev1.OpenStackAnsibleEE{}
+					g.Expect(th.K8sClient.Get(th.Ctx, types.NamespacedName{
+						Name:      aeeName,
+						Namespace: namespace,
+					}, ansibleEE)).To(Succeed())
+					if serviceName == dataplaneServiceName.Name {
+						g.Expect(ansibleEE.Spec.CmdLine).To(Equal("--tags foo"))
+						g.Expect(string(ansibleEE.Spec.ExtraVars["foo_var"])).To(Equal("\"bar\""))
+					} else {
+						g.Expect(ansibleEE.Spec.CmdLine).To(Equal("--tags common"))
+						g.Expect(ansibleEE.Spec.ExtraVars).ToNot(HaveKey("foo_var"))
+					}
+				}, th.Timeout, th.Interval).Should(Succeed())
+			}
+		})
+	})
+
+	When("A dataplaneDeployment is created in Check mode", func() {
+		BeforeEach(func() {
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			CreateDataplaneService(dataplaneServiceName, false)
+			CreateDataplaneService(dataplaneGlobalServiceName, true)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteService, dataplaneGlobalServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)))
+			deploymentSpec := DefaultDataPlaneDeploymentSpec()
+			deploymentSpec["mode"] = "Check"
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, deploymentSpec))
+		})
+
+		It("should run ansible in check mode and report the changes", func() {
+
+			CreateOVNControllerConfigMap(namespace)
+
+			nodeSet := *GetDataplaneNodeSet(dataplaneNodeSetName)
+
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			for _, serviceName := range nodeSet.Spec.Services {
+				dataplaneServiceName := types.NamespacedName{
+					Name:      serviceName,
+					Namespace: namespace,
+				}
+				service := GetService(dataplaneServiceName)
+				deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+				//Retrieve the AnsibleEE and set JobStatus to Successful
+				aeeName, _ := dataplaneutil.GetAnsibleExecutionNameAndLabels(
+					service, deployment.GetName(), nodeSet.GetName())
+				Eventually(func(g Gomega) {
+					ansibleeeName := types.NamespacedName{
+						Name:      aeeName,
+						Namespace: dataplaneDeploymentName.Namespace,
+					}
+					ansibleEE := &ansibleeev1.OpenStackAnsibleEE{}
+					g.Expect(th.K8sClient.Get(th.Ctx, ansibleeeName, ansibleEE)).To(Succeed())
+					g.Expect(ansibleEE.Spec.CmdLine).To(Equal("--check --diff"))
+					ansibleEE.Status.JobStatus = ansibleeev1.JobStatusSucceeded
+
+					g.Expect(th.K8sClient.Status().Update(th.Ctx, ansibleEE)).To(Succeed())
+				}, th.Timeout, th.Interval).Should(Succeed())
+			}
+
+			th.ExpectCondition(
+				dataplaneDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			Eventually(func(g Gomega) {
+				deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+				g.Expect(deployment.Status.CheckReport).To(Equal(dataplaneDeploymentName.Name + "-check-report"))
+				g.Expect(deployment.Status.NodeSetHashes).To(BeEmpty())
+			}, th.Timeout, th.Interval).Should(Succeed())
+			report := th.GetConfigMap(types.NamespacedName{
+				Name:      dataplaneDeploymentName.Name + "-check-report",
+				Namespace: namespace,
+			})
+			Expect(report.Data).To(HaveKey(dataplaneNodeSetName.Name))
+			Expect(GetDataplaneNodeSet(dataplaneNodeSetName).Status.DeployedConfigHash).To(BeEmpty())
+
+			Eventually(func(g Gomega) {
+				history := GetDataplaneNodeSet(dataplaneNodeSetName).Status.DeploymentHistory
+				g.Expect(history).To(HaveLen(1))
+				g.Expect(history[0].Deployment).To(Equal(dataplaneDeploymentName.Name))
+				g.Expect(history[0].Mode).To(Equal(dataplanev1.DeploymentModeCheck))
+				g.Expect(history[0].Outcome).To(Equal(dataplanev1.DeploymentOutcomeSucceeded))
+				g.Expect(history[0].FinishTime).NotTo(BeNil())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				g.Expect(GetEventReasons(dataplaneDeploymentName)).To(ContainElements(
+					"DeploymentStarted",
+					"ServiceExecutionCreated",
+					"ServiceExecutionSucceeded",
+					"DeploymentSucceeded",
+				))
+				g.Expect(GetEventReasons(dataplaneDeploymentName)).NotTo(ContainElement("ServiceExecutionFailed"))
+				g.Expect(GetEventReasons(dataplaneNodeSetName)).To(ContainElements(
+					"IPReservationReady",
+					"DNSDataReady",
+				))
+			}, th.Timeout, th.Interval).Should(Succeed())
+		})
+	})
+
+	When("A dataplaneDeployment execution fails", func() {
+		BeforeEach(func() {
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			CreateDataplaneService(dataplaneServiceName, false)
+			CreateDataplaneService(dataplaneGlobalServiceName, true)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteService, dataplaneGlobalServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, DefaultDataPlaneDeploymentSpec()))
+		})
+
+		It("should record the results of the execution", func() {
+
+			CreateOVNControllerConfigMap(namespace)
+
+			nodeSet := *GetDataplaneNodeSet(dataplaneNodeSetName)
+
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			serviceName := nodeSet.Spec.Services[0]
+			service := GetService(types.NamespacedName{
+				Name:      serviceName,
+				Namespace: namespace,
+			})
+			deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+			//Retrieve the AnsibleEE and set JobStatus to Failed
+			aeeName, _ := dataplaneutil.GetAnsibleExecutionNameAndLabels(
+				service, deployment.GetName(), nodeSet.GetName())
+			Eventually(func(g Gomega) {
+				ansibleeeName := types.NamespacedName{
+					Name:      aeeName,
+					Namespace: dataplaneDeploymentName.Namespace,
+				}
+				ansibleEE := &ansibleeev1.OpenStackAnsibleEE{}
+				g.Expect(th.K8sClient.Get(th.Ctx, ansibleeeName, ansibleEE)).To(Succeed())
+				ansibleEE.Status.JobStatus = ansibleeev1.JobStatusFailed
+
+				g.Expect(th.K8sClient.Status().Update(th.Ctx, ansibleEE)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			th.ExpectCondition(
+				dataplaneDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.DeploymentReadyCondition,
+				corev1.ConditionFalse,
+			)
+
+			Eventually(func(g Gomega) {
+				deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+				g.Expect(deployment.Status.Results).To(Equal(dataplaneDeploymentName.Name + "-results"))
+				g.Expect(deployment.Status.NodeSetResults).To(HaveKey(dataplaneNodeSetName.Name))
+				g.Expect(deployment.Status.NodeSetResults[dataplaneNodeSetName.Name]).To(HaveKey(serviceName))
+			}, th.Timeout, th.Interval).Should(Succeed())
+			results := th.GetConfigMap(types.NamespacedName{
+				Name:      dataplaneDeploymentName.Name + "-results",
+				Namespace: namespace,
+			})
+			Expect(results.Data).To(HaveKey(dataplaneNodeSetName.Name))
+			Expect(results.Data[dataplaneNodeSetName.Name]).To(ContainSubstring(aeeName))
+			Expect(results.Data[dataplaneNodeSetName.Name]).To(ContainSubstring(ansibleeev1.JobStatusFailed))
+
+			Eventually(func(g Gomega) {
+				deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+				g.Expect(deployment.Status.FinishTime).NotTo(BeNil())
+				history := GetDataplaneNodeSet(dataplaneNodeSetName).Status.DeploymentHistory
+				g.Expect(history).To(HaveLen(1))
+				g.Expect(history[0].Deployment).To(Equal(dataplaneDeploymentName.Name))
+				g.Expect(history[0].Outcome).To(Equal(dataplanev1.DeploymentOutcomeFailed))
+				g.Expect(history[0].FinishTime).NotTo(BeNil())
+				g.Expect(history[0].StartTime.Time).To(BeTemporally("==", deployment.CreationTimestamp.Time))
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				g.Expect(GetEventReasons(dataplaneDeploymentName)).To(ContainElements(
+					"DeploymentStarted",
+					"ServiceExecutionCreated",
+					"ServiceExecutionFailed",
+					"DeploymentFailed",
+				))
+			}, th.Timeout, th.Interval).Should(Succeed())
+		})
+
+		It("should report why the execution failed", func() {
+
+			CreateOVNControllerConfigMap(namespace)
+
+			nodeSet := *GetDataplaneNodeSet(dataplaneNodeSetName)
+
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			service := GetService(types.NamespacedName{
+				Name:      nodeSet.Spec.Services[0],
+				Namespace: namespace,
+			})
+			deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+			aeeName, _ := dataplaneutil.GetAnsibleExecutionNameAndLabels(
+				service, deployment.GetName(), nodeSet.GetName())
+
+			// Create the failed pod of the AnsibleEE
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      aeeName + "-pod",
+					Namespace: namespace,
+					Labels:    map[string]string{"job-name": aeeName},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "ansibleee", Image: "ansibleee"}},
+				},
+			}
+			Expect(th.K8sClient.Create(th.Ctx, pod)).To(Succeed())
+			DeferCleanup(th.DeleteInstance, pod)
+			pod.Status.Phase = corev1.PodFailed
+			pod.Status.Message = "ansible failed with password=hunter2"
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+				Name:  "ansibleee",
+				Image: "ansibleee",
+				State: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{
+						Reason:   "Error",
+						ExitCode: 2,
+					},
+				},
+			}}
+			Expect(th.K8sClient.Status().Update(th.Ctx, pod)).To(Succeed())
+
+			//Retrieve the AnsibleEE and set JobStatus to Failed
+			Eventually(func(g Gomega) {
+				ansibleeeName := types.NamespacedName{
+					Name:      aeeName,
+					Namespace: dataplaneDeploymentName.Namespace,
+				}
+				ansibleEE := &ansibleeev1.OpenStackAnsibleEE{}
+				g.Expect(th.K8sClient.Get(th.Ctx, ansibleeeName, ansibleEE)).To(Succeed())
+				ansibleEE.Status.JobStatus = ansibleeev1.JobStatusFailed
+
+				g.Expect(th.K8sClient.Status().Update(th.Ctx, ansibleEE)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				deployment := GetDataplaneDeployment(dataplaneDeploymentName)
+				deploymentReady := deployment.Status.Conditions.Get(condition.DeploymentReadyCondition)
+				g.Expect(deploymentReady).NotTo(BeNil())
+				g.Expect(deploymentReady.Message).To(ContainSubstring("container reason: Error"))
+				g.Expect(deploymentReady.Message).To(ContainSubstring("exit code: 2"))
+				g.Expect(deploymentReady.Message).To(ContainSubstring("password=<redacted>"))
+				g.Expect(deploymentReady.Message).NotTo(ContainSubstring("hunter2"))
+			}, th.Timeout, th.Interval).Should(Succeed())
+		})
+	})
+
+	When("A failed dataplaneDeployment is resumed", func() {
+		var resumedDeploymentName types.NamespacedName
+		var serviceConfigMapName types.NamespacedName
+		var serviceSecretName types.NamespacedName
+		fooServiceReady := condition.Type("ServiceFooServiceDeploymentReady")
+
+		BeforeEach(func() {
+			resumedDeploymentName = types.NamespacedName{
+				Name:      "edpm-deployment-resumed",
+				Namespace: namespace,
+			}
+			serviceConfigMapName = types.NamespacedName{
+				Name:      "foo-service-config",
+				Namespace: namespace,
+			}
+			serviceSecretName = types.NamespacedName{
+				Name:      "foo-service-secret",
+				Namespace: namespace,
+			}
+			CreateSSHSecret(dataplaneSSHSecretName)
+			CreateServiceSecrets(namespace)
+			DeferCleanup(th.DeleteInstance, th.CreateConfigMap(serviceConfigMapName, map[string]interface{}{
+				"foo.conf": "foo=bar",
+			}))
+			DeferCleanup(th.DeleteInstance, th.CreateSecret(serviceSecretName, map[string][]byte{
+				"password": []byte("foo"),
+			}))
+			CreateDataplaneServiceWithSpec(dataplaneServiceName, map[string]interface{}{
+				"configMaps": []string{serviceConfigMapName.Name},
+				"secrets":    []string{serviceSecretName.Name},
+			})
+			CreateDataplaneService(dataplaneGlobalServiceName, true)
+
+			DeferCleanup(th.DeleteService, dataplaneServiceName)
+			DeferCleanup(th.DeleteService, dataplaneGlobalServiceName)
+			DeferCleanup(th.DeleteInstance, CreateNetConfig(dataplaneNetConfigName, DefaultNetConfigSpec()))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, DefaultDataPlaneNodeSetSpec(dataplaneNodeSetName.Name)))
+		})
+
+		// failFirstDeployment runs a first Deployment with the given spec, in
+		// which foo-service succeeds and global-service fails
+		failFirstDeployment := func(deploymentSpec map[string]interface{}, batch int) {
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, deploymentSpec))
+			SimulateBaremetalSetReady(dataplaneNodeSetName)
+
+			SetAnsibleeeJobStatus(dataplaneDeploymentName, dataplaneNodeSetName.Name,
+				dataplaneServiceName.Name, batch, ansibleeev1.JobStatusSucceeded)
+			SetAnsibleeeJobStatus(dataplaneDeploymentName, dataplaneNodeSetName.Name,
+				dataplaneGlobalServiceName.Name, batch, ansibleeev1.JobStatusFailed)
+			Eventually(func(g Gomega) {
+				instance := GetDataplaneDeployment(dataplaneDeploymentName)
+				g.Expect(instance.IsFailed()).To(BeTrue())
+				nsConditions := instance.Status.NodeSetConditions[dataplaneNodeSetName.Name]
+				g.Expect(nsConditions.IsTrue(fooServiceReady)).To(BeTrue())
+			}, th.Timeout, th.Interval).Should(Succeed())
+		}
+
+		resumeDeployment := func() {
+			deploymentSpec := DefaultDataPlaneDeploymentSpec()
+			deploymentSpec["resumeFrom"] = dataplaneDeploymentName.Name
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(resumedDeploymentName, deploymentSpec))
+		}
+
+		ansibleEEExists := func(serviceName types.NamespacedName) bool {
+			aeeName, _ := dataplaneutil.GetAnsibleExecutionNameAndLabels(
+				GetService(serviceName), resumedDeploymentName.Name, dataplaneNodeSetName.Name)
+			return th.K8sClient.Get(th.Ctx, types.NamespacedName{Name: aeeName, Namespace: namespace},
+				&ansibleeev1.OpenStackAnsibleEE{}) == nil
+		}
+
+		// expectRerun checks the resumed Deployment runs foo-service again
+		expectRerun := func() {
+			Eventually(func() bool {
+				return ansibleEEExists(dataplaneServiceName)
+			}, th.Timeout, th.Interval).Should(BeTrue())
+			Expect(ansibleEEExists(dataplaneGlobalServiceName)).To(BeFalse())
+		}
+
+		It("should skip the services which succeeded", func() {
+			failFirstDeployment(DefaultDataPlaneDeploymentSpec(), 0)
+			resumeDeployment()
+
+			Eventually(func() bool {
+				return ansibleEEExists(dataplaneGlobalServiceName)
+			}, th.Timeout, th.Interval).Should(BeTrue())
+			Expect(ansibleEEExists(dataplaneServiceName)).To(BeFalse())
+			Eventually(func(g Gomega) {
+				nsConditions := GetDataplaneDeployment(resumedDeploymentName).Status.NodeSetConditions[dataplaneNodeSetName.Name]
+				fooReady := nsConditions.Get(fooServiceReady)
+				g.Expect(fooReady).NotTo(BeNil())
+				g.Expect(fooReady.Status).To(Equal(corev1.ConditionTrue))
+				g.Expect(fooReady.Message).To(Equal(fmt.Sprintf(dataplanev1.NodeSetServiceDeploymentResumedMessage,
+					dataplaneServiceName.Name, dataplaneDeploymentName.Name)))
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			SetAnsibleeeJobStatus(resumedDeploymentName, dataplaneNodeSetName.Name,
+				dataplaneGlobalServiceName.Name, 0, ansibleeev1.JobStatusSucceeded)
+			th.ExpectCondition(
+				resumedDeploymentName,
+				ConditionGetterFunc(DataplaneDeploymentConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+
+		It("should rerun a service whose ConfigMap changed", func() {
+			failFirstDeployment(DefaultDataPlaneDeploymentSpec(), 0)
+			Eventually(func(g Gomega) {
+				configMap := th.GetConfigMap(serviceConfigMapName)
+				configMap.Data["foo.conf"] = "foo=baz"
+				g.Expect(th.K8sClient.Update(th.Ctx, configMap)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+			resumeDeployment()
+
+			expectRerun()
+		})
+
+		It("should rerun a service whose Secret changed", func() {
+			failFirstDeployment(DefaultDataPlaneDeploymentSpec(), 0)
+			Eventually(func(g Gomega) {
+				secret := th.GetSecret(serviceSecretName)
+				secret.Data["password"] = []byte("bar")
+				g.Expect(th.K8sClient.Update(th.Ctx, &secret)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+			resumeDeployment()
+
+			expectRerun()
+		})
+
+		It("should not skip the services of a Deployment run in batches", func() {
+			deploymentSpec := DefaultDataPlaneDeploymentSpec()
+			deploymentSpec["rolloutStrategy"] = map[string]interface{}{
+				"batchSize": 1,
+			}
+			failFirstDeployment(deploymentSpec, 1)
+			resumeDeployment()
+
+			expectRerun()
+		})
+
+		It("should not skip the services of a Deployment with a canary", func() {
+			deploymentSpec := DefaultDataPlaneDeploymentSpec()
+			deploymentSpec["canary"] = map[string]interface{}{
+				"count": 1,
+			}
+			failFirstDeployment(deploymentSpec, 1)
+			resumeDeployment()
+
+			expectRerun()
+		})
+
+		It("should not skip the services of a Deployment run in Check mode", func() {
+			deploymentSpec := DefaultDataPlaneDeploymentSpec()
+			deploymentSpec["mode"] = "Check"
+			failFirstDeployment(deploymentSpec, 0)
+			resumeDeployment()
+
+			expectRerun()
+		})
+
+		It("should not skip the services of a Deployment limited to some hosts", func() {
+			deploymentSpec := DefaultDataPlaneDeploymentSpec()
+			deploymentSpec["ansibleLimit"] = "edpm-bm-compute-1"
+			failFirstDeployment(deploymentSpec, 0)
+			resumeDeployment()
+
+			expectRerun()
+		})
+	})
 })
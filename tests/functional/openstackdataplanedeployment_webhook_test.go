@@ -0,0 +1,97 @@
+package functional
+
+import (
+	"fmt"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2" //revive:disable:dot-imports
+	. "github.com/onsi/gomega"    //revive:disable:dot-imports
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("DataplaneDeployment Webhook", func() {
+
+	var dataplaneDeploymentName types.NamespacedName
+
+	BeforeEach(func() {
+		dataplaneDeploymentName = types.NamespacedName{
+			Name:      "edpm-deployment",
+			Namespace: namespace,
+		}
+		err := os.Setenv("OPERATOR_SERVICES", "../../config/services")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	// createDeployment creates a Deployment of the alpha and beta NodeSets
+	// with the given strategy, returning the error of the webhook
+	createDeployment := func(nodeSetStrategy map[string]interface{}) error {
+		deploymentSpec := map[string]interface{}{
+			"nodeSets": []string{
+				"alpha-nodeset",
+				"beta-nodeset",
+			},
+			"nodeSetStrategy": nodeSetStrategy,
+		}
+		raw := DefaultDataplaneDeploymentTemplate(dataplaneDeploymentName, deploymentSpec)
+		err := th.K8sClient.Create(th.Ctx, &unstructured.Unstructured{Object: raw})
+		if err == nil {
+			DeferCleanup(th.DeleteInstance, GetDataplaneDeployment(dataplaneDeploymentName))
+		}
+		return err
+	}
+
+	When("A user creates a Deployment with waves", func() {
+		It("Should accept each NodeSet in exactly one wave", func() {
+			Expect(createDeployment(map[string]interface{}{
+				"type": "Waves",
+				"waves": [][]string{
+					{"alpha-nodeset"},
+					{"beta-nodeset"},
+				},
+			})).To(Succeed())
+		})
+
+		It("Should block a NodeSet which is not part of any wave", func() {
+			err := createDeployment(map[string]interface{}{
+				"type": "Waves",
+				"waves": [][]string{
+					{"alpha-nodeset"},
+				},
+			})
+			Expect(fmt.Sprintf("%s", err)).To(ContainSubstring("NodeSet beta-nodeset is not part of any wave"))
+		})
+
+		It("Should block a NodeSet which is part of two waves", func() {
+			err := createDeployment(map[string]interface{}{
+				"type": "Waves",
+				"waves": [][]string{
+					{"alpha-nodeset", "beta-nodeset"},
+					{"beta-nodeset"},
+				},
+			})
+			Expect(fmt.Sprintf("%s", err)).To(ContainSubstring(`spec.nodeSetStrategy.waves[1]: Duplicate value: "beta-nodeset"`))
+		})
+
+		It("Should block a wave with a NodeSet of another Deployment", func() {
+			err := createDeployment(map[string]interface{}{
+				"type": "Waves",
+				"waves": [][]string{
+					{"alpha-nodeset", "beta-nodeset"},
+					{"gamma-nodeset"},
+				},
+			})
+			Expect(fmt.Sprintf("%s", err)).To(ContainSubstring("NodeSet gamma-nodeset is not in the nodeSets list"))
+		})
+
+		It("Should block waves without the Waves type", func() {
+			err := createDeployment(map[string]interface{}{
+				"type": "Serial",
+				"waves": [][]string{
+					{"alpha-nodeset", "beta-nodeset"},
+				},
+			})
+			Expect(fmt.Sprintf("%s", err)).To(ContainSubstring("waves can only be set with the Waves type"))
+		})
+	})
+})
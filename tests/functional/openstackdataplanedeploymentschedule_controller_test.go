@@ -0,0 +1,88 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package functional
+
+import (
+	. "github.com/onsi/ginkgo/v2" //revive:disable:dot-imports
+	. "github.com/onsi/gomega"    //revive:disable:dot-imports
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
+
+	//revive:disable-next-line:dot-imports
+	. "github.com/openstack-k8s-operators/lib-common/modules/common/test/helpers"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("Dataplane Deployment Schedule Test", func() {
+	var dataplaneDeploymentScheduleName types.NamespacedName
+
+	BeforeEach(func() {
+		dataplaneDeploymentScheduleName = types.NamespacedName{
+			Name:      "edpm-nightly",
+			Namespace: namespace,
+		}
+	})
+
+	When("A dataplaneDeploymentSchedule is created", func() {
+		BeforeEach(func() {
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeploymentSchedule(dataplaneDeploymentScheduleName, map[string]interface{}{
+				"schedule":           "0 2 * * *",
+				"deploymentTemplate": DefaultDataPlaneDeploymentSpec(),
+			}))
+		})
+
+		It("Should have Spec fields initialized", func() {
+			schedule := GetDataplaneDeploymentSchedule(dataplaneDeploymentScheduleName)
+			Expect(schedule.Spec.ConcurrencyPolicy).Should(Equal(dataplanev1.ConcurrencyPolicyForbid))
+			Expect(schedule.Spec.HistoryLimit).Should(Equal(3))
+			Expect(schedule.Spec.DeploymentTemplate.NodeSets).Should(Equal([]string{"edpm-compute-nodeset"}))
+		})
+
+		It("Should compute the next schedule time", func() {
+			th.ExpectCondition(
+				dataplaneDeploymentScheduleName,
+				ConditionGetterFunc(DataplaneDeploymentScheduleConditionGetter),
+				dataplanev1.DeploymentScheduleReadyCondition,
+				corev1.ConditionTrue,
+			)
+			schedule := GetDataplaneDeploymentSchedule(dataplaneDeploymentScheduleName)
+			Expect(schedule.Status.NextScheduleTime).ShouldNot(BeNil())
+			Expect(schedule.Status.NextScheduleTime.UTC().Hour()).Should(Equal(2))
+			Expect(schedule.Status.NextScheduleTime.UTC().Minute()).Should(Equal(0))
+			Expect(schedule.Status.Active).Should(BeEmpty())
+		})
+	})
+
+	When("A dataplaneDeploymentSchedule is created with an invalid schedule", func() {
+		BeforeEach(func() {
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeploymentSchedule(dataplaneDeploymentScheduleName, map[string]interface{}{
+				"schedule":           "0 25 * * *",
+				"deploymentTemplate": DefaultDataPlaneDeploymentSpec(),
+			}))
+		})
+
+		It("Should report the schedule error", func() {
+			th.ExpectConditionWithDetails(
+				dataplaneDeploymentScheduleName,
+				ConditionGetterFunc(DataplaneDeploymentScheduleConditionGetter),
+				dataplanev1.DeploymentScheduleReadyCondition,
+				corev1.ConditionFalse,
+				"Error",
+				"Schedule error occurred invalid hour in cron schedule \"0 25 * * *\": value 25 out of range [0-23]",
+			)
+		})
+	})
+})
@@ -30,6 +30,7 @@ import (
 	baremetalv1 "github.com/openstack-k8s-operators/openstack-baremetal-operator/api/v1beta1"
 	"gopkg.in/yaml.v3"
 	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -470,6 +471,20 @@ var _ = Describe("Dataplane NodeSet Test", func() {
 					corev1.ConditionTrue,
 				)
 			})
+			It("Should expose the NodeSet metrics", func() {
+				labels := map[string]string{
+					"namespace": dataplaneNodeSetName.Namespace,
+					"nodeset":   dataplaneNodeSetName.Name,
+				}
+				Eventually(func(g Gomega) {
+					nodes, found := GetMetricValue("dataplane_nodeset_nodes", labels)
+					g.Expect(found).To(BeTrue())
+					g.Expect(nodes).To(Equal(0.0))
+					drift, found := GetMetricValue("dataplane_nodeset_config_drift", labels)
+					g.Expect(found).To(BeTrue())
+					g.Expect(drift).To(Equal(1.0))
+				}, timeout, interval).Should(Succeed())
+			})
 		})
 
 		When("No default service image is provided", func() {
@@ -611,6 +626,14 @@ var _ = Describe("Dataplane NodeSet Test", func() {
 					return getCtlPlaneIP(&secret)
 				}).Should(Equal("172.20.12.76"))
 			})
+			It("Should record the IPs of the node in the nodes status", func() {
+				Eventually(func(g Gomega) {
+					instance := GetDataplaneNodeSet(dataplaneNodeSetName)
+					g.Expect(instance.Status.Nodes).Should(HaveKey("edpm-bm-compute-1"))
+					g.Expect(instance.Status.Nodes["edpm-bm-compute-1"].IPs).Should(ContainElement("172.20.12.76"))
+					g.Expect(instance.Status.Nodes["edpm-bm-compute-1"].LastDeployment).Should(BeEmpty())
+				}, th.Timeout, th.Interval).Should(Succeed())
+			})
 		})
 
 		When("A DataPlaneNodeSet is created with NoNodes and a OpenStackDataPlaneDeployment is created", func() {
@@ -1030,6 +1053,95 @@ var _ = Describe("Dataplane NodeSet Test", func() {
 		})
 	})
 
+	When("A NodeSet is created with an autoDeploy policy", func() {
+		BeforeEach(func() {
+			nodeSetSpec := DefaultDataPlaneNoNodeSetSpec(true)
+			nodeSetSpec["autoDeploy"] = map[string]interface{}{
+				"debounceSeconds": 0,
+			}
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, nodeSetSpec))
+			CreateSSHSecret(dataplaneSSHSecretName)
+		})
+
+		It("Should create a Deployment for the NodeSet", func() {
+			var deploymentName string
+			Eventually(func(g Gomega) {
+				instance := GetDataplaneNodeSet(dataplaneNodeSetName)
+				g.Expect(instance.Status.AutoDeploy).ShouldNot(BeNil())
+				g.Expect(instance.Status.AutoDeploy.LastDeployment).ShouldNot(BeEmpty())
+				deploymentName = instance.Status.AutoDeploy.LastDeployment
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			deployment := GetDataplaneDeployment(types.NamespacedName{
+				Name:      deploymentName,
+				Namespace: namespace,
+			})
+			Expect(deployment.Spec.NodeSets).Should(Equal([]string{dataplaneNodeSetName.Name}))
+			Expect(deployment.Annotations).Should(HaveKey(dataplanev1.AutoDeployHashAnnotation))
+			Expect(deployment.Name).Should(HaveSuffix("-1"))
+			DeferCleanup(th.DeleteInstance, deployment)
+		})
+	})
+
+	When("The Deployment of a NodeSet is cancelled", func() {
+		BeforeEach(func() {
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, DefaultDataPlaneNoNodeSetSpec(true)))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, DefaultDataPlaneDeploymentSpec()))
+			CreateSSHSecret(dataplaneSSHSecretName)
+		})
+
+		It("Should not report the Deployment in progress", func() {
+			Eventually(func(g Gomega) {
+				instance := GetDataplaneDeployment(dataplaneDeploymentName)
+				if instance.Annotations == nil {
+					instance.Annotations = map[string]string{}
+				}
+				instance.Annotations[dataplanev1.DeploymentCancelAnnotation] = "true"
+				g.Expect(th.K8sClient.Update(th.Ctx, instance)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+			Eventually(func(g Gomega) {
+				g.Expect(GetDataplaneDeployment(dataplaneDeploymentName).Status.Cancelled).To(BeTrue())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				instance := GetDataplaneNodeSet(dataplaneNodeSetName)
+				g.Expect(instance.Status.DeploymentStatuses).To(HaveKey(dataplaneDeploymentName.Name))
+				deploymentReady := instance.Status.Conditions.Get(condition.DeploymentReadyCondition)
+				g.Expect(deploymentReady).NotTo(BeNil())
+				g.Expect(deploymentReady.Status).To(Equal(corev1.ConditionFalse))
+				g.Expect(deploymentReady.Message).To(Equal(condition.DeploymentReadyInitMessage))
+			}, th.Timeout, th.Interval).Should(Succeed())
+		})
+	})
+
+	When("A NodeSet is created with a deploymentRetention and its Deployment is cancelled", func() {
+		BeforeEach(func() {
+			nodeSetSpec := DefaultDataPlaneNoNodeSetSpec(true)
+			nodeSetSpec["deploymentRetention"] = map[string]interface{}{
+				"failed": 0,
+			}
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, nodeSetSpec))
+			DeferCleanup(th.DeleteInstance, CreateDataplaneDeployment(dataplaneDeploymentName, DefaultDataPlaneDeploymentSpec()))
+			CreateSSHSecret(dataplaneSSHSecretName)
+		})
+
+		It("Should delete the cancelled Deployment", func() {
+			Eventually(func(g Gomega) {
+				instance := GetDataplaneDeployment(dataplaneDeploymentName)
+				if instance.Annotations == nil {
+					instance.Annotations = map[string]string{}
+				}
+				instance.Annotations[dataplanev1.DeploymentCancelAnnotation] = "true"
+				g.Expect(th.K8sClient.Update(th.Ctx, instance)).To(Succeed())
+			}, th.Timeout, th.Interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				err := th.K8sClient.Get(th.Ctx, dataplaneDeploymentName, &dataplanev1.OpenStackDataPlaneDeployment{})
+				g.Expect(k8s_errors.IsNotFound(err)).To(BeTrue())
+			}, th.Timeout, th.Interval).Should(Succeed())
+		})
+	})
+
 	When("A user changes spec field that would require a new Ansible execution", func() {
 		BeforeEach(func() {
 			nodeSetSpec := DefaultDataPlaneNodeSetSpec("edpm-compute")
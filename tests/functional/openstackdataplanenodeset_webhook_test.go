@@ -11,6 +11,7 @@ import (
 
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
+	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
 	baremetalv1 "github.com/openstack-k8s-operators/openstack-baremetal-operator/api/v1beta1"
 )
 
@@ -181,4 +182,38 @@ var _ = Describe("DataplaneNodeSet Webhook", func() {
 			}).Should(ContainSubstring("already exists in another cluster"))
 		})
 	})
+
+	When("A user sets the maintenance windows of a NodeSet", func() {
+		It("Should block an invalid schedule", func() {
+			nodeSetSpec := DefaultDataPlaneNoNodeSetSpec(false)
+			nodeSetSpec["maintenanceWindows"] = []map[string]interface{}{
+				{
+					"schedule":        "0 25 * * *",
+					"durationMinutes": 60,
+				},
+			}
+			raw := DefaultDataplaneNodeSetTemplate(dataplaneNodeSetName, nodeSetSpec)
+			unstructuredObj := &unstructured.Unstructured{Object: raw}
+			_, err := controllerutil.CreateOrPatch(
+				th.Ctx, th.K8sClient, unstructuredObj, func() error { return nil })
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring("invalid hour in cron schedule"))
+		})
+
+		It("Should block a schedule which never matches", func() {
+			nodeSetSpec := DefaultDataPlaneNoNodeSetSpec(false)
+			DeferCleanup(th.DeleteInstance, CreateDataplaneNodeSet(dataplaneNodeSetName, nodeSetSpec))
+
+			Eventually(func(_ Gomega) string {
+				instance := GetDataplaneNodeSet(dataplaneNodeSetName)
+				instance.Spec.MaintenanceWindows = []dataplanev1.MaintenanceWindow{
+					{
+						Schedule:        "0 0 31 2 *",
+						DurationMinutes: 60,
+					},
+				}
+				return fmt.Sprintf("%s", th.K8sClient.Update(th.Ctx, instance))
+			}).Should(ContainSubstring("the maintenance window never opens"))
+		})
+	})
 })
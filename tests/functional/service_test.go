@@ -45,6 +45,7 @@ var _ = Describe("OpenstackDataplaneService Test", func() {
 			Expect(service.Spec.Playbook).To(BeEmpty())
 			Expect(service.Spec.ConfigMaps).To(BeEmpty())
 			Expect(service.Spec.DeployOnAllNodeSets).To(BeFalse())
+			Expect(service.Spec.DependsOn).To(BeEmpty())
 		})
 	})
 
@@ -61,6 +62,7 @@ var _ = Describe("OpenstackDataplaneService Test", func() {
 			Expect(service.Spec.Playbook).To(BeEmpty())
 			Expect(service.Spec.ConfigMaps).To(BeEmpty())
 			Expect(service.Spec.DeployOnAllNodeSets).To(BeTrue())
+			Expect(service.Spec.DependsOn).To(BeEmpty())
 		})
 	})
 })
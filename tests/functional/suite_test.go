@@ -43,6 +43,7 @@ import (
 
 	dataplanev1 "github.com/openstack-k8s-operators/dataplane-operator/api/v1beta1"
 	"github.com/openstack-k8s-operators/dataplane-operator/controllers"
+	"github.com/openstack-k8s-operators/dataplane-operator/pkg/deployment"
 	infrav1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
 	aee "github.com/openstack-k8s-operators/openstack-ansibleee-operator/api/v1beta1"
 	baremetalv1 "github.com/openstack-k8s-operators/openstack-baremetal-operator/api/v1beta1"
@@ -64,6 +65,9 @@ var (
 	logger    logr.Logger
 	th        *TestHelper
 	namespace string
+	// executionQueue limits the ansible executions of the Deployments, the
+	// tests set its limits
+	executionQueue *deployment.ExecutionQueue
 )
 
 const (
@@ -178,13 +182,24 @@ var _ = BeforeSuite(func() {
 	kclient, err := kubernetes.NewForConfig(cfg)
 	Expect(err).ToNot(HaveOccurred(), "failed to create kclient")
 	err = (&controllers.OpenStackDataPlaneNodeSetReconciler{
-		Client:  k8sManager.GetClient(),
-		Scheme:  k8sManager.GetScheme(),
-		Kclient: kclient,
+		Client:   k8sManager.GetClient(),
+		Scheme:   k8sManager.GetScheme(),
+		Kclient:  kclient,
+		Recorder: k8sManager.GetEventRecorderFor("openstackdataplanenodeset-controller"),
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
+	executionQueue = deployment.NewExecutionQueue(0, 0)
 	err = (&controllers.OpenStackDataPlaneDeploymentReconciler{
+		Client:         k8sManager.GetClient(),
+		Scheme:         k8sManager.GetScheme(),
+		Kclient:        kclient,
+		Recorder:       k8sManager.GetEventRecorderFor("openstackdataplanedeployment-controller"),
+		ExecutionQueue: executionQueue,
+	}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&controllers.OpenStackDataPlaneDeploymentScheduleReconciler{
 		Client:  k8sManager.GetClient(),
 		Scheme:  k8sManager.GetScheme(),
 		Kclient: kclient,